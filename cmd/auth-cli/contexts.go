@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
+)
+
+// printProfiles writes a table of all profiles and their token expiry
+func printProfiles(out io.Writer, file *profiles.File) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CURRENT\tNAME\tSERVER\tUSER\tEXPIRES")
+
+	for _, p := range file.Profiles {
+		current := ""
+		if p.Name == file.CurrentContext {
+			current = "*"
+		}
+
+		user, expires := "-", "not logged in"
+		if creds, err := store.LoadCredentials(p.Name); err == nil {
+			user = creds.UserInfo.Email
+			if creds.Expired() {
+				expires = "expired"
+			} else {
+				expires = fmt.Sprintf("%s (%s)", creds.ExpiresAt.Format(time.RFC3339), time.Until(creds.ExpiresAt).Round(time.Minute))
+			}
+		}
+
+		server := p.Server
+		if server == "" {
+			server = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", current, p.Name, server, user, expires)
+	}
+
+	_ = w.Flush()
+}
+
+func setContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-context NAME",
+		Short: "Create or update a profile",
+		Long:  "Create or update a named profile with the API server URL and OAuth client used to log in to it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := store.Load()
+			if err != nil {
+				return err
+			}
+
+			profile := profiles.Profile{Name: args[0]}
+			if existing, err := file.Get(args[0]); err == nil {
+				profile = *existing
+			}
+			if cmd.Flags().Changed("server") {
+				profile.Server, _ = cmd.Flags().GetString("server")
+			}
+			if cmd.Flags().Changed("provider") {
+				profile.Provider, _ = cmd.Flags().GetString("provider")
+			}
+			if cmd.Flags().Changed("client-id") {
+				profile.ClientID, _ = cmd.Flags().GetString("client-id")
+			}
+			if cmd.Flags().Changed("client-secret") {
+				profile.ClientSecret, _ = cmd.Flags().GetString("client-secret")
+			}
+
+			if err := file.Set(profile); err != nil {
+				return err
+			}
+			if use, _ := cmd.Flags().GetBool("use"); use || file.CurrentContext == "" {
+				file.CurrentContext = profile.Name
+			}
+			if err := store.Save(file); err != nil {
+				return fmt.Errorf("failed to save profiles: %w", err)
+			}
+
+			fmt.Printf("Profile %q saved\n", profile.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("server", "", "API server URL, e.g. https://api.dev.example.com")
+	cmd.Flags().String("provider", "", "Identity provider name (defaults to google)")
+	cmd.Flags().String("client-id", "", "OAuth client ID used to log in (defaults to GOOGLE_CLIENT_ID)")
+	cmd.Flags().String("client-secret", "", "OAuth client secret of --client-id (defaults to none)")
+	cmd.Flags().Bool("use", false, "Make this the current context")
+
+	return cmd
+}
+
+func useContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "use-context NAME",
+		Short: "Switch the current profile",
+		Long:  "Set the profile used by commands that are run without --profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := store.Load()
+			if err != nil {
+				return err
+			}
+			if _, err := file.Get(args[0]); err != nil {
+				return fmt.Errorf("%w. Create it with 'auth-cli set-context %s --server <url>'", err, args[0])
+			}
+
+			file.CurrentContext = args[0]
+			if err := store.Save(file); err != nil {
+				return fmt.Errorf("failed to save profiles: %w", err)
+			}
+
+			fmt.Printf("Switched to profile %q\n", args[0])
+			return nil
+		},
+	}
+}
+
+func deleteContextCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-context NAME",
+		Short: "Delete a profile",
+		Long:  "Delete a profile and its stored credentials",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := store.Load()
+			if err != nil {
+				return err
+			}
+			if err := file.Delete(args[0]); err != nil {
+				return err
+			}
+			if err := store.DeleteCredentials(args[0]); err != nil {
+				return fmt.Errorf("failed to remove credentials: %w", err)
+			}
+			if err := store.Save(file); err != nil {
+				return fmt.Errorf("failed to save profiles: %w", err)
+			}
+
+			fmt.Printf("Deleted profile %q\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// deviceCodeGrantType is the grant type for polling the token endpoint (RFC 8628)
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Device flow defaults used when the provider omits interval or expires_in
+const (
+	defaultDevicePollInterval = 5 * time.Second
+	defaultDeviceCodeLifetime = 10 * time.Minute
+	deviceSlowDownIncrement   = 5 * time.Second
+)
+
+// deviceAuthResponse is the device authorization endpoint response.
+// Google returns verification_url instead of the standard verification_uri.
+type deviceAuthResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURL         string `json:"verification_url"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// deviceTokenError is the error body returned by the token endpoint while polling
+type deviceTokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// performDeviceLogin runs the OAuth 2.0 device authorization flow, which
+// needs no local browser or callback port
+func performDeviceLogin(ctx context.Context, endpoint *loginEndpoint) error {
+	if endpoint.DeviceAuthURL == "" {
+		return fmt.Errorf("identity provider %q does not support the device authorization flow", endpoint.Provider)
+	}
+
+	auth, err := requestDeviceCode(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	verificationURI := auth.VerificationURI
+	if verificationURI == "" {
+		verificationURI = auth.VerificationURL
+	}
+
+	fmt.Printf("To sign in, visit:\n\n    %s\n\nand enter the code: %s\n\n", verificationURI, auth.UserCode)
+	if auth.VerificationURIComplete != "" {
+		fmt.Printf("Or open this link directly:\n\n    %s\n\n", auth.VerificationURIComplete)
+	}
+	fmt.Println("Waiting for authorization...")
+
+	tokenResp, err := pollDeviceToken(ctx, endpoint, auth, sleepContext)
+	if err != nil {
+		return err
+	}
+
+	return completeLogin(endpoint, tokenResp)
+}
+
+// requestDeviceCode obtains a device code and user code from the provider
+func requestDeviceCode(ctx context.Context, endpoint *loginEndpoint) (*deviceAuthResponse, error) {
+	data := url.Values{
+		"client_id": {endpoint.ClientID},
+		"scope":     {strings.Join(endpoint.Scopes, " ")},
+	}
+
+	body, status, err := postForm(ctx, endpoint.DeviceAuthURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request device code: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("device authorization failed: %s", string(body))
+	}
+
+	var auth deviceAuthResponse
+	if err := json.Unmarshal(body, &auth); err != nil {
+		return nil, fmt.Errorf("failed to parse device authorization response: %w", err)
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" {
+		return nil, fmt.Errorf("device authorization response is missing device_code or user_code")
+	}
+
+	return &auth, nil
+}
+
+// pollDeviceToken polls the token endpoint until the user approves or denies
+// the request, or the device code expires. sleep is injectable for tests.
+func pollDeviceToken(ctx context.Context, endpoint *loginEndpoint, auth *deviceAuthResponse, sleep func(context.Context, time.Duration) error) (*models.OAuthTokenResponse, error) {
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollInterval
+	}
+
+	lifetime := time.Duration(auth.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultDeviceCodeLifetime
+	}
+	deadline := time.Now().Add(lifetime)
+
+	data := url.Values{
+		"client_id":   {endpoint.ClientID},
+		"device_code": {auth.DeviceCode},
+		"grant_type":  {deviceCodeGrantType},
+	}
+	if endpoint.ClientSecret != "" {
+		data.Set("client_secret", endpoint.ClientSecret)
+	}
+
+	for {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("device code expired before authorization completed")
+		}
+
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+
+		body, status, err := postForm(ctx, endpoint.TokenURL, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll token endpoint: %w", err)
+		}
+
+		if status == http.StatusOK {
+			var tokenResp models.OAuthTokenResponse
+			if err := json.Unmarshal(body, &tokenResp); err != nil {
+				return nil, fmt.Errorf("failed to parse token response: %w", err)
+			}
+			return &tokenResp, nil
+		}
+
+		var tokenErr deviceTokenError
+		if err := json.Unmarshal(body, &tokenErr); err != nil {
+			return nil, fmt.Errorf("token request failed: %s", string(body))
+		}
+
+		switch tokenErr.Error {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += deviceSlowDownIncrement
+		case "access_denied":
+			return nil, fmt.Errorf("authorization was denied")
+		case "expired_token":
+			return nil, fmt.Errorf("device code expired before authorization completed")
+		default:
+			return nil, fmt.Errorf("token request failed: %s %s", tokenErr.Error, tokenErr.ErrorDescription)
+		}
+	}
+}
+
+// postForm sends a form-encoded POST and returns the body and status code
+func postForm(ctx context.Context, endpoint string, data url.Values) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return body, resp.StatusCode, nil
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
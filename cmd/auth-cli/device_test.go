@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+func TestPollDeviceToken(t *testing.T) {
+	responses := []string{
+		`{"error":"authorization_pending"}`,
+		`{"error":"slow_down"}`,
+		`{"access_token":"at","id_token":"idt","token_type":"Bearer","expires_in":3600}`,
+	}
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, deviceCodeGrantType, r.PostForm.Get("grant_type"))
+		assert.Equal(t, "device-123", r.PostForm.Get("device_code"))
+
+		if calls < len(responses)-1 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(responses[calls]))
+		calls++
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	sleep := func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	endpoint := &loginEndpoint{TokenURL: server.URL, ClientID: "client"}
+	auth := &deviceAuthResponse{DeviceCode: "device-123", Interval: 2, ExpiresIn: 600}
+
+	tokenResp, err := pollDeviceToken(context.Background(), endpoint, auth, sleep)
+	require.NoError(t, err)
+	assert.Equal(t, "idt", tokenResp.IDToken)
+	assert.Equal(t, []time.Duration{2 * time.Second, 2 * time.Second, 7 * time.Second}, waits)
+}
+
+func TestPollDeviceTokenDenied(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"access_denied"}`))
+	}))
+	defer server.Close()
+
+	noSleep := func(ctx context.Context, d time.Duration) error { return nil }
+	endpoint := &loginEndpoint{TokenURL: server.URL, ClientID: "client"}
+
+	_, err := pollDeviceToken(context.Background(), endpoint, &deviceAuthResponse{DeviceCode: "d"}, noSleep)
+	assert.ErrorContains(t, err, "denied")
+}
+
+func TestBuildAuthURLIncludesPKCEChallenge(t *testing.T) {
+	cfg = &config.Config{OAuthRedirectURI: "http://localhost:8085/callback"}
+	defer func() { cfg = nil }()
+
+	pkce, err := newPKCEChallenge()
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte(pkce.Verifier))
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(sum[:]), pkce.Challenge)
+
+	endpoint := &loginEndpoint{Provider: "corp", AuthURL: "https://idp.example.com/authorize", ClientID: "client", Scopes: []string{"openid"}}
+	parsed, err := url.Parse(buildAuthURL(endpoint, "state", pkce))
+	require.NoError(t, err)
+
+	assert.Equal(t, pkce.Challenge, parsed.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Empty(t, parsed.Query().Get("code_verifier"))
+}
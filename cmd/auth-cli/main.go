@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -12,29 +13,23 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/identity"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
 	"github.com/stuartshay/gcp-automation-api/internal/services"
 )
 
-// StoredCredentials represents the stored authentication data
-type StoredCredentials struct {
-	AccessToken  string                `json:"access_token"`
-	TokenType    string                `json:"token_type"`
-	ExpiresAt    time.Time             `json:"expires_at"`
-	UserInfo     models.GoogleUserInfo `json:"user_info"`
-	RefreshToken string                `json:"refresh_token,omitempty"`
-}
-
 var (
 	cfg         *config.Config
 	authService *services.AuthService
+	store       *profiles.Store
+	profileName string
 )
 
 func main() {
@@ -45,6 +40,10 @@ func main() {
 	}
 
 	authService = services.NewAuthService(cfg)
+	store, err = profiles.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open credential store: %v", err)
+	}
 
 	rootCmd := &cobra.Command{
 		Use:   "auth-cli",
@@ -52,6 +51,9 @@ func main() {
 		Long:  "A CLI tool for managing authentication with the GCP Automation API",
 	}
 
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", cfg.ActiveProfile,
+		"Profile (context) to use instead of the current context")
+
 	rootCmd.AddCommand(
 		loginCmd(),
 		tokenCmd(),
@@ -60,6 +62,10 @@ func main() {
 		testTokenCmd(),
 		logoutCmd(),
 		statusCmd(),
+		setContextCmd(),
+		useContextCmd(),
+		deleteContextCmd(),
+		proxyCmd(),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -69,35 +75,84 @@ func main() {
 }
 
 func loginCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Login with Google OAuth",
-		Long:  "Perform Google OAuth authentication and store credentials locally",
+		Long:  "Perform Google OAuth (or configured OIDC provider) authentication and store credentials locally",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return performGoogleLogin()
+			profile, err := currentProfile()
+			if err != nil {
+				return err
+			}
+
+			providerName, _ := cmd.Flags().GetString("provider")
+			if !cmd.Flags().Changed("provider") && profile.Provider != "" {
+				providerName = profile.Provider
+			}
+			device, _ := cmd.Flags().GetBool("device")
+
+			endpoint, err := resolveLoginEndpoint(cmd.Context(), providerName)
+			if err != nil {
+				return err
+			}
+			if profile.ClientID != "" {
+				endpoint.ClientID = profile.ClientID
+				endpoint.ClientSecret = profile.ClientSecret
+			}
+			if device {
+				return performDeviceLogin(cmd.Context(), endpoint)
+			}
+			return performBrowserLogin(endpoint)
 		},
 	}
+
+	cmd.Flags().String("provider", "google", "Identity provider name from OIDC_PROVIDERS_FILE")
+	cmd.Flags().Bool("device", false, "Use the device authorization flow (for SSH sessions and containers without a browser)")
+
+	return cmd
 }
 
 func tokenCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "token",
+	cmd := &cobra.Command{
+		Use:   "token [get|store|erase]",
 		Short: "Display current access token",
-		Long:  "Show the current JWT access token if available",
+		Long: `Show the current JWT access token if available. A token that expires
+within --refresh-before is refreshed first.
+
+Use --output to print it in a format other tools understand:
+  token            the bare JWT (default)
+  json             token with expiry, for scripts
+  exec-credential  a Kubernetes ExecCredential for kubeconfig exec plugins
+  env              an export line for eval "$(auth-cli token --output env)"
+  git-credential   a git credential helper response, for the profile's server only`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			creds, err := loadCredentials()
+			output, _ := cmd.Flags().GetString("output")
+
+			profile, err := currentProfile()
 			if err != nil {
-				return fmt.Errorf("no valid credentials found. Please run 'auth-cli login' first")
+				return err
 			}
 
-			if time.Now().After(creds.ExpiresAt) {
-				return fmt.Errorf("token has expired. Please run 'auth-cli refresh' or 'auth-cli login'")
+			refreshBefore, _ := cmd.Flags().GetDuration("refresh-before")
+			tokens := &refreshingTokenSource{
+				load:          loadCredentials,
+				refresh:       refreshCredentials,
+				refreshBefore: refreshBefore,
+			}
+			creds, err := tokens.Credentials()
+			if err != nil {
+				return err
 			}
 
-			fmt.Println(creds.AccessToken)
-			return nil
+			return writeToken(cmd.OutOrStdout(), cmd.InOrStdin(), output, profile, creds, args)
 		},
 	}
+
+	cmd.Flags().StringP("output", "o", outputToken, "Output format: token, json, exec-credential, env or git-credential")
+	cmd.Flags().Duration("refresh-before", 5*time.Minute, "Refresh the token when it expires within this duration")
+
+	return cmd
 }
 
 func refreshCmd() *cobra.Command {
@@ -129,6 +184,9 @@ func profileCmd() *cobra.Command {
 			if creds.UserInfo.Picture != "" {
 				fmt.Printf("  Picture: %s\n", creds.UserInfo.Picture)
 			}
+			if len(creds.Groups) > 0 {
+				fmt.Printf("  Groups: %s\n", strings.Join(creds.Groups, ", "))
+			}
 			fmt.Printf("  Token Expires: %s\n", creds.ExpiresAt.Format(time.RFC3339))
 
 			return nil
@@ -160,7 +218,7 @@ func testTokenCmd() *cobra.Command {
 			}
 
 			// Store the test credentials
-			creds := &StoredCredentials{
+			creds := &profiles.StoredCredentials{
 				AccessToken: token,
 				TokenType:   "Bearer",
 				ExpiresAt:   time.Now().Add(time.Duration(cfg.JWTExpirationHours) * time.Hour),
@@ -195,13 +253,16 @@ func logoutCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "logout",
 		Short: "Clear stored credentials",
-		Long:  "Remove all stored authentication credentials",
+		Long:  "Remove the stored authentication credentials of the current profile",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			credPath := getCredentialsPath()
-			if err := os.Remove(credPath); err != nil && !os.IsNotExist(err) {
+			profile, err := currentProfile()
+			if err != nil {
+				return err
+			}
+			if err := store.DeleteCredentials(profile.Name); err != nil {
 				return fmt.Errorf("failed to remove credentials: %w", err)
 			}
-			fmt.Println("Logged out successfully")
+			fmt.Printf("Logged out of profile %q successfully\n", profile.Name)
 			return nil
 		},
 	}
@@ -211,45 +272,130 @@ func statusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show authentication status",
-		Long:  "Display current authentication status and token information",
+		Long:  "Display authentication status of the current profile and token expiry of every profile",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			creds, err := loadCredentials()
+			file, err := store.Load()
 			if err != nil {
-				fmt.Println("Status: Not authenticated")
-				fmt.Println("Run 'auth-cli login' to authenticate")
-				return nil
+				return err
+			}
+			profile, err := file.Resolve(profileName)
+			if err != nil {
+				return err
 			}
 
-			fmt.Println("Status: Authenticated")
-			fmt.Printf("User: %s (%s)\n", creds.UserInfo.Name, creds.UserInfo.Email)
-			fmt.Printf("Token Type: %s\n", creds.TokenType)
-			fmt.Printf("Expires: %s\n", creds.ExpiresAt.Format(time.RFC3339))
+			fmt.Printf("Profile: %s\n", profile.Name)
+			if profile.Server != "" {
+				fmt.Printf("Server: %s\n", profile.Server)
+			}
+			fmt.Printf("Credential Store: %s\n", store.Backend())
 
-			if time.Now().After(creds.ExpiresAt) {
-				fmt.Println("⚠️  Token has expired. Run 'auth-cli refresh' or 'auth-cli login'")
+			creds, err := store.LoadCredentials(profile.Name)
+			if err != nil {
+				fmt.Println("Status: Not authenticated")
+				fmt.Println("Run 'auth-cli login' to authenticate")
 			} else {
-				remaining := time.Until(creds.ExpiresAt)
-				fmt.Printf("Time remaining: %s\n", remaining.Round(time.Minute))
+				fmt.Println("Status: Authenticated")
+				fmt.Printf("User: %s (%s)\n", creds.UserInfo.Name, creds.UserInfo.Email)
+				fmt.Printf("Token Type: %s\n", creds.TokenType)
+				fmt.Printf("Expires: %s\n", creds.ExpiresAt.Format(time.RFC3339))
+
+				if creds.Expired() {
+					fmt.Println("⚠️  Token has expired. Run 'auth-cli refresh' or 'auth-cli login'")
+				} else {
+					remaining := time.Until(creds.ExpiresAt)
+					fmt.Printf("Time remaining: %s\n", remaining.Round(time.Minute))
+				}
 			}
 
+			fmt.Println()
+			printProfiles(os.Stdout, file)
 			return nil
 		},
 	}
 }
 
-func performGoogleLogin() error {
-	if cfg.GoogleClientID == "" {
-		return fmt.Errorf("GOOGLE_CLIENT_ID not configured")
+// loginEndpoint describes the OAuth endpoints and client used for a login
+type loginEndpoint struct {
+	Provider      string
+	AuthURL       string
+	TokenURL      string
+	DeviceAuthURL string
+	ClientID      string
+	ClientSecret  string
+	Scopes        []string
+}
+
+// resolveLoginEndpoint returns the OAuth endpoints for the named provider,
+// using OIDC discovery for providers other than Google
+func resolveLoginEndpoint(ctx context.Context, providerName string) (*loginEndpoint, error) {
+	if providerName == "" || providerName == identity.GoogleProviderName {
+		if cfg.GoogleClientID == "" {
+			return nil, fmt.Errorf("GOOGLE_CLIENT_ID not configured")
+		}
+		return &loginEndpoint{
+			Provider:      identity.GoogleProviderName,
+			AuthURL:       "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL:      cfg.OAuthTokenURL,
+			DeviceAuthURL: cfg.OAuthDeviceAuthURL,
+			ClientID:      cfg.GoogleClientID,
+			ClientSecret:  cfg.GoogleClientSecret,
+			Scopes:        []string{"openid", "email", "profile"},
+		}, nil
+	}
+
+	p, ok := authService.IdentityProviders().Get(providerName)
+	if !ok {
+		return nil, fmt.Errorf("unknown identity provider %q (configured: %s)",
+			providerName, strings.Join(authService.IdentityProviders().Names(), ", "))
+	}
+	oidcProvider, ok := p.(*identity.OIDCProvider)
+	if !ok {
+		return nil, fmt.Errorf("identity provider %q does not support interactive login", providerName)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	discovered, err := oidcProvider.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := oidcProvider.Config()
+	if pc.ClientID == "" {
+		return nil, fmt.Errorf("identity provider %q has no client_id configured", providerName)
+	}
+	scopes := pc.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &loginEndpoint{
+		Provider:      providerName,
+		AuthURL:       discovered.Endpoint().AuthURL,
+		TokenURL:      discovered.Endpoint().TokenURL,
+		DeviceAuthURL: discovered.Endpoint().DeviceAuthURL,
+		ClientID:      pc.ClientID,
+		ClientSecret:  pc.ClientSecret,
+		Scopes:        scopes,
+	}, nil
+}
 
+func performBrowserLogin(endpoint *loginEndpoint) error {
 	// Generate state parameter for security
 	state, err := generateRandomString(32)
 	if err != nil {
 		return fmt.Errorf("failed to generate state parameter: %w", err)
 	}
 
+	// Generate PKCE verifier; only its challenge is sent to the browser
+	pkce, err := newPKCEChallenge()
+	if err != nil {
+		return fmt.Errorf("failed to generate PKCE verifier: %w", err)
+	}
+
 	// Build OAuth URL
-	authURL := buildGoogleAuthURL(state)
+	authURL := buildAuthURL(endpoint, state, pkce)
 
 	// Start local server to handle callback
 	server := &http.Server{
@@ -305,7 +451,7 @@ func performGoogleLogin() error {
 	}()
 
 	// Open browser
-	fmt.Printf("Opening browser for Google authentication...\n")
+	fmt.Printf("Opening browser for %s authentication...\n", endpoint.Provider)
 	fmt.Printf("If the browser doesn't open automatically, visit: %s\n", authURL)
 
 	if err := openBrowser(authURL); err != nil {
@@ -329,37 +475,41 @@ func performGoogleLogin() error {
 			}
 			if authCode != "" {
 				// Exchange code for token
-				return exchangeCodeForToken(authCode)
+				return exchangeCodeForToken(endpoint, authCode, pkce.Verifier)
 			}
 		}
 	}
 }
 
-func buildGoogleAuthURL(state string) string {
-	baseURL := "https://accounts.google.com/o/oauth2/v2/auth"
+func buildAuthURL(endpoint *loginEndpoint, state string, pkce *pkceChallenge) string {
 	params := url.Values{
-		"client_id":     {cfg.GoogleClientID},
-		"redirect_uri":  {cfg.OAuthRedirectURI},
-		"response_type": {"code"},
-		"scope":         {"openid email profile"},
-		"state":         {state},
-		"access_type":   {"offline"},
-		"prompt":        {"consent"},
-	}
-	return baseURL + "?" + params.Encode()
+		"client_id":             {endpoint.ClientID},
+		"redirect_uri":          {cfg.OAuthRedirectURI},
+		"response_type":         {"code"},
+		"scope":                 {strings.Join(endpoint.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {pkce.Challenge},
+		"code_challenge_method": {pkce.Method},
+	}
+	if endpoint.Provider == identity.GoogleProviderName {
+		params.Set("access_type", "offline")
+		params.Set("prompt", "consent")
+	}
+	return endpoint.AuthURL + "?" + params.Encode()
 }
 
-func exchangeCodeForToken(code string) error {
+func exchangeCodeForToken(endpoint *loginEndpoint, code, codeVerifier string) error {
 	// Exchange authorization code for tokens
 	data := url.Values{
-		"client_id":     {cfg.GoogleClientID},
-		"client_secret": {cfg.GoogleClientSecret},
+		"client_id":     {endpoint.ClientID},
+		"client_secret": {endpoint.ClientSecret},
 		"code":          {code},
+		"code_verifier": {codeVerifier},
 		"grant_type":    {"authorization_code"},
 		"redirect_uri":  {cfg.OAuthRedirectURI},
 	}
 
-	resp, err := http.PostForm(cfg.OAuthTokenURL, data)
+	resp, err := http.PostForm(endpoint.TokenURL, data)
 	if err != nil {
 		return fmt.Errorf("failed to exchange code for token: %w", err)
 	}
@@ -380,18 +530,34 @@ func exchangeCodeForToken(code string) error {
 		return fmt.Errorf("failed to parse token response: %w", err)
 	}
 
+	return completeLogin(endpoint, &tokenResp)
+}
+
+// completeLogin exchanges the provider's ID token for an API token and stores it
+func completeLogin(endpoint *loginEndpoint, tokenResp *models.OAuthTokenResponse) error {
+	if tokenResp.IDToken == "" {
+		return fmt.Errorf("token response did not include an ID token")
+	}
+
 	// Use the ID token to authenticate with our service
-	loginResp, err := authService.LoginWithGoogle(context.Background(), tokenResp.IDToken)
+	var loginResp *models.LoginResponse
+	var err error
+	if endpoint.Provider == identity.GoogleProviderName {
+		loginResp, err = authService.LoginWithGoogle(context.Background(), tokenResp.IDToken)
+	} else {
+		loginResp, err = authService.LoginWithIDToken(context.Background(), tokenResp.IDToken)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to authenticate with service: %w", err)
 	}
 
 	// Store credentials
-	creds := &StoredCredentials{
+	creds := &profiles.StoredCredentials{
 		AccessToken: loginResp.AccessToken,
 		TokenType:   loginResp.TokenType,
 		ExpiresAt:   time.Now().Add(time.Duration(loginResp.ExpiresIn) * time.Second),
 		UserInfo:    loginResp.UserInfo,
+		Groups:      loginResp.Groups,
 	}
 
 	if err := saveCredentials(creds); err != nil {
@@ -409,16 +575,26 @@ func refreshToken() error {
 		return fmt.Errorf("no credentials found. Please run 'auth-cli login' first")
 	}
 
+	if _, err := refreshCredentials(creds); err != nil {
+		return err
+	}
+
+	fmt.Println("Token refreshed successfully")
+	return nil
+}
+
+// refreshCredentials issues a new token for stored credentials and saves it
+func refreshCredentials(creds *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
 	// Validate current token to get claims
 	claims, err := authService.ValidateJWT(creds.AccessToken)
 	if err != nil {
-		return fmt.Errorf("current token is invalid. Please run 'auth-cli login' again")
+		return nil, fmt.Errorf("current token is invalid. Please run 'auth-cli login' again")
 	}
 
 	// Generate new token
 	newToken, err := authService.RefreshJWT(claims)
 	if err != nil {
-		return fmt.Errorf("failed to refresh token: %w", err)
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
 
 	// Update stored credentials
@@ -426,95 +602,67 @@ func refreshToken() error {
 	creds.ExpiresAt = time.Now().Add(time.Duration(cfg.JWTExpirationHours) * time.Hour)
 
 	if err := saveCredentials(creds); err != nil {
-		return fmt.Errorf("failed to save refreshed credentials: %w", err)
+		return nil, fmt.Errorf("failed to save refreshed credentials: %w", err)
 	}
 
-	fmt.Println("Token refreshed successfully")
-	return nil
+	return creds, nil
 }
 
-func loadCredentials() (*StoredCredentials, error) {
-	credPath := getCredentialsPath()
-	if err := validateFilePath(credPath); err != nil {
-		return nil, fmt.Errorf("invalid credentials path: %w", err)
-	}
-
-	// #nosec G304 - credPath is validated by validateFilePath to prevent path traversal
-	data, err := os.ReadFile(credPath)
+// currentProfile returns the profile selected by --profile or the current context
+func currentProfile() (*profiles.Profile, error) {
+	file, err := store.Load()
 	if err != nil {
 		return nil, err
 	}
+	return file.Resolve(profileName)
+}
 
-	var creds StoredCredentials
-	if err := json.Unmarshal(data, &creds); err != nil {
+func loadCredentials() (*profiles.StoredCredentials, error) {
+	profile, err := currentProfile()
+	if err != nil {
 		return nil, err
 	}
-
-	return &creds, nil
+	return store.LoadCredentials(profile.Name)
 }
 
-func saveCredentials(creds *StoredCredentials) error {
-	credPath := getCredentialsPath()
-
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(credPath), 0700); err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(creds, "", "  ")
+func saveCredentials(creds *profiles.StoredCredentials) error {
+	profile, err := currentProfile()
 	if err != nil {
 		return err
 	}
-
-	return os.WriteFile(credPath, data, 0600)
+	return store.SaveCredentials(profile.Name, creds)
 }
 
-func getCredentialsPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		// Fallback to current directory
-		return filepath.Join(".", cfg.CredentialsDir, cfg.CredentialsFile)
+func generateRandomString(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
 	}
-	return filepath.Join(homeDir, cfg.CredentialsDir, cfg.CredentialsFile)
+	return base64.URLEncoding.EncodeToString(bytes)[:length], nil
 }
 
-// validateFilePath ensures the file path is safe and within expected boundaries
-func validateFilePath(path string) error {
-	// Get absolute path to resolve any path traversal attempts
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return fmt.Errorf("failed to resolve absolute path: %w", err)
-	}
-
-	// Get expected credentials directory absolute path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get user home directory: %w", err)
-	}
-	expectedDir := filepath.Join(homeDir, cfg.CredentialsDir)
-	absExpectedDir, err := filepath.Abs(expectedDir)
-	if err != nil {
-		return fmt.Errorf("failed to resolve expected directory path: %w", err)
-	}
-
-	// Check if the resolved path is within the expected directory using filepath.Rel
-	rel, err := filepath.Rel(absExpectedDir, absPath)
-	if err != nil {
-		return fmt.Errorf("failed to compute relative path: %w", err)
-	}
-	if strings.HasPrefix(rel, ".."+string(os.PathSeparator)) || rel == ".." || filepath.IsAbs(rel) {
-		return fmt.Errorf("path is outside allowed credentials directory")
-	}
-
-	return nil
+// pkceChallenge holds a PKCE code verifier and its S256 challenge (RFC 7636)
+type pkceChallenge struct {
+	Verifier  string
+	Challenge string
+	Method    string
 }
 
-func generateRandomString(length int) (string, error) {
-	bytes := make([]byte, length)
+// newPKCEChallenge generates a random code verifier and its S256 challenge
+func newPKCEChallenge() (*pkceChallenge, error) {
+	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+		return nil, err
 	}
-	return base64.URLEncoding.EncodeToString(bytes)[:length], nil
+
+	verifier := base64.RawURLEncoding.EncodeToString(bytes)
+	sum := sha256.Sum256([]byte(verifier))
+
+	return &pkceChallenge{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+		Method:    "S256",
+	}, nil
 }
 
 func openBrowser(urlStr string) error {
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+func proxyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "proxy",
+		Short: "Run a local authenticating proxy",
+		Long: `Forward requests to the profile's API server, adding the stored bearer token.
+The token is refreshed automatically before it expires, so scripts can call the
+API with plain curl, e.g. curl http://localhost:9000/api/v1/projects/my-project`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profile, err := currentProfile()
+			if err != nil {
+				return err
+			}
+			// Pin the profile so a later use-context does not switch a running proxy
+			profileName = profile.Name
+
+			server, _ := cmd.Flags().GetString("server")
+			if server == "" {
+				server = profile.Server
+			}
+			if server == "" {
+				return fmt.Errorf("no API server configured for profile %q. Use 'auth-cli set-context %s --server <url>' or --server",
+					profile.Name, profile.Name)
+			}
+			target, err := url.Parse(server)
+			if err != nil || target.Scheme == "" || target.Host == "" {
+				return fmt.Errorf("invalid API server URL %q", server)
+			}
+
+			listen, _ := cmd.Flags().GetString("listen")
+			listen, allowedHosts, err := loopbackListen(listen)
+			if err != nil {
+				return err
+			}
+			refreshBefore, _ := cmd.Flags().GetDuration("refresh-before")
+
+			tokens := &refreshingTokenSource{
+				load:          loadCredentials,
+				refresh:       refreshCredentials,
+				refreshBefore: refreshBefore,
+			}
+			if _, err := tokens.Token(); err != nil {
+				return err
+			}
+
+			srv := &http.Server{
+				Addr:              listen,
+				Handler:           newAuthProxy(target, allowedHosts, tokens),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+
+			log.Printf("Proxying %s -> %s (profile %q)", listen, target, profile.Name)
+			return srv.ListenAndServe()
+		},
+	}
+
+	cmd.Flags().String("listen", "localhost:9000", "Loopback address to listen on (a bare :port listens on localhost)")
+	cmd.Flags().String("server", "", "API server URL (defaults to the profile's server)")
+	cmd.Flags().Duration("refresh-before", 5*time.Minute, "Refresh the token when it expires within this duration")
+
+	return cmd
+}
+
+// tokenSource provides the bearer token injected by the proxy
+type tokenSource interface {
+	Token() (string, error)
+}
+
+// loopbackListen returns the address to listen on for listen, and the Host
+// headers accepted there. Anyone who can reach the proxy can call the API as
+// the user, so it must listen on a loopback address; a bare port such as
+// ":9000" listens on localhost.
+func loopbackListen(listen string) (string, map[string]bool, error) {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid listen address %q: %w", listen, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", nil, fmt.Errorf("listen address %q is not a loopback address; anyone who can reach it could call the API as you", listen)
+	}
+
+	hosts := make(map[string]bool)
+	for _, name := range []string{"localhost", "127.0.0.1", "::1", host} {
+		hosts[net.JoinHostPort(name, port)] = true
+	}
+	return net.JoinHostPort(host, port), hosts, nil
+}
+
+// newAuthProxy returns a handler forwarding requests to target with a bearer
+// token. Requests from web pages (with an Origin or a cross-site
+// Sec-Fetch-Site header) are rejected, so that a page open in the user's
+// browser cannot call the API as them, as are requests for any host other
+// than the proxy's own, which defeats DNS rebinding.
+func newAuthProxy(target *url.URL, allowedHosts map[string]bool, tokens tokenSource) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		if !allowedHosts[r.Host] {
+			log.Printf("%s %s -> %d (host %q rejected)", r.Method, r.URL.RequestURI(), http.StatusForbidden, r.Host)
+			writeProxyError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+			return
+		}
+		if site := r.Header.Get("Sec-Fetch-Site"); r.Header.Get("Origin") != "" || site != "" && site != "none" {
+			log.Printf("%s %s -> %d (browser request rejected)", r.Method, r.URL.RequestURI(), http.StatusForbidden)
+			writeProxyError(w, http.StatusForbidden, fmt.Errorf("requests from web pages are not allowed"))
+			return
+		}
+
+		token, err := tokens.Token()
+		if err != nil {
+			log.Printf("%s %s -> %d (%v)", r.Method, r.URL.RequestURI(), http.StatusUnauthorized, err)
+			writeProxyError(w, http.StatusUnauthorized, err)
+			return
+		}
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		proxy.ServeHTTP(rec, r)
+
+		log.Printf("%s %s -> %d (%s)", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// writeProxyError writes an API-style error response, named by the
+// canonical code for status
+func writeProxyError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:   gcperrors.Name(gcperrors.FromHTTPStatus(status)),
+		Message: err.Error(),
+		Code:    status,
+	})
+}
+
+// statusRecorder captures the response status code for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush supports streaming responses such as log tails
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
+)
+
+func TestAuthProxyInjectsBearerToken(t *testing.T) {
+	var gotAuth, gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer backend.Close()
+
+	target, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "stored-token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		refreshBefore: 5 * time.Minute,
+	}
+
+	_, allowedHosts, err := loopbackListen("localhost:9000")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:9000/api/v1/buckets", nil)
+	req.Header.Set("Authorization", "Bearer caller-supplied")
+	rec := httptest.NewRecorder()
+	newAuthProxy(target, allowedHosts, tokens).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "Bearer stored-token", gotAuth)
+	assert.Equal(t, "/api/v1/buckets", gotPath)
+}
+
+func TestAuthProxyRejectsWhenNoToken(t *testing.T) {
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "expired", ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+		refresh: func(c *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
+			return nil, errors.New("current token is invalid")
+		},
+	}
+
+	target, _ := url.Parse("http://127.0.0.1:1")
+	rec := httptest.NewRecorder()
+	allowedHosts := map[string]bool{"localhost:9000": true}
+	newAuthProxy(target, allowedHosts, tokens).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:9000/api/v1/projects/p", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "current token is invalid")
+}
+
+func TestAuthProxyRejectsBrowserAndForeignHostRequests(t *testing.T) {
+	called := false
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer backend.Close()
+
+	target, err := url.Parse(backend.URL)
+	require.NoError(t, err)
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "stored-token", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+	}
+	_, allowedHosts, err := loopbackListen("127.0.0.1:9000")
+	require.NoError(t, err)
+	proxy := newAuthProxy(target, allowedHosts, tokens)
+
+	tests := map[string]func(r *http.Request){
+		"Cross-origin request": func(r *http.Request) { r.Header.Set("Origin", "https://evil.example.com") },
+		"Cross-site fetch":     func(r *http.Request) { r.Header.Set("Sec-Fetch-Site", "cross-site") },
+		"DNS rebinding":        func(r *http.Request) { r.Host = "evil.example.com:9000" },
+	}
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "http://127.0.0.1:9000/api/v1/buckets/b", nil)
+			modify(req)
+			rec := httptest.NewRecorder()
+			proxy.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+	assert.False(t, called)
+
+	// The loopback names of the listen port are accepted
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:9000/api/v1/buckets/b", nil)
+	req.Header.Set("Sec-Fetch-Site", "none")
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, called)
+}
+
+func TestLoopbackListenRejectsNonLoopbackListenAddress(t *testing.T) {
+	for _, listen := range []string{"0.0.0.0:9000", "192.168.1.10:9000", "proxy.example.com:9000"} {
+		_, _, err := loopbackListen(listen)
+		assert.ErrorContains(t, err, "not a loopback address", listen)
+	}
+	addr, _, err := loopbackListen("[::1]:9000")
+	assert.NoError(t, err)
+	assert.Equal(t, "[::1]:9000", addr)
+}
+
+func TestLoopbackListenBindsBarePortToLocalhost(t *testing.T) {
+	addr, hosts, err := loopbackListen(":9000")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:9000", addr)
+	assert.True(t, hosts["localhost:9000"])
+	assert.True(t, hosts["127.0.0.1:9000"])
+}
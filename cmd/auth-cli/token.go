@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
+)
+
+// Output formats supported by 'auth-cli token --output'
+const (
+	outputToken          = "token"
+	outputJSON           = "json"
+	outputExecCredential = "exec-credential"
+	outputEnv            = "env"
+	outputGitCredential  = "git-credential"
+)
+
+// tokenEnvVar is the variable exported by the env output format
+const tokenEnvVar = "GCP_AUTOMATION_TOKEN"
+
+// execCredentialAPIVersion is the client.authentication.k8s.io version emitted
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+// tokenOutput is the JSON output format
+type tokenOutput struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	ExpiresIn   int64     `json:"expires_in"`
+	Email       string    `json:"email,omitempty"`
+	Profile     string    `json:"profile"`
+}
+
+// execCredential is a Kubernetes ExecCredential document
+type execCredential struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Status     execCredentialStatus `json:"status"`
+}
+
+type execCredentialStatus struct {
+	Token               string `json:"token"`
+	ExpirationTimestamp string `json:"expirationTimestamp"`
+}
+
+// refreshingTokenSource hands out the stored credentials, refreshing them
+// shortly before the access token expires
+type refreshingTokenSource struct {
+	load          func() (*profiles.StoredCredentials, error)
+	refresh       func(*profiles.StoredCredentials) (*profiles.StoredCredentials, error)
+	refreshBefore time.Duration
+
+	mu    sync.Mutex
+	creds *profiles.StoredCredentials
+}
+
+// Token returns a valid access token
+func (ts *refreshingTokenSource) Token() (string, error) {
+	creds, err := ts.Credentials()
+	if err != nil {
+		return "", err
+	}
+	return creds.AccessToken, nil
+}
+
+// Credentials returns credentials holding a valid access token. A token
+// that expires soon is refreshed; if that fails it is still used until it
+// has expired.
+func (ts *refreshingTokenSource) Credentials() (*profiles.StoredCredentials, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.creds != nil && !ts.expiresSoon(ts.creds) {
+		return ts.creds, nil
+	}
+
+	// Another auth-cli command may have logged in or refreshed since
+	creds, err := ts.load()
+	if err != nil {
+		return nil, fmt.Errorf("no credentials found. Please run 'auth-cli login' first")
+	}
+
+	if ts.expiresSoon(creds) {
+		refreshed, err := ts.refresh(creds)
+		switch {
+		case err == nil:
+			log.Printf("Refreshed access token, now expires %s", refreshed.ExpiresAt.Format(time.RFC3339))
+			creds = refreshed
+		case creds.Expired():
+			return nil, fmt.Errorf("token has expired and could not be refreshed: %w", err)
+		default:
+			log.Printf("Token refresh failed, using current token until it expires: %v", err)
+		}
+	}
+
+	ts.creds = creds
+	return creds, nil
+}
+
+func (ts *refreshingTokenSource) expiresSoon(creds *profiles.StoredCredentials) bool {
+	return time.Until(creds.ExpiresAt) < ts.refreshBefore
+}
+
+// writeToken writes the credentials of profile in the requested output
+// format. args are the positional arguments passed by git to a credential
+// helper.
+func writeToken(out io.Writer, in io.Reader, format string, profile *profiles.Profile, creds *profiles.StoredCredentials, args []string) error {
+	switch format {
+	case "", outputToken:
+		_, err := fmt.Fprintln(out, creds.AccessToken)
+		return err
+
+	case outputJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(tokenOutput{
+			AccessToken: creds.AccessToken,
+			TokenType:   creds.TokenType,
+			ExpiresAt:   creds.ExpiresAt.UTC(),
+			ExpiresIn:   int64(time.Until(creds.ExpiresAt).Seconds()),
+			Email:       creds.UserInfo.Email,
+			Profile:     profile.Name,
+		})
+
+	case outputExecCredential:
+		return json.NewEncoder(out).Encode(execCredential{
+			APIVersion: execCredentialAPIVersion,
+			Kind:       "ExecCredential",
+			Status: execCredentialStatus{
+				Token:               creds.AccessToken,
+				ExpirationTimestamp: creds.ExpiresAt.UTC().Format(time.RFC3339),
+			},
+		})
+
+	case outputEnv:
+		_, err := fmt.Fprintf(out, "export %s=%s\n", tokenEnvVar, creds.AccessToken)
+		return err
+
+	case outputGitCredential:
+		return writeGitCredential(out, in, profile.Server, creds, args)
+
+	default:
+		return fmt.Errorf("unknown output format %q (expected %s)", format,
+			strings.Join([]string{outputToken, outputJSON, outputExecCredential, outputEnv, outputGitCredential}, ", "))
+	}
+}
+
+// writeGitCredential implements the git credential helper protocol. Only the
+// "get" operation returns credentials, and only for the protocol and host of
+// the profile's API server; for any other remote it prints nothing so that
+// git asks its next helper. "store" and "erase" are ignored since tokens are
+// managed by auth-cli.
+func writeGitCredential(out io.Writer, in io.Reader, server string, creds *profiles.StoredCredentials, args []string) error {
+	operation := "get"
+	if len(args) > 0 {
+		operation = args[0]
+	}
+
+	// Git writes the request attributes terminated by a blank line
+	attrs := map[string]string{}
+	if in != nil {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if scanner.Text() == "" {
+				break
+			}
+			if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+				attrs[key] = value
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	if operation != "get" {
+		return nil
+	}
+
+	if server == "" {
+		log.Printf("No API server configured for this profile, not answering git for %s://%s", attrs["protocol"], attrs["host"])
+		return nil
+	}
+	target, err := url.Parse(server)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return fmt.Errorf("invalid API server URL %q", server)
+	}
+	if !strings.EqualFold(attrs["protocol"], target.Scheme) || !strings.EqualFold(attrs["host"], target.Host) {
+		return nil
+	}
+
+	username := creds.UserInfo.Email
+	if username == "" {
+		username = "oauth2"
+	}
+
+	_, err = fmt.Fprintf(out, "username=%s\npassword=%s\npassword_expiry_utc=%d\n",
+		username, creds.AccessToken, creds.ExpiresAt.Unix())
+	return err
+}
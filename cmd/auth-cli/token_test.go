@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
+)
+
+func TestWriteTokenFormats(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	creds := &profiles.StoredCredentials{
+		AccessToken: "jwt-token",
+		TokenType:   "Bearer",
+		ExpiresAt:   expires,
+		UserInfo:    models.GoogleUserInfo{Email: "alice@example.com"},
+	}
+	prod := &profiles.Profile{Name: "prod", Server: "https://api.example.com"}
+
+	t.Run("Bare token", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, writeToken(&out, nil, "", &profiles.Profile{Name: "default"}, creds, nil))
+		assert.Equal(t, "jwt-token\n", out.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, writeToken(&out, nil, outputJSON, prod, creds, nil))
+
+		var got tokenOutput
+		require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+		assert.Equal(t, "jwt-token", got.AccessToken)
+		assert.Equal(t, "prod", got.Profile)
+		assert.True(t, got.ExpiresAt.Equal(expires))
+	})
+
+	t.Run("ExecCredential", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, writeToken(&out, nil, outputExecCredential, prod, creds, nil))
+		assert.JSONEq(t, `{
+			"apiVersion": "client.authentication.k8s.io/v1",
+			"kind": "ExecCredential",
+			"status": {"token": "jwt-token", "expirationTimestamp": "2030-01-02T03:04:05Z"}
+		}`, out.String())
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, writeToken(&out, nil, outputEnv, prod, creds, nil))
+		assert.Equal(t, "export GCP_AUTOMATION_TOKEN=jwt-token\n", out.String())
+	})
+
+	t.Run("Git credential get", func(t *testing.T) {
+		var out bytes.Buffer
+		in := strings.NewReader("protocol=https\nhost=api.example.com\n\n")
+		require.NoError(t, writeToken(&out, in, outputGitCredential, prod, creds, []string{"get"}))
+		assert.Equal(t, "username=alice@example.com\npassword=jwt-token\npassword_expiry_utc=1893553445\n", out.String())
+	})
+
+	t.Run("Git credential get for another host", func(t *testing.T) {
+		for _, in := range []string{
+			"protocol=https\nhost=github.com\n\n",
+			"protocol=http\nhost=api.example.com\n\n",
+			"\n",
+		} {
+			var out bytes.Buffer
+			require.NoError(t, writeToken(&out, strings.NewReader(in), outputGitCredential, prod, creds, []string{"get"}))
+			assert.Empty(t, out.String(), in)
+		}
+	})
+
+	t.Run("Git credential get without a server", func(t *testing.T) {
+		var out bytes.Buffer
+		in := strings.NewReader("protocol=https\nhost=api.example.com\n\n")
+		require.NoError(t, writeToken(&out, in, outputGitCredential, &profiles.Profile{Name: "prod"}, creds, []string{"get"}))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("Git credential store is ignored", func(t *testing.T) {
+		var out bytes.Buffer
+		in := strings.NewReader("protocol=https\nhost=api.example.com\npassword=x\n\n")
+		require.NoError(t, writeToken(&out, in, outputGitCredential, prod, creds, []string{"store"}))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		assert.Error(t, writeToken(&bytes.Buffer{}, nil, "yaml", prod, creds, nil))
+	})
+}
+
+func TestRefreshingTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	refreshed := 0
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "old", ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+		refresh: func(c *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
+			refreshed++
+			return &profiles.StoredCredentials{AccessToken: "new", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		refreshBefore: 5 * time.Minute,
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := tokens.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "new", token)
+	}
+	assert.Equal(t, 1, refreshed)
+}
+
+func TestRefreshingTokenSourceFallsBackToUnexpiredToken(t *testing.T) {
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "current", ExpiresAt: time.Now().Add(time.Minute)}, nil
+		},
+		refresh: func(c *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
+			return nil, errors.New("refresh failed")
+		},
+		refreshBefore: 5 * time.Minute,
+	}
+
+	token, err := tokens.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "current", token)
+}
+
+func TestRefreshingTokenSourceRefreshesExpiredToken(t *testing.T) {
+	tokens := &refreshingTokenSource{
+		load: func() (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "expired", ExpiresAt: time.Now().Add(-time.Minute)}, nil
+		},
+		refresh: func(c *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
+			return &profiles.StoredCredentials{AccessToken: "new", ExpiresAt: time.Now().Add(time.Hour)}, nil
+		},
+		refreshBefore: 5 * time.Minute,
+	}
+
+	creds, err := tokens.Credentials()
+	require.NoError(t, err)
+	assert.Equal(t, "new", creds.AccessToken)
+	assert.False(t, creds.Expired())
+
+	tokens.creds = nil
+	tokens.refresh = func(c *profiles.StoredCredentials) (*profiles.StoredCredentials, error) {
+		return nil, errors.New("current token is invalid")
+	}
+	_, err = tokens.Credentials()
+	assert.ErrorContains(t, err, "token has expired and could not be refreshed: current token is invalid")
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+)
+
+func bucketsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "buckets",
+		Aliases: []string{"bucket"},
+		Short:   "Manage Cloud Storage buckets",
+	}
+
+	cmd.AddCommand(bucketsCreateCmd(), bucketsGetCmd(), bucketsDeleteCmd())
+	return cmd
+}
+
+func bucketsCreateCmd() *cobra.Command {
+	var (
+		req    client.BucketRequest
+		labels []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create BUCKET",
+		Short: "Create a bucket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			req.Name = args[0]
+			if req.Labels, err = parseLabels(labels); err != nil {
+				return err
+			}
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			bucket, err := api.CreateBucket(ctx, &req)
+			if err != nil {
+				return err
+			}
+			return printBucket(cmd, bucket)
+		},
+	}
+
+	cmd.Flags().StringVar(&req.Location, "location", "", "Bucket location, e.g. us-central1")
+	cmd.Flags().StringVar(&req.StorageClass, "storage-class", "", "Storage class: STANDARD, NEARLINE, COLDLINE or ARCHIVE")
+	cmd.Flags().StringArrayVar(&labels, "label", nil, "Label as key=value (repeatable)")
+	cmd.Flags().BoolVar(&req.Versioning, "versioning", false, "Enable object versioning")
+	cmd.Flags().BoolVar(&req.UniformBucketLevelAccess, "uniform-access", false, "Enable uniform bucket-level access")
+	cmd.Flags().StringVar(&req.PublicAccessPrevention, "public-access-prevention", "", "Public access prevention: inherited or enforced")
+	cmd.Flags().StringVar(&req.KMSKeyName, "kms-key", "", "Cloud KMS key used to encrypt new objects")
+	_ = cmd.MarkFlagRequired("location")
+	_ = cmd.RegisterFlagCompletionFunc("storage-class", cobra.FixedCompletions(
+		[]string{"STANDARD", "NEARLINE", "COLDLINE", "ARCHIVE"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("public-access-prevention", cobra.FixedCompletions(
+		[]string{"inherited", "enforced"}, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func bucketsGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get BUCKET",
+		Short: "Show a bucket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			bucket, err := api.GetBucket(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printBucket(cmd, bucket)
+		},
+	}
+}
+
+func bucketsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete BUCKET",
+		Short: "Delete an empty bucket",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			if err := api.DeleteBucket(ctx, args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Bucket %s deleted\n", args[0])
+			return nil
+		},
+	}
+}
+
+func printBucket(cmd *cobra.Command, bucket *client.BucketResponse) error {
+	t := table{headers: []string{"NAME", "LOCATION", "STORAGE_CLASS", "VERSIONING", "LABELS", "CREATED"}}
+	t.rows = append(t.rows, []string{
+		bucket.Name,
+		bucket.Location,
+		orDash(bucket.StorageClass),
+		strconv.FormatBool(bucket.Versioning),
+		formatLabels(bucket.Labels),
+		formatTime(bucket.CreateTime),
+	})
+	return printResult(cmd.OutOrStdout(), output, bucket, t)
+}
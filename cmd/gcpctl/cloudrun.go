@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+	"google.golang.org/api/iterator"
+)
+
+// maxLogPageSize is the largest page the logs endpoint returns
+const maxLogPageSize = 1000
+
+func cloudRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cloudrun",
+		Short: "Inspect Cloud Run services",
+	}
+
+	cmd.AddCommand(cloudRunLogsCmd())
+	return cmd
+}
+
+func cloudRunLogsCmd() *cobra.Command {
+	var (
+		region     string
+		since      time.Duration
+		start, end string
+		filter     string
+		limit      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "logs SERVICE",
+		Short: "Show recent logs of a Cloud Run service",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &client.CloudRunLogsRequest{
+				ServiceName: args[0],
+				Region:      region,
+				Filter:      filter,
+				PageSize:    min(limit, maxLogPageSize),
+			}
+			if start == "" && since > 0 {
+				req.StartTime = time.Now().Add(-since).UTC()
+			}
+			for _, flag := range []struct {
+				value  string
+				target *time.Time
+			}{{start, &req.StartTime}, {end, &req.EndTime}} {
+				if flag.value == "" {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, flag.value)
+				if err != nil {
+					return fmt.Errorf("invalid time %q: use RFC3339, e.g. 2025-01-02T15:04:05Z", flag.value)
+				}
+				*flag.target = t
+			}
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			// Follow page tokens until --limit entries have been read
+			logs := &client.CloudRunLogsResponse{ServiceName: req.ServiceName, Region: req.Region, Logs: []client.LogEntry{}}
+			it := api.CloudRunLogs(ctx, req)
+			for len(logs.Logs) < limit {
+				entry, err := it.Next()
+				if err == iterator.Done {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				logs.Logs = append(logs.Logs, *entry)
+			}
+			logs.TotalCount = len(logs.Logs)
+
+			t := table{headers: []string{"TIMESTAMP", "SEVERITY", "REVISION", "MESSAGE"}}
+			for _, entry := range logs.Logs {
+				t.rows = append(t.rows, []string{
+					formatTime(entry.Timestamp),
+					orDash(entry.Severity),
+					orDash(entry.Resource.RevisionName),
+					strings.ReplaceAll(entry.Message, "\n", " "),
+				})
+			}
+			return printResult(cmd.OutOrStdout(), output, logs, t)
+		},
+	}
+
+	cmd.Flags().StringVar(&region, "region", "", "Region the service runs in")
+	cmd.Flags().DurationVar(&since, "since", time.Hour, "Show logs newer than this duration (ignored with --start)")
+	cmd.Flags().StringVar(&start, "start", "", "Start time (RFC3339)")
+	cmd.Flags().StringVar(&end, "end", "", "End time (RFC3339)")
+	cmd.Flags().StringVar(&filter, "filter", "", "Additional Cloud Logging filter, e.g. 'severity >= WARNING'")
+	cmd.Flags().IntVar(&limit, "limit", 100, "Maximum number of entries")
+	_ = cmd.MarkFlagRequired("region")
+
+	return cmd
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+)
+
+func foldersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "folders",
+		Aliases: []string{"folder"},
+		Short:   "Manage GCP folders",
+	}
+
+	cmd.AddCommand(foldersCreateCmd(), foldersGetCmd(), foldersDeleteCmd())
+	return cmd
+}
+
+func foldersCreateCmd() *cobra.Command {
+	var (
+		req  client.FolderRequest
+		wait bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create DISPLAY_NAME",
+		Short: "Create a folder",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.DisplayName = args[0]
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			folder, err := api.CreateFolder(ctx, &req)
+			if err != nil {
+				return err
+			}
+
+			if wait && folder.State != stateActive {
+				folderID := strings.TrimPrefix(folder.Name, "folders/")
+				err := waitFor(ctx, cmd.ErrOrStderr(), "folder "+folderID+" to become active", func(ctx context.Context) (bool, error) {
+					current, err := api.GetFolder(ctx, folderID)
+					if err != nil {
+						return false, err
+					}
+					folder = current
+					return folder.State == stateActive, nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return printFolder(cmd, folder)
+		},
+	}
+
+	cmd.Flags().StringVar(&req.ParentID, "parent-id", "", "Numeric ID of the parent organization or folder")
+	cmd.Flags().StringVar(&req.ParentType, "parent-type", "organization", "Parent type: organization or folder")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the folder is active")
+	_ = cmd.MarkFlagRequired("parent-id")
+	_ = cmd.RegisterFlagCompletionFunc("parent-type", parentTypeCompletions)
+
+	return cmd
+}
+
+func foldersGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get FOLDER_ID",
+		Short: "Show a folder",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			folder, err := api.GetFolder(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printFolder(cmd, folder)
+		},
+	}
+}
+
+func foldersDeleteCmd() *cobra.Command {
+	var wait bool
+
+	cmd := &cobra.Command{
+		Use:   "delete FOLDER_ID",
+		Short: "Delete a folder",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			id := strings.TrimPrefix(args[0], "folders/")
+			if err := api.DeleteFolder(ctx, id); err != nil {
+				return err
+			}
+
+			if wait {
+				err := waitFor(ctx, cmd.ErrOrStderr(), "folder "+id+" to be deleted", func(ctx context.Context) (bool, error) {
+					folder, err := api.GetFolder(ctx, id)
+					if err != nil {
+						if client.IsNotFound(err) {
+							return true, nil
+						}
+						return false, err
+					}
+					return folder.State == stateDeleteRequested, nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Folder %s deleted\n", id)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the folder is marked for deletion")
+	return cmd
+}
+
+func printFolder(cmd *cobra.Command, folder *client.FolderResponse) error {
+	t := table{headers: []string{"NAME", "DISPLAY_NAME", "STATE", "PARENT", "CREATED"}}
+	t.rows = append(t.rows, []string{
+		folder.Name,
+		folder.DisplayName,
+		orDash(folder.State),
+		folder.ParentType + "/" + folder.ParentID,
+		formatTime(folder.CreateTime),
+	})
+	return printResult(cmd.OutOrStdout(), output, folder, t)
+}
@@ -0,0 +1,137 @@
+// Command gcpctl manages GCP resources through the GCP Automation API using
+// the credentials stored by auth-cli.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/profiles"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+)
+
+var (
+	cfg         *config.Config
+	store       *profiles.Store
+	profileName string
+	serverURL   string
+	output      string
+	timeout     time.Duration
+	// idempotencyKeys makes creates safe to retry on servers with
+	// IDEMPOTENCY_ENABLED=true; servers without it ignore the keys
+	idempotencyKeys bool
+)
+
+func main() {
+	var err error
+	cfg, err = config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	store, err = profiles.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to open credential store: %v", err)
+	}
+
+	if err := newRootCmd().Execute(); err != nil {
+		printError(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:   "gcpctl",
+		Short: "Manage GCP resources through the GCP Automation API",
+		Long: `gcpctl manages projects, folders, buckets, objects and Cloud Run logs through
+the GCP Automation API. It uses the profiles and credentials stored by auth-cli,
+so run 'auth-cli login' first.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", cfg.ActiveProfile,
+		"auth-cli profile (context) to use instead of the current context")
+	rootCmd.PersistentFlags().StringVar(&serverURL, "server", "", "API server URL (defaults to the profile's server)")
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputTable, "Output format: table, json or yaml")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 5*time.Minute, "Maximum time to wait for a command, including --wait")
+	rootCmd.PersistentFlags().BoolVar(&idempotencyKeys, "idempotency-keys", false,
+		"Send Idempotency-Key headers and retry failed creates (only for servers with IDEMPOTENCY_ENABLED=true)")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	_ = rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.AddCommand(
+		projectsCmd(),
+		foldersCmd(),
+		bucketsCmd(),
+		objectsCmd(),
+		cloudRunCmd(),
+	)
+
+	return rootCmd
+}
+
+// newClient creates an API client for the selected profile
+func newClient() (*client.Client, error) {
+	file, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	profile, err := file.Resolve(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	server := serverURL
+	if server == "" {
+		server = profile.Server
+	}
+	if server == "" {
+		return nil, fmt.Errorf("no API server configured for profile %q. Use 'auth-cli set-context %s --server <url>' or --server",
+			profile.Name, profile.Name)
+	}
+
+	creds, err := store.LoadCredentials(profile.Name)
+	if err != nil {
+		return nil, fmt.Errorf("no valid credentials found for profile %q. Please run 'auth-cli login' first", profile.Name)
+	}
+	if creds.Expired() {
+		return nil, fmt.Errorf("token has expired. Please run 'auth-cli refresh' or 'auth-cli login'")
+	}
+
+	return client.New(server, clientOptions(creds.AccessToken)...)
+}
+
+// clientOptions returns the API client options for token and the global flags
+func clientOptions(token string) []client.Option {
+	opts := []client.Option{client.WithToken(token), client.WithUserAgent("gcpctl")}
+	if idempotencyKeys {
+		opts = append(opts, client.WithIdempotencyKeys())
+	}
+	return opts
+}
+
+// commandContext bounds a command by --timeout
+func commandContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(cmd.Context(), timeout)
+}
+
+// completeProfiles completes profile names from the profiles file
+func completeProfiles(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	file, err := store.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(file.Profiles))
+	for _, p := range file.Profiles {
+		names = append(names, p.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+	"google.golang.org/api/iterator"
+)
+
+func objectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "objects",
+		Aliases: []string{"object"},
+		Short:   "Manage objects in Cloud Storage buckets",
+	}
+
+	cmd.AddCommand(objectsListCmd(), objectsGetCmd(), objectsDeleteCmd())
+	return cmd
+}
+
+// maxObjectPageSize is the largest page the objects endpoint returns
+const maxObjectPageSize = 1000
+
+func objectsListCmd() *cobra.Command {
+	var (
+		prefix string
+		limit  int
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list BUCKET",
+		Aliases: []string{"ls"},
+		Short:   "List objects in a bucket",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			pageSize := maxObjectPageSize
+			if limit > 0 {
+				pageSize = min(limit, maxObjectPageSize)
+			}
+
+			// Follow page tokens until --limit objects have been read
+			objects := []client.ObjectResponse{}
+			it := api.Objects(ctx, &client.ObjectListRequest{Bucket: args[0], Prefix: prefix, PageSize: pageSize})
+			for limit <= 0 || len(objects) < limit {
+				object, err := it.Next()
+				if err == iterator.Done {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				objects = append(objects, *object)
+			}
+			return printObjects(cmd, objects, objects)
+		},
+	}
+
+	cmd.Flags().StringVar(&prefix, "prefix", "", "Only list objects whose names start with this prefix")
+	cmd.Flags().IntVar(&limit, "limit", 0, "Maximum number of objects (0 lists all)")
+	return cmd
+}
+
+func objectsGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get BUCKET OBJECT",
+		Short: "Show an object's metadata",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			object, err := api.GetObject(ctx, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			return printObjects(cmd, object, []client.ObjectResponse{*object})
+		},
+	}
+}
+
+func objectsDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete BUCKET OBJECT",
+		Short: "Delete an object",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			if err := api.DeleteObject(ctx, args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Object gs://%s/%s deleted\n", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+func printObjects(cmd *cobra.Command, v interface{}, objects []client.ObjectResponse) error {
+	t := table{headers: []string{"NAME", "SIZE", "CONTENT_TYPE", "STORAGE_CLASS", "UPDATED"}}
+	for _, o := range objects {
+		t.rows = append(t.rows, []string{
+			o.Name,
+			strconv.FormatInt(o.Size, 10),
+			orDash(o.ContentType),
+			orDash(o.StorageClass),
+			formatTime(o.UpdateTime),
+		})
+	}
+	return printResult(cmd.OutOrStdout(), output, v, t)
+}
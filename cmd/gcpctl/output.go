@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats selectable with -o
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// table is a tabular rendering of a result
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+// printResult writes v in the requested format, using t for table output
+func printResult(out io.Writer, format string, v interface{}, t table) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case outputYAML:
+		// Round-trip through JSON so field names follow the API's json tags
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	case outputTable, "":
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown output format %q (expected %s)", format, strings.Join(outputFormats, ", "))
+	}
+}
+
+// printError writes err, followed by the policies and label rules that
+// rejected the request when the API reported them
+func printError(out io.Writer, err error) {
+	fmt.Fprintf(out, "Error: %v\n", err)
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	for _, d := range apiErr.PolicyDecisions {
+		fmt.Fprintf(out, "  policy %s (%s): %s\n", d.Policy, d.Effect, orDash(d.Message))
+	}
+	for _, v := range apiErr.LabelViolations {
+		label := v.Key
+		if v.Value != "" {
+			label += "=" + v.Value
+		}
+		fmt.Fprintf(out, "  label %s (%s): %s\n", label, v.Reason, orDash(v.Message))
+	}
+}
+
+// formatLabels renders labels as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "-"
+	}
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// formatTime renders a timestamp for table output
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// orDash renders empty values as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// parseLabels parses repeated key=value flags
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", v)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
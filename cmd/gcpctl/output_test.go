@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+)
+
+func TestWaitForPollsUntilDone(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = time.Millisecond
+
+	calls := 0
+	err := waitFor(context.Background(), io.Discard, "test", func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = waitFor(ctx, io.Discard, "test", func(context.Context) (bool, error) { return false, nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPrintResultFormats(t *testing.T) {
+	project := client.ProjectResponse{ProjectID: "my-project", State: "ACTIVE"}
+	tbl := table{headers: []string{"PROJECT_ID", "STATE"}, rows: [][]string{{"my-project", "ACTIVE"}}}
+
+	var out bytes.Buffer
+	require.NoError(t, printResult(&out, outputTable, project, tbl))
+	assert.Equal(t, "PROJECT_ID  STATE\nmy-project  ACTIVE\n", out.String())
+
+	out.Reset()
+	require.NoError(t, printResult(&out, outputYAML, project, tbl))
+	assert.Contains(t, out.String(), "project_id: my-project\n")
+
+	out.Reset()
+	require.NoError(t, printResult(&out, outputJSON, project, tbl))
+	assert.Contains(t, out.String(), `"project_id": "my-project"`)
+
+	assert.Error(t, printResult(&out, "xml", project, tbl))
+}
+
+func TestPrintErrorListsPolicyDecisionsAndLabelViolations(t *testing.T) {
+	err := &client.Error{
+		StatusCode:      http.StatusForbidden,
+		Type:            "PERMISSION_DENIED",
+		Message:         "request denied by policy",
+		PolicyDecisions: []client.PolicyDecision{{Policy: "buckets-in-us", Effect: "deny", Message: "Buckets must be created in a US location"}},
+		LabelViolations: []client.LabelViolation{{Key: "env", Value: "qa", Reason: "not_allowed", Message: "label env must be one of dev, prod"}},
+	}
+
+	var out bytes.Buffer
+	printError(&out, err)
+	assert.Equal(t, "Error: PERMISSION_DENIED: request denied by policy (HTTP 403)\n"+
+		"  policy buckets-in-us (deny): Buckets must be created in a US location\n"+
+		"  label env=qa (not_allowed): label env must be one of dev, prod\n", out.String())
+}
+
+func TestCreatesRetriedOnlyWithIdempotencyKeys(t *testing.T) {
+	defer func(enabled bool) { idempotencyKeys = enabled }(idempotencyKeys)
+
+	for _, enabled := range []bool{false, true} {
+		attempts := 0
+		var keys []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"data":{"name":"my-bucket"}}`))
+		}))
+
+		idempotencyKeys = enabled
+		opts := append(clientOptions("jwt-token"), client.WithRetryPolicy(client.RetryPolicy{MaxRetries: 1}))
+		c, err := client.New(server.URL, opts...)
+		require.NoError(t, err)
+		_, err = c.CreateBucket(context.Background(), &client.BucketRequest{Name: "my-bucket", Location: "US"})
+		server.Close()
+
+		if enabled {
+			require.NoError(t, err)
+			assert.Equal(t, 2, attempts)
+			assert.NotEmpty(t, keys[0])
+			assert.Equal(t, keys[0], keys[1], "retries reuse the key")
+		} else {
+			assert.Error(t, err)
+			assert.Equal(t, 1, attempts)
+			assert.Empty(t, keys[0])
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stuartshay/gcp-automation-api/pkg/client"
+)
+
+// Lifecycle states reported for projects and folders
+const (
+	stateActive          = "ACTIVE"
+	stateDeleteRequested = "DELETE_REQUESTED"
+)
+
+func projectsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "projects",
+		Aliases: []string{"project"},
+		Short:   "Manage GCP projects",
+	}
+
+	cmd.AddCommand(projectsCreateCmd(), projectsGetCmd(), projectsDeleteCmd())
+	return cmd
+}
+
+func projectsCreateCmd() *cobra.Command {
+	var (
+		req    client.ProjectRequest
+		labels []string
+		wait   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create PROJECT_ID",
+		Short: "Create a project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			req.ProjectID = args[0]
+			if req.DisplayName == "" {
+				req.DisplayName = req.ProjectID
+			}
+			if req.Labels, err = parseLabels(labels); err != nil {
+				return err
+			}
+
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			project, err := api.CreateProject(ctx, &req)
+			if err != nil {
+				return err
+			}
+
+			if wait && project.State != stateActive {
+				err := waitFor(ctx, cmd.ErrOrStderr(), "project "+project.ProjectID+" to become active", func(ctx context.Context) (bool, error) {
+					current, err := api.GetProject(ctx, project.ProjectID)
+					if err != nil {
+						return false, err
+					}
+					project = current
+					return project.State == stateActive, nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return printProject(cmd, project)
+		},
+	}
+
+	cmd.Flags().StringVar(&req.DisplayName, "display-name", "", "Display name (defaults to the project ID)")
+	cmd.Flags().StringVar(&req.ParentID, "parent-id", "", "Numeric ID of the parent organization or folder")
+	cmd.Flags().StringVar(&req.ParentType, "parent-type", "", "Parent type: organization or folder")
+	cmd.Flags().StringArrayVar(&labels, "label", nil, "Label as key=value (repeatable)")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the project is active")
+	_ = cmd.RegisterFlagCompletionFunc("parent-type", parentTypeCompletions)
+
+	return cmd
+}
+
+func projectsGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get PROJECT_ID",
+		Short: "Show a project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			project, err := api.GetProject(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printProject(cmd, project)
+		},
+	}
+}
+
+func projectsDeleteCmd() *cobra.Command {
+	var wait bool
+
+	cmd := &cobra.Command{
+		Use:   "delete PROJECT_ID",
+		Short: "Delete a project",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			api, err := newClient()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(cmd)
+			defer cancel()
+
+			projectID := args[0]
+			if err := api.DeleteProject(ctx, projectID); err != nil {
+				return err
+			}
+
+			if wait {
+				err := waitFor(ctx, cmd.ErrOrStderr(), "project "+projectID+" to be deleted", func(ctx context.Context) (bool, error) {
+					project, err := api.GetProject(ctx, projectID)
+					if err != nil {
+						if client.IsNotFound(err) {
+							return true, nil
+						}
+						return false, err
+					}
+					return project.State == stateDeleteRequested, nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Project %s deleted\n", projectID)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait until the project is marked for deletion")
+	return cmd
+}
+
+func printProject(cmd *cobra.Command, project *client.ProjectResponse) error {
+	t := table{headers: []string{"PROJECT_ID", "NAME", "NUMBER", "STATE", "PARENT", "LABELS", "CREATED"}}
+	parent := "-"
+	if project.ParentID != "" {
+		parent = project.ParentType + "/" + project.ParentID
+	}
+	t.rows = append(t.rows, []string{
+		project.ProjectID,
+		project.DisplayName,
+		strconv.FormatInt(project.ProjectNumber, 10),
+		orDash(project.State),
+		parent,
+		formatLabels(project.Labels),
+		formatTime(project.CreateTime),
+	})
+	return printResult(cmd.OutOrStdout(), output, project, t)
+}
+
+// parentTypeCompletions completes --parent-type values
+func parentTypeCompletions(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return []string{"organization", "folder"}, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// pollInterval is how often --wait re-reads a resource
+var pollInterval = 5 * time.Second
+
+// waitFor polls done until it reports true, returns an error, or ctx ends.
+// Progress is written to progress so that stdout stays machine-readable.
+func waitFor(ctx context.Context, progress io.Writer, what string, done func(context.Context) (bool, error)) error {
+	fmt.Fprintf(progress, "Waiting for %s...\n", what)
+	for {
+		ok, err := done(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %s: %w", what, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
@@ -33,66 +33,97 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/audit"
 	"github.com/stuartshay/gcp-automation-api/internal/config"
 	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/health"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
 	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+	"github.com/stuartshay/gcp-automation-api/internal/retry"
 	"github.com/stuartshay/gcp-automation-api/internal/services"
+	"github.com/stuartshay/gcp-automation-api/internal/tracing"
+	"google.golang.org/api/option"
 	"gopkg.in/yaml.v3"
 )
 
-// setupLogging configures logging to write to both file and console
-func setupLogging(cfg *config.Config) error {
-	// Create logs directory if it doesn't exist
-	logDir := filepath.Dir(cfg.LogFile)
-	if err := os.MkdirAll(logDir, 0750); err != nil {
-		return fmt.Errorf("failed to create log directory: %w", err)
-	}
-
-	// Open log file
-	logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
-	}
-
-	// Set up multi-writer to write to both file and console
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-	log.SetOutput(multiWriter)
-
-	// Set log format with timestamp
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
 
-	log.Printf("Logging configured - writing to: %s", cfg.LogFile)
-	return nil
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 func main() {
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"),
+		"YAML or TOML config file; environment variables take precedence (env CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := config.LoadFile(*configFile)
+	if err != nil {
+		fatal("Failed to load configuration", err)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("Failed to print configuration", err)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal("Invalid configuration", err)
+	}
+	if *printConfig {
+		return
+	}
+
+	// Setup logging; the standard log package writes through the same logger
+	logger, logOutput, err := logging.Setup(cfg)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to setup logging", err)
 	}
+	defer func() { _ = logOutput.Close() }()
+	logger.Info("Logging configured", "level", cfg.LogLevel, "format", cfg.LogFormat, "file", cfg.LogFile)
 
-	// Setup logging
-	if err := setupLogging(cfg); err != nil {
-		log.Fatalf("Failed to setup logging: %v", err)
+	metrics.SetBuildInfo(version)
+
+	// Export traces of requests and the GCP calls they make
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg, version)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("Failed to flush traces", "error", err)
+		}
+	}()
+	if cfg.TracingEnabled {
+		logger.Info("Tracing enabled", "endpoint", cfg.TracingEndpoint, "protocol", cfg.TracingProtocol)
 	}
 
 	// Initialize services
 	gcpService, err := services.NewGCPService(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize GCP service: %v", err)
+		fatal("Failed to initialize GCP service", err)
 	}
 
 	// Initialize authentication service
@@ -101,8 +132,127 @@ func main() {
 	// Initialize handlers
 	handler := handlers.NewHandler(gcpService, authService)
 
+	// Optionally act on GCP as a service account mapped from the caller's identity
+	if cfg.GCPImpersonationEnabled {
+		resolver := services.NewImpersonatingGCPServiceResolver(cfg)
+		defer func() {
+			if err := resolver.Close(); err != nil {
+				logger.Error("Failed to close impersonated GCP clients", "error", err)
+			}
+		}()
+		handler.WithGCPServiceResolver(resolver)
+		logger.Info("GCP impersonation enabled", "file", cfg.GCPImpersonationFile)
+	}
+
+	// Cloud Run endpoints query logs in the configured project
+	var opts []option.ClientOption
+	if cfg.GCPCredentials != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.GCPCredentials))
+	}
+	cloudRunService, err := services.NewCloudRunService(context.Background(), cfg.GCPProjectID, opts...)
+	if err != nil {
+		logger.Warn("Cloud Run endpoints disabled", "error", err)
+		cloudRunService = nil
+	} else {
+		cloudRunService.SetRetryPolicy(retry.NewPolicy(cfg))
+		defer func() {
+			if err := cloudRunService.Close(); err != nil {
+				logger.Error("Failed to close Cloud Run clients", "error", err)
+			}
+		}()
+		handler.WithCloudRunService(cloudRunService)
+	}
+
+	// Record every mutating API call
+	var auditSink audit.Sink
+	if cfg.AuditEnabled {
+		auditSink, err = audit.NewSink(context.Background(), cfg)
+		if err != nil {
+			fatal("Failed to initialize audit log", err)
+		}
+		defer func() {
+			if err := auditSink.Close(); err != nil {
+				logger.Error("Failed to close audit log", "error", err)
+			}
+		}()
+		handler.WithAuditLog(auditSink, cfg.AuditViewerGroups)
+		logger.Info("Audit log enabled", "sink", cfg.AuditSink)
+	}
+
+	// Check create requests against the guardrail policies; the policies are
+	// reloaded on SIGHUP
+	policies, err := policy.Load(cfg.PolicyFiles)
+	if err != nil {
+		fatal("Failed to load policies", err)
+	}
+	handler.WithPolicies(policies)
+	if policies.Len() > 0 {
+		logger.Info("Policies enabled", "files", cfg.PolicyFiles, "policies", policies.Len())
+	}
+
+	// Check the labels of new buckets and projects against their schemas
+	labelSchemas, err := labels.NewRegistry(cfg.LabelSchemaFile)
+	if err != nil {
+		fatal("Failed to load label schemas", err)
+	}
+	handler.WithLabelSchemas(labelSchemas, cfg.LabelSchemaAdminGroups)
+
+	// Make create requests carrying an Idempotency-Key safe to retry
+	var idempotencyStore idempotency.Store
+	if cfg.IdempotencyEnabled {
+		idempotencyStore, err = idempotency.NewStore(cfg)
+		if err != nil {
+			fatal("Failed to initialize idempotency store", err)
+		}
+		defer func() {
+			if err := idempotencyStore.Close(); err != nil {
+				logger.Error("Failed to close idempotency store", "error", err)
+			}
+		}()
+		logger.Info("Idempotency keys enabled", "store", cfg.IdempotencyStore)
+	}
+
+	// Limit request rates per caller; the limits are reloaded on SIGHUP
+	var rateLimiter *authmiddleware.RateLimiter
+	if cfg.RateLimitEnabled {
+		rateLimiter = authmiddleware.NewRateLimiter(cfg.RateLimits)
+	}
+
+	// Report liveness, and readiness based on the server's dependencies
+	probeOpts := health.Options{
+		Timeout:  time.Duration(cfg.HealthCheckTimeoutSeconds) * time.Second,
+		CacheTTL: time.Duration(cfg.HealthCheckCacheSeconds) * time.Second,
+	}
+	liveness := health.NewProbe(probeOpts)
+	liveness.Add("ping", health.CheckerFunc(func(context.Context) error { return nil }))
+	readiness := health.NewProbe(probeOpts)
+	readiness.Add("config", health.CheckerFunc(func(context.Context) error {
+		// The configuration the server would load on SIGHUP or restart
+		next, err := config.LoadFile(cfg.ConfigFile)
+		if err != nil {
+			return err
+		}
+		return next.Validate()
+	}))
+	readiness.Add("resourcemanager", health.CheckerFunc(gcpService.PingResourceManager))
+	readiness.Add("storage", health.CheckerFunc(gcpService.PingStorage))
+	if cloudRunService != nil {
+		readiness.Add("logging", health.CheckerFunc(cloudRunService.PingLogging))
+	}
+	if pinger, ok := auditSink.(health.Pinger); ok {
+		readiness.Add("audit", health.CheckerFunc(pinger.Ping))
+	}
+	if pinger, ok := idempotencyStore.(health.Pinger); ok {
+		readiness.Add("idempotency", health.CheckerFunc(pinger.Ping))
+	}
+
 	// Setup router
-	router := setupRouter(handler, authService, cfg)
+	router := setupRouter(handler, authService, auditSink, idempotencyStore, rateLimiter, liveness, readiness, cfg)
+
+	// Requests run in a context that is cancelled if they outlast the graceful
+	// shutdown, which cancels their in-flight GCP calls
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -112,13 +262,31 @@ func main() {
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      30 * time.Second,
 		IdleTimeout:       60 * time.Second,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		BaseContext:       func(net.Listener) context.Context { return requestsCtx },
 	}
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Server starting on port %s", cfg.Port)
+		logger.Info("Server starting", "port", cfg.Port)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("Failed to start server", err)
+		}
+	}()
+
+	// On SIGHUP reopen the log file, e.g. after logrotate has moved it away,
+	// and reload the settings that can change at runtime
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		current := cfg
+		for range hup {
+			if err := logOutput.Reopen(); err != nil {
+				logger.Error("Failed to reopen log file", "error", err)
+			} else {
+				logger.Info("Reopened log file", "file", current.LogFile)
+			}
+			current = reloadConfig(current, rateLimiter, policies, logger)
 		}
 	}()
 
@@ -126,36 +294,74 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	logger.Info("Shutting down server")
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		cancelRequests()
+		fatal("Server forced to shutdown", err)
 	}
 
-	log.Println("Server exited")
+	logger.Info("Server exited")
 }
 
-func setupRouter(handler *handlers.Handler, authService *services.AuthService, cfg *config.Config) *echo.Echo {
-	// Create Echo instance
+// reloadConfig re-reads the configuration and applies the log level, rate
+// limits and policies. It returns the configuration now in effect, which is
+// unchanged if the new one is invalid.
+func reloadConfig(current *config.Config, rateLimiter *authmiddleware.RateLimiter, policies *policy.Engine, logger *slog.Logger) *config.Config {
+	next, err := config.LoadFile(current.ConfigFile)
+	if err == nil {
+		err = next.Validate()
+	}
+	if err != nil {
+		logger.Error("Failed to reload configuration; keeping the current one", "error", err)
+		return current
+	}
+
+	level, err := logging.ParseLevel(next.LogLevel)
+	if err != nil {
+		logger.Error("Failed to reload configuration; keeping the current one", "error", err)
+		return current
+	}
+	logging.SetLevel(level)
+	if rateLimiter != nil && next.RateLimitEnabled {
+		rateLimiter.SetLimits(next.RateLimits)
+	}
+	if err := policies.Reload(next.PolicyFiles); err != nil {
+		logger.Error("Failed to reload policies; keeping the current ones", "error", err)
+	}
+
+	var restart []string
+	for _, key := range current.Changed(next) {
+		if !config.ReloadableSettings[key] {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		logger.Warn("Some changed settings take effect only after a restart", "settings", restart)
+	}
+	logger.Info("Configuration reloaded", "file", next.ConfigFile, "log_level", next.LogLevel)
+	return next
+}
+
+func setupRouter(handler *handlers.Handler, authService *services.AuthService, auditSink audit.Sink, idempotencyStore idempotency.Store, rateLimiter *authmiddleware.RateLimiter, liveness, readiness *health.Probe, cfg *config.Config) *echo.Echo {
+	// Create Echo instance; errors are rendered with the status matching
+	// their GCP error code
 	e := echo.New()
+	e.HTTPErrorHandler = handlers.ErrorHandler
 
 	// Middleware
-	e.Use(middleware.Logger())
+	if cfg.MetricsEnabled {
+		e.Use(metrics.Middleware())
+	}
+	e.Use(tracing.Middleware(cfg.TracingServiceName))
+	e.Use(logging.Middleware(slog.Default()))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
-	// Custom logging middleware to write to our log file
-	if cfg.LogFile != "" {
-		logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
-		if err == nil {
-			e.Logger.SetOutput(io.MultiWriter(os.Stdout, logFile))
-		}
-	}
-
 	// Custom Swagger UI endpoint
 	e.GET("/swagger/", func(c echo.Context) error {
 		return c.File("static/swagger-ui.html")
@@ -175,14 +381,14 @@ func setupRouter(handler *handlers.Handler, authService *services.AuthService, c
 		openapiFile := "api/v1/openapi.yaml"
 		openapiData, err := os.ReadFile(openapiFile)
 		if err != nil {
-			log.Printf("Failed to read openapi.yaml file: %v", err)
+			logging.FromContext(c.Request().Context()).Error("Failed to read openapi.yaml file", "error", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load swagger documentation")
 		}
 
 		// Parse the YAML content
 		var openapiSpec map[string]interface{}
 		if err := yaml.Unmarshal(openapiData, &openapiSpec); err != nil {
-			log.Printf("Failed to unmarshal openapi.yaml file: %v", err)
+			logging.FromContext(c.Request().Context()).Error("Failed to unmarshal openapi.yaml file", "error", err)
 			return echo.NewHTTPError(http.StatusInternalServerError, "Invalid openapi.yaml format")
 		}
 
@@ -204,12 +410,35 @@ func setupRouter(handler *handlers.Handler, authService *services.AuthService, c
 		return c.JSON(http.StatusOK, map[string]string{"status": "healthy"})
 	})
 
+	// Liveness and readiness probes (no authentication required); add
+	// ?verbose for the status and latency of each check
+	e.GET("/livez", liveness.Handler())
+	e.GET("/readyz", readiness.Handler())
+
+	// Prometheus metrics endpoint (no authentication required)
+	if cfg.MetricsEnabled {
+		e.GET("/metrics", metrics.Handler())
+	}
+
 	// Create authentication middleware
-	authMiddleware := authmiddleware.NewAuthMiddleware(cfg)
+	authMiddleware := authmiddleware.NewAuthMiddleware(cfg, authService.IdentityProviders())
 
 	// API v1 routes (all require authentication)
 	v1 := e.Group("/api/v1")
+	if rateLimiter != nil {
+		// Requests failing authentication are limited per client IP
+		v1.Use(rateLimiter.UnauthenticatedMiddleware())
+	}
 	v1.Use(authMiddleware.RequireAuth())
+	if rateLimiter != nil {
+		v1.Use(rateLimiter.Middleware())
+	}
+	if auditSink != nil {
+		v1.Use(audit.Middleware(auditSink, cfg.AuditMaxBodyBytes))
+	}
+	if idempotencyStore != nil {
+		v1.Use(idempotency.Middleware(idempotencyStore))
+	}
 	{
 		// Project endpoints
 		projects := v1.Group("/projects")
@@ -233,6 +462,30 @@ func setupRouter(handler *handlers.Handler, authService *services.AuthService, c
 			buckets.POST("", handler.CreateBucket)
 			buckets.GET("/:name", handler.GetBucket)
 			buckets.DELETE("/:name", handler.DeleteBucket)
+
+			// Object endpoints (object names may contain slashes)
+			buckets.GET("/:name/objects", handler.ListObjects)
+			buckets.GET("/:name/objects/*", handler.GetObject)
+			buckets.DELETE("/:name/objects/*", handler.DeleteObject)
+		}
+
+		// Cloud Run endpoints
+		cloudrun := v1.Group("/cloudrun")
+		{
+			cloudrun.GET("/logs/:serviceName/:region", handler.GetCloudRunLogs)
+		}
+
+		// Audit log
+		v1.GET("/audit", handler.ListAuditEntries)
+
+		// Label schema endpoints
+		labelSchemas := v1.Group("/label-schemas")
+		{
+			labelSchemas.GET("", handler.ListLabelSchemas)
+			labelSchemas.GET("/:resource_type", handler.GetLabelSchema)
+			labelSchemas.PUT("/:resource_type", handler.PutLabelSchema)
+			labelSchemas.DELETE("/:resource_type", handler.DeleteLabelSchema)
+			labelSchemas.GET("/:resource_type/compliance", handler.GetLabelCompliance)
 		}
 	}
 
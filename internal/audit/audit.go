@@ -0,0 +1,116 @@
+// Package audit records every mutating API call (who, what, outcome and
+// latency) to a pluggable sink and answers queries over the recorded entries.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Query limits
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// Sink stores audit entries. Sinks are append-only: entries are never
+// modified or removed except by the sink's own retention.
+type Sink interface {
+	// Record appends an entry
+	Record(ctx context.Context, entry *models.AuditEntry) error
+	// Query returns matching entries, newest first
+	Query(ctx context.Context, filter Filter) ([]models.AuditEntry, error)
+	// Close flushes and releases the sink
+	Close() error
+}
+
+// Filter selects audit entries. Empty fields match everything.
+type Filter struct {
+	// User matches the user ID or email
+	User         string
+	ResourceType string
+	Resource     string
+	Method       string
+	Outcome      string
+	RequestID    string
+	Since        time.Time
+	Until        time.Time
+	Limit        int
+}
+
+// NewSink creates the sink selected by AUDIT_SINK
+func NewSink(ctx context.Context, cfg *config.Config) (Sink, error) {
+	switch cfg.AuditSink {
+	case "jsonl", "":
+		return NewJSONLSink(JSONLOptions{
+			Path:       cfg.AuditFile,
+			MaxSizeMB:  cfg.AuditMaxSizeMB,
+			MaxBackups: cfg.AuditMaxBackups,
+			MaxAgeDays: cfg.AuditMaxAgeDays,
+			Compress:   cfg.AuditCompress,
+		})
+	case "sqlite":
+		return NewSQLiteSink(cfg.AuditDatabase)
+	case "cloudlogging":
+		return NewCloudLoggingSink(ctx, cfg.GCPProjectID, cfg.AuditLogName)
+	default:
+		return nil, fmt.Errorf("unknown audit sink %q", cfg.AuditSink)
+	}
+}
+
+// limit returns the effective result limit of the filter
+func (f Filter) limit() int {
+	if f.Limit <= 0 {
+		return DefaultQueryLimit
+	}
+	if f.Limit > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return f.Limit
+}
+
+// Matches reports whether entry satisfies the filter
+func (f Filter) Matches(entry *models.AuditEntry) bool {
+	if f.User != "" && !strings.EqualFold(entry.Email, f.User) && entry.UserID != f.User {
+		return false
+	}
+	if f.ResourceType != "" && entry.ResourceType != f.ResourceType {
+		return false
+	}
+	if f.Resource != "" && entry.Resource != f.Resource {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(entry.Method, f.Method) {
+		return false
+	}
+	if f.Outcome != "" && entry.Outcome != f.Outcome {
+		return false
+	}
+	if f.RequestID != "" && entry.RequestID != f.RequestID {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Timestamp.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// newestFirst sorts entries newest first and applies the filter's limit
+func newestFirst(entries []models.AuditEntry, filter Filter) []models.AuditEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if limit := filter.limit(); len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// memorySink records entries in memory
+type memorySink struct {
+	entries []models.AuditEntry
+}
+
+func (s *memorySink) Record(_ context.Context, entry *models.AuditEntry) error {
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+func (s *memorySink) Query(_ context.Context, filter Filter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for i := range s.entries {
+		if filter.Matches(&s.entries[i]) {
+			entries = append(entries, s.entries[i])
+		}
+	}
+	return newestFirst(entries, filter), nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestSanitizeRedactsSensitiveFields(t *testing.T) {
+	body := Sanitize([]byte(`{"name":"my-bucket","labels":{"team":"data"},"client_secret":"s3cr3t",
+		"nested":[{"Access-Token":"abc","keep":"yes"}]}`))
+
+	assert.JSONEq(t, `{"name":"my-bucket","labels":{"team":"data"},"client_secret":"[REDACTED]",
+		"nested":[{"Access-Token":"[REDACTED]","keep":"yes"}]}`, string(body))
+	assert.Nil(t, Sanitize([]byte("not json")))
+	assert.Nil(t, Sanitize(nil))
+}
+
+func TestMiddlewareRecordsMutatingRequests(t *testing.T) {
+	sink := &memorySink{}
+	e := echo.New()
+	g := e.Group("/api/v1", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", "user-1")
+			c.Set("user_email", "jane@example.com")
+			return next(c)
+		}
+	}, Middleware(sink, 1024))
+	g.POST("/buckets", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, models.SuccessResponse{Message: "created"})
+	})
+	g.DELETE("/buckets/:name/objects/*", func(c echo.Context) error {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Object not found", Message: "no such object", Code: 404})
+	})
+	g.GET("/buckets/:name", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/v1/buckets", strings.NewReader(`{"name":"my-bucket","token":"x"}`)),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/buckets/my-bucket/objects/a/b.txt", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/buckets/my-bucket", nil),
+	}
+	requests[0].Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	requests[0].Header.Set(echo.HeaderXRequestID, "req-1")
+	for _, req := range requests {
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.Len(t, sink.entries, 2, "GET requests are not audited")
+
+	created := sink.entries[0]
+	assert.Equal(t, "req-1", created.RequestID)
+	assert.Equal(t, "jane@example.com", created.Email)
+	assert.Equal(t, "user-1", created.UserID)
+	assert.Equal(t, "buckets", created.ResourceType)
+	assert.Equal(t, "my-bucket", created.Resource)
+	assert.Equal(t, http.StatusCreated, created.Status)
+	assert.Equal(t, models.AuditOutcomeSuccess, created.Outcome)
+	assert.JSONEq(t, `{"name":"my-bucket","token":"[REDACTED]"}`, string(created.Body))
+
+	deleted := sink.entries[1]
+	assert.Equal(t, "my-bucket/a/b.txt", deleted.Resource)
+	assert.Equal(t, http.StatusNotFound, deleted.Status)
+	assert.Equal(t, models.AuditOutcomeFailure, deleted.Outcome)
+	assert.Equal(t, "Object not found: no such object", deleted.Error)
+}
+
+func TestMiddlewarePassesLargeBodiesThrough(t *testing.T) {
+	sink := &memorySink{}
+	e := echo.New()
+	var received string
+	e.POST("/api/v1/projects", func(c echo.Context) error {
+		var req models.ProjectRequest
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		received = req.DisplayName
+		return c.NoContent(http.StatusCreated)
+	}, Middleware(sink, 16))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/projects",
+		strings.NewReader(`{"project_id":"my-project","display_name":"A display name longer than the limit"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "A display name longer than the limit", received)
+	require.Len(t, sink.entries, 1)
+	assert.Nil(t, sink.entries[0].Body)
+}
+
+// testSinks returns each file-backed sink rooted in a temporary directory
+func testSinks(t *testing.T) map[string]Sink {
+	dir := t.TempDir()
+	jsonl, err := NewJSONLSink(JSONLOptions{Path: filepath.Join(dir, "audit.jsonl"), MaxSizeMB: 1})
+	require.NoError(t, err)
+	sqlite, err := NewSQLiteSink(filepath.Join(dir, "audit.db"))
+	require.NoError(t, err)
+
+	sinks := map[string]Sink{"jsonl": jsonl, "sqlite": sqlite}
+	t.Cleanup(func() {
+		for _, sink := range sinks {
+			_ = sink.Close()
+		}
+	})
+	return sinks
+}
+
+func TestSinksRecordAndQuery(t *testing.T) {
+	base := time.Date(2025, 9, 20, 10, 0, 0, 0, time.UTC)
+	entries := []models.AuditEntry{
+		{Timestamp: base, Email: "jane@example.com", Method: "POST", ResourceType: "buckets", Resource: "logs", Status: 201, Outcome: "success", Body: []byte(`{"name":"logs"}`)},
+		{Timestamp: base.Add(time.Minute), Email: "bob@example.com", Method: "DELETE", ResourceType: "buckets", Resource: "logs", Status: 200, Outcome: "success"},
+		{Timestamp: base.Add(2 * time.Minute), Email: "jane@example.com", Method: "DELETE", ResourceType: "projects", Resource: "demo", Status: 403, Outcome: "failure", Error: "forbidden"},
+	}
+
+	for name, sink := range testSinks(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for i := range entries {
+				require.NoError(t, sink.Record(ctx, &entries[i]))
+			}
+
+			all, err := sink.Query(ctx, Filter{})
+			require.NoError(t, err)
+			require.Len(t, all, 3)
+			assert.Equal(t, "demo", all[0].Resource, "newest first")
+			assert.JSONEq(t, `{"name":"logs"}`, string(all[2].Body))
+
+			whoDeleted, err := sink.Query(ctx, Filter{ResourceType: "buckets", Resource: "logs", Method: "delete"})
+			require.NoError(t, err)
+			require.Len(t, whoDeleted, 1)
+			assert.Equal(t, "bob@example.com", whoDeleted[0].Email)
+
+			byUser, err := sink.Query(ctx, Filter{User: "JANE@example.com", Since: base.Add(time.Second)})
+			require.NoError(t, err)
+			require.Len(t, byUser, 1)
+			assert.Equal(t, "failure", byUser[0].Outcome)
+			assert.True(t, byUser[0].Timestamp.Equal(base.Add(2*time.Minute)))
+
+			limited, err := sink.Query(ctx, Filter{Until: base.Add(2 * time.Minute), Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, limited, 1)
+			assert.Equal(t, "bob@example.com", limited[0].Email)
+		})
+	}
+}
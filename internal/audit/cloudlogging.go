@@ -0,0 +1,166 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"cloud.google.com/go/logging/logadmin"
+	"google.golang.org/api/iterator"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// CloudLoggingSink writes entries to a Cloud Logging log and queries them back
+type CloudLoggingSink struct {
+	projectID string
+	logName   string
+	client    *logging.Client
+	admin     *logadmin.Client
+	logger    *logging.Logger
+}
+
+// NewCloudLoggingSink creates a sink writing to logName in projectID
+func NewCloudLoggingSink(ctx context.Context, projectID, logName string) (*CloudLoggingSink, error) {
+	client, err := logging.NewClient(ctx, "projects/"+projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logging client: %w", err)
+	}
+	client.OnError = func(err error) {
+		slog.Error("Failed to write audit entry to Cloud Logging", "error", err)
+	}
+
+	admin, err := logadmin.NewClient(ctx, projectID)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to create log admin client: %w", err)
+	}
+
+	return &CloudLoggingSink{
+		projectID: projectID,
+		logName:   logName,
+		client:    client,
+		admin:     admin,
+		logger:    client.Logger(logName),
+	}, nil
+}
+
+// Record buffers an entry for delivery; Close flushes pending entries
+func (s *CloudLoggingSink) Record(_ context.Context, entry *models.AuditEntry) error {
+	severity := logging.Notice
+	if entry.Outcome != models.AuditOutcomeSuccess {
+		severity = logging.Warning
+	}
+
+	s.logger.Log(logging.Entry{
+		Timestamp: entry.Timestamp,
+		Severity:  severity,
+		Payload:   entry,
+		Labels: map[string]string{
+			"method":        entry.Method,
+			"resource_type": entry.ResourceType,
+			"outcome":       entry.Outcome,
+		},
+	})
+	return nil
+}
+
+// Query translates the filter into a Cloud Logging filter
+func (s *CloudLoggingSink) Query(ctx context.Context, filter Filter) ([]models.AuditEntry, error) {
+	it := s.admin.Entries(ctx, logadmin.Filter(s.logFilter(filter)), logadmin.NewestFirst())
+
+	entries := []models.AuditEntry{}
+	for len(entries) < filter.limit() {
+		logEntry, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query audit log: %w", err)
+		}
+
+		entry, err := decodePayload(logEntry.Payload)
+		if err != nil {
+			continue
+		}
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = logEntry.Timestamp
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// Ping checks that the Cloud Logging API is available by listing at most one
+// log in the project
+func (s *CloudLoggingSink) Ping(ctx context.Context) error {
+	it := s.admin.Logs(ctx)
+	it.PageInfo().MaxSize = 1
+	_, err := it.Next()
+	if err == iterator.Done {
+		return nil
+	}
+	return err
+}
+
+// Close flushes buffered entries and closes the clients
+func (s *CloudLoggingSink) Close() error {
+	err := s.client.Close()
+	if adminErr := s.admin.Close(); err == nil {
+		err = adminErr
+	}
+	return err
+}
+
+func (s *CloudLoggingSink) logFilter(filter Filter) string {
+	clauses := []string{fmt.Sprintf("logName=%s", strconv.Quote(fmt.Sprintf("projects/%s/logs/%s", s.projectID, s.logName)))}
+	eq := func(field, value string) {
+		if value != "" {
+			clauses = append(clauses, fmt.Sprintf("%s=%s", field, strconv.Quote(value)))
+		}
+	}
+
+	if filter.User != "" {
+		clauses = append(clauses, fmt.Sprintf("(jsonPayload.email=%s OR jsonPayload.user_id=%s)",
+			strconv.Quote(filter.User), strconv.Quote(filter.User)))
+	}
+	eq("jsonPayload.resource_type", filter.ResourceType)
+	eq("jsonPayload.resource", filter.Resource)
+	eq("jsonPayload.method", strings.ToUpper(filter.Method))
+	eq("jsonPayload.outcome", filter.Outcome)
+	eq("jsonPayload.request_id", filter.RequestID)
+	if !filter.Since.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("timestamp>=%s", strconv.Quote(filter.Since.UTC().Format(time.RFC3339Nano))))
+	}
+	if !filter.Until.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("timestamp<%s", strconv.Quote(filter.Until.UTC().Format(time.RFC3339Nano))))
+	}
+
+	return strings.Join(clauses, " AND ")
+}
+
+// decodePayload converts a JSON log payload back into an audit entry
+func decodePayload(payload interface{}) (*models.AuditEntry, error) {
+	structPayload, ok := payload.(*structpb.Struct)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload type %T", payload)
+	}
+
+	data, err := protojson.Marshal(structPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.AuditEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// maxErrorBody is how much of an error response is kept to extract its message
+const maxErrorBody = 4096
+
+// resourceNameFields are request body fields that name the resource being
+// created, in order of preference
+var resourceNameFields = []string{"project_id", "name", "display_name"}
+
+// Middleware records every POST, PUT, PATCH and DELETE request that is not
+// a dry run to sink. It must run after authentication so that the caller is
+// known. Request bodies larger than maxBodyBytes are not recorded. Failures
+// to record are logged and never fail the request.
+func Middleware(sink Sink, maxBodyBytes int) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !isMutating(req.Method) {
+				return next(c)
+			}
+			if dryRun, err := dryrun.Requested(req); dryRun || err != nil {
+				// Nothing is changed
+				return next(c)
+			}
+
+			start := time.Now()
+			body := readBody(req, maxBodyBytes)
+
+			capture := &errorCapture{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = capture
+
+			err := next(c)
+			if err != nil {
+				// Render the error now so that the recorded status is the one sent
+				c.Error(err)
+			}
+			status := c.Response().Status
+
+			userID, email, _ := authmiddleware.GetUserFromContext(c)
+			resourceType, resource := resourceOf(c, body)
+			entry := &models.AuditEntry{
+				Timestamp:    start.UTC(),
+				RequestID:    requestID(c),
+				UserID:       userID,
+				Email:        email,
+				Method:       req.Method,
+				Path:         req.URL.Path,
+				ResourceType: resourceType,
+				Resource:     resource,
+				Body:         Sanitize(body),
+				Status:       status,
+				Outcome:      models.AuditOutcomeSuccess,
+				LatencyMS:    time.Since(start).Milliseconds(),
+			}
+			if status >= http.StatusBadRequest {
+				entry.Outcome = models.AuditOutcomeFailure
+				entry.Error = errorMessage(err, capture.body.Bytes())
+			}
+
+			if recordErr := sink.Record(req.Context(), entry); recordErr != nil {
+				logging.FromContext(req.Context()).ErrorContext(req.Context(), "Failed to record audit entry",
+					"path", req.URL.Path, "error", recordErr)
+			}
+			return err
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// readBody reads up to limit bytes of the request body for auditing and
+// restores the body for the handler. It returns nil if the body is larger.
+func readBody(req *http.Request, limit int) []byte {
+	if req.Body == nil || limit <= 0 {
+		return nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+
+	if err != nil || len(buf) > limit {
+		return nil
+	}
+	return buf
+}
+
+// requestID returns the request ID assigned by the RequestID middleware or
+// supplied by the client
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// resourceOf derives the resource type from the route (e.g. "buckets" for
+// /api/v1/buckets/:name) and the resource from the path parameters, or from
+// the request body for creates
+func resourceOf(c echo.Context, body []byte) (resourceType, resource string) {
+	route := strings.TrimPrefix(c.Path(), "/api/v1/")
+	resourceType, _, _ = strings.Cut(route, "/")
+
+	var parts []string
+	for _, value := range c.ParamValues() {
+		if value = strings.TrimPrefix(value, "/"); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if len(parts) > 0 {
+		return resourceType, strings.Join(parts, "/")
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal(body, &fields) == nil {
+		for _, name := range resourceNameFields {
+			if value, ok := fields[name].(string); ok && value != "" {
+				return resourceType, value
+			}
+		}
+	}
+	return resourceType, ""
+}
+
+// errorMessage extracts a failure description from the handler's error or
+// its ErrorResponse body
+func errorMessage(err error, body []byte) string {
+	if err != nil {
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			return fmt.Sprint(httpErr.Message)
+		}
+		return err.Error()
+	}
+
+	var resp models.ErrorResponse
+	if json.Unmarshal(body, &resp) == nil {
+		if resp.Message != "" {
+			return resp.Error + ": " + resp.Message
+		}
+		return resp.Error
+	}
+	return ""
+}
+
+// errorCapture keeps the start of error response bodies
+type errorCapture struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *errorCapture) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *errorCapture) Write(b []byte) (int, error) {
+	if w.status >= http.StatusBadRequest && w.body.Len() < maxErrorBody {
+		w.body.Write(b[:min(len(b), maxErrorBody-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *errorCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
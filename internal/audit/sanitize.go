@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redacted replaces the values of sensitive fields
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are substrings of field names whose values are never recorded
+var sensitiveKeys = []string{
+	"password",
+	"passphrase",
+	"secret",
+	"token",
+	"credential",
+	"private_key",
+	"api_key",
+	"authorization",
+}
+
+// Sanitize returns a copy of a JSON request body with sensitive fields
+// redacted. Bodies that are empty or not JSON are not recorded.
+func Sanitize(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil
+	}
+
+	sanitized, err := json.Marshal(redact(value))
+	if err != nil {
+		return nil
+	}
+	return sanitized
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redact(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Registers the pure-Go "sqlite" driver, so builds with CGO_ENABLED=0 work
+	_ "modernc.org/sqlite"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp     INTEGER NOT NULL,
+	request_id    TEXT NOT NULL,
+	user_id       TEXT NOT NULL,
+	email         TEXT NOT NULL,
+	method        TEXT NOT NULL,
+	path          TEXT NOT NULL,
+	resource_type TEXT NOT NULL,
+	resource      TEXT NOT NULL,
+	body          TEXT,
+	status        INTEGER NOT NULL,
+	outcome       TEXT NOT NULL,
+	error         TEXT NOT NULL,
+	latency_ms    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_log_timestamp ON audit_log (timestamp);
+CREATE INDEX IF NOT EXISTS audit_log_resource ON audit_log (resource_type, resource);
+CREATE INDEX IF NOT EXISTS audit_log_email ON audit_log (email);
+`
+
+// SQLiteSink stores entries in a SQLite database
+type SQLiteSink struct {
+	db *sql.DB
+}
+
+// NewSQLiteSink opens or creates the database at path
+func NewSQLiteSink(path string) (*SQLiteSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create audit database directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize audit database: %w", err)
+	}
+
+	return &SQLiteSink{db: db}, nil
+}
+
+// Ping checks the database connection
+func (s *SQLiteSink) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Record inserts an entry
+func (s *SQLiteSink) Record(ctx context.Context, entry *models.AuditEntry) error {
+	var body interface{}
+	if len(entry.Body) > 0 {
+		body = string(entry.Body)
+	}
+
+	_, err := s.db.ExecContext(ctx, `INSERT INTO audit_log
+		(timestamp, request_id, user_id, email, method, path, resource_type, resource, body, status, outcome, error, latency_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Timestamp.UnixNano(), entry.RequestID, entry.UserID, entry.Email, entry.Method, entry.Path,
+		entry.ResourceType, entry.Resource, body, entry.Status, entry.Outcome, entry.Error, entry.LatencyMS)
+	return err
+}
+
+// Query selects matching entries using the database indexes
+func (s *SQLiteSink) Query(ctx context.Context, filter Filter) ([]models.AuditEntry, error) {
+	var where []string
+	var args []interface{}
+	add := func(clause string, values ...interface{}) {
+		where = append(where, clause)
+		args = append(args, values...)
+	}
+
+	if filter.User != "" {
+		add("(email = ? COLLATE NOCASE OR user_id = ?)", filter.User, filter.User)
+	}
+	if filter.ResourceType != "" {
+		add("resource_type = ?", filter.ResourceType)
+	}
+	if filter.Resource != "" {
+		add("resource = ?", filter.Resource)
+	}
+	if filter.Method != "" {
+		add("method = ?", strings.ToUpper(filter.Method))
+	}
+	if filter.Outcome != "" {
+		add("outcome = ?", filter.Outcome)
+	}
+	if filter.RequestID != "" {
+		add("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		add("timestamp >= ?", filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		add("timestamp < ?", filter.Until.UnixNano())
+	}
+
+	query := `SELECT timestamp, request_id, user_id, email, method, path, resource_type, resource,
+		body, status, outcome, error, latency_ms FROM audit_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, filter.limit())
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry     models.AuditEntry
+			timestamp int64
+			body      sql.NullString
+		)
+		if err := rows.Scan(&timestamp, &entry.RequestID, &entry.UserID, &entry.Email, &entry.Method, &entry.Path,
+			&entry.ResourceType, &entry.Resource, &body, &entry.Status, &entry.Outcome, &entry.Error, &entry.LatencyMS); err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		entry.Timestamp = time.Unix(0, timestamp).UTC()
+		if body.Valid {
+			entry.Body = []byte(body.String)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Close closes the database
+func (s *SQLiteSink) Close() error {
+	return s.db.Close()
+}
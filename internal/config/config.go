@@ -1,96 +1,694 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the application
 type Config struct {
+	// ConfigFile is the file the configuration was loaded from, if any
+	ConfigFile     string
 	Port           string
 	GCPProjectID   string
 	GCPCredentials string
 	Environment    string
 	LogLevel       string
+	LogFormat      string // "json" or "text"
 	LogFile        string
+	LogMaxSizeMB   int
+	LogRotateHours int
+	LogMaxBackups  int
+	LogMaxAgeDays  int
+	LogCompress    bool
 	EnableDebug    bool
 	GCPRegion      string
 	GCPZone        string
+	// GCP Timeout Configuration: GCPTimeoutSeconds bounds each GCP service
+	// operation unless GCPOperationTimeouts has an entry for it
+	GCPTimeoutSeconds    int
+	GCPOperationTimeouts map[string]int
+	// GCP Retry Configuration: GCPRetryMax bounds the retries of each GCP
+	// service operation unless GCPRetryBudgets has an entry for it
+	GCPRetryMax              int
+	GCPRetryBudgets          map[string]int
+	GCPRetryInitialBackoffMS int
+	GCPRetryMaxBackoffMS     int
 	// JWT Configuration
 	JWTSecret          string
 	JWTExpirationHours int
 	GoogleClientID     string
 	GoogleClientSecret string
-	EnableGoogleAuth   bool
+	// GoogleAudiences are further OAuth client IDs accepted in the "aud"
+	// claim of Google ID tokens, e.g. those of auth-cli profiles
+	GoogleAudiences  []string
+	EnableGoogleAuth bool
 	// OAuth Configuration
-	OAuthTokenURL     string
-	OAuthRedirectURI  string
-	OAuthCallbackPort string
-	CredentialsDir    string
-	CredentialsFile   string
+	OAuthTokenURL      string
+	OAuthDeviceAuthURL string
+	OAuthRedirectURI   string
+	OAuthCallbackPort  string
+	CredentialsDir     string
+	CredentialsFile    string
+	ProfilesFile       string
+	ActiveProfile      string
+	// CLI Credential Storage
+	CredentialsBackend    string
+	CredentialsPassphrase string
+	// OIDC Identity Provider Configuration
+	OIDCProvidersFile string
+	OIDCProviders     []OIDCProviderConfig
+	// Login Authorization Policy
+	LoginAllowedDomains []string
+	LoginAllowedEmails  []string
+	LoginDeniedEmails   []string
+	LoginRequiredGroups []string
+	// Group Resolution ("", "static" or "google-directory")
+	GroupResolver        string
+	GroupMappingsFile    string
+	GroupMappings        map[string][]string
+	DirectoryAdminEmail  string
+	DirectoryCredentials string
+	DirectoryCustomerID  string
+	GroupCacheTTLMinutes int
+	// GCP Impersonation Configuration
+	GCPImpersonationEnabled bool
+	GCPImpersonationFile    string
+	GCPImpersonation        *ImpersonationConfig
+	// Audit Log Configuration
+	AuditEnabled      bool
+	AuditSink         string // "jsonl", "sqlite" or "cloudlogging"
+	AuditFile         string
+	AuditMaxSizeMB    int
+	AuditMaxBackups   int
+	AuditMaxAgeDays   int
+	AuditCompress     bool
+	AuditDatabase     string
+	AuditLogName      string
+	AuditMaxBodyBytes int
+	AuditViewerGroups []string
+	// Idempotency Configuration
+	IdempotencyEnabled            bool
+	IdempotencyStore              string // "memory" or "sqlite"
+	IdempotencyDatabase           string
+	IdempotencyTTLHours           int
+	IdempotencyLockTimeoutSeconds int
+	// Policy Configuration
+	PolicyFiles []string
+	// Label Schema Configuration
+	LabelSchemaFile        string
+	LabelSchemaAdminGroups []string
+	// Rate Limit Configuration
+	RateLimitEnabled bool
+	RateLimitFile    string
+	RateLimits       *RateLimitConfig
+	// Metrics Configuration
+	MetricsEnabled bool
+	// Tracing Configuration
+	TracingEnabled     bool
+	TracingEndpoint    string
+	TracingProtocol    string // "grpc" or "http/protobuf"
+	TracingInsecure    bool
+	TracingSampleRatio float64
+	TracingServiceName string
+	// Health Check Configuration
+	HealthCheckTimeoutSeconds int
+	HealthCheckCacheSeconds   int
 	// Swagger Configuration
 	SwaggerHost   string
 	SwaggerScheme string
+
+	// settings holds the effective value of every setting by variable name
+	settings map[string]string
 }
 
-// Load reads configuration from environment variables with defaults
+// Load reads configuration from environment variables, falling back to the
+// file named by CONFIG_FILE, if any, and then to defaults
 func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile reads configuration from environment variables, falling back to
+// the YAML or TOML file at path, if not empty, and then to defaults
+func LoadFile(path string) (*Config, error) {
+	src, err := newSource(path)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
-		Port:           getEnv("PORT", "8080"),
-		GCPProjectID:   getEnv("GCP_PROJECT_ID", ""),
-		GCPCredentials: getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
-		Environment:    getEnv("ENVIRONMENT", "development"),
-		LogLevel:       getEnv("LOG_LEVEL", "info"),
-		LogFile:        getEnv("LOG_FILE", "logs/app.log"),
-		EnableDebug:    getEnvAsBool("ENABLE_DEBUG", false),
-		GCPRegion:      getEnv("GCP_REGION", "us-central1"),
-		GCPZone:        getEnv("GCP_ZONE", "us-central1-a"),
+		ConfigFile:     path,
+		Port:           src.getEnv("PORT", "8080"),
+		GCPProjectID:   src.getEnv("GCP_PROJECT_ID", ""),
+		GCPCredentials: src.getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
+		Environment:    src.getEnv("ENVIRONMENT", "development"),
+		LogLevel:       src.getEnv("LOG_LEVEL", "info"),
+		LogFormat:      src.getEnv("LOG_FORMAT", "json"),
+		LogFile:        src.getEnv("LOG_FILE", "logs/app.log"),
+		LogMaxSizeMB:   src.getEnvAsInt("LOG_MAX_SIZE_MB", 100),
+		LogRotateHours: src.getEnvAsInt("LOG_ROTATE_HOURS", 0),
+		LogMaxBackups:  src.getEnvAsInt("LOG_MAX_BACKUPS", 7),
+		LogMaxAgeDays:  src.getEnvAsInt("LOG_MAX_AGE_DAYS", 30),
+		LogCompress:    src.getEnvAsBool("LOG_COMPRESS", true),
+		EnableDebug:    src.getEnvAsBool("ENABLE_DEBUG", false),
+		GCPRegion:      src.getEnv("GCP_REGION", "us-central1"),
+		GCPZone:        src.getEnv("GCP_ZONE", "us-central1-a"),
+		// GCP Timeout Configuration
+		GCPTimeoutSeconds:    src.getEnvAsInt("GCP_TIMEOUT_SECONDS", 30),
+		GCPOperationTimeouts: src.getEnvAsIntMap("GCP_OPERATION_TIMEOUTS"),
+		// GCP Retry Configuration
+		GCPRetryMax:              src.getEnvAsInt("GCP_RETRY_MAX", 3),
+		GCPRetryBudgets:          src.getEnvAsIntMap("GCP_RETRY_BUDGETS"),
+		GCPRetryInitialBackoffMS: src.getEnvAsInt("GCP_RETRY_INITIAL_BACKOFF_MS", 200),
+		GCPRetryMaxBackoffMS:     src.getEnvAsInt("GCP_RETRY_MAX_BACKOFF_MS", 5000),
 		// JWT Configuration
-		JWTSecret:          getEnv("JWT_SECRET", "your-super-secret-jwt-key-change-in-production"),
-		JWTExpirationHours: getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
-		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
-		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
-		EnableGoogleAuth:   getEnvAsBool("ENABLE_GOOGLE_AUTH", true),
+		JWTSecret:          src.getEnv("JWT_SECRET", defaultJWTSecret),
+		JWTExpirationHours: src.getEnvAsInt("JWT_EXPIRATION_HOURS", 24),
+		GoogleClientID:     src.getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret: src.getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleAudiences:    src.getEnvAsSlice("GOOGLE_AUDIENCES"),
+		EnableGoogleAuth:   src.getEnvAsBool("ENABLE_GOOGLE_AUTH", true),
 		// OAuth Configuration
-		OAuthTokenURL:     getEnv("OAUTH_TOKEN_URL", "https://oauth2.googleapis.com/token"),
-		OAuthRedirectURI:  getEnv("OAUTH_REDIRECT_URI", "http://localhost:8085/callback"),
-		OAuthCallbackPort: getEnv("OAUTH_CALLBACK_PORT", "8085"),
-		CredentialsDir:    getEnv("CREDENTIALS_DIR", ".gcp-automation"),
-		CredentialsFile:   getEnv("CREDENTIALS_FILE", "credentials.json"),
+		OAuthTokenURL:      src.getEnv("OAUTH_TOKEN_URL", "https://oauth2.googleapis.com/token"),
+		OAuthDeviceAuthURL: src.getEnv("OAUTH_DEVICE_AUTH_URL", "https://oauth2.googleapis.com/device/code"),
+		OAuthRedirectURI:   src.getEnv("OAUTH_REDIRECT_URI", "http://localhost:8085/callback"),
+		OAuthCallbackPort:  src.getEnv("OAUTH_CALLBACK_PORT", "8085"),
+		CredentialsDir:     src.getEnv("CREDENTIALS_DIR", ".gcp-automation"),
+		CredentialsFile:    src.getEnv("CREDENTIALS_FILE", "credentials.json"),
+		ProfilesFile:       src.getEnv("PROFILES_FILE", "config.yaml"),
+		ActiveProfile:      src.getEnv("GCP_AUTOMATION_PROFILE", ""),
+		// CLI Credential Storage
+		CredentialsBackend:    src.getEnv("CREDENTIALS_BACKEND", "auto"),
+		CredentialsPassphrase: src.getEnv("CREDENTIALS_PASSPHRASE", ""),
 		// Swagger Configuration
-		SwaggerHost:   getEnv("SWAGGER_HOST", "localhost:8080"),
-		SwaggerScheme: getEnv("SWAGGER_SCHEME", "http"),
+		SwaggerHost:   src.getEnv("SWAGGER_HOST", "localhost:8080"),
+		SwaggerScheme: src.getEnv("SWAGGER_SCHEME", "http"),
+		// OIDC Identity Provider Configuration
+		OIDCProvidersFile: src.getEnv("OIDC_PROVIDERS_FILE", ""),
+		// Login Authorization Policy
+		LoginAllowedDomains: src.getEnvAsSlice("LOGIN_ALLOWED_DOMAINS"),
+		LoginAllowedEmails:  src.getEnvAsSlice("LOGIN_ALLOWED_EMAILS"),
+		LoginDeniedEmails:   src.getEnvAsSlice("LOGIN_DENIED_EMAILS"),
+		LoginRequiredGroups: src.getEnvAsSlice("LOGIN_REQUIRED_GROUPS"),
+		// Group Resolution
+		GroupResolver:        src.getEnv("GROUP_RESOLVER", ""),
+		GroupMappingsFile:    src.getEnv("GROUP_MAPPINGS_FILE", ""),
+		DirectoryAdminEmail:  src.getEnv("DIRECTORY_ADMIN_EMAIL", ""),
+		DirectoryCredentials: src.getEnv("DIRECTORY_CREDENTIALS", src.getEnv("GOOGLE_APPLICATION_CREDENTIALS", "")),
+		DirectoryCustomerID:  src.getEnv("DIRECTORY_CUSTOMER_ID", "my_customer"),
+		GroupCacheTTLMinutes: src.getEnvAsInt("GROUP_CACHE_TTL_MINUTES", 15),
+		// GCP Impersonation Configuration
+		GCPImpersonationEnabled: src.getEnvAsBool("GCP_IMPERSONATION_ENABLED", false),
+		GCPImpersonationFile:    src.getEnv("GCP_IMPERSONATION_FILE", ""),
+		// Audit Log Configuration
+		AuditEnabled:      src.getEnvAsBool("AUDIT_ENABLED", false),
+		AuditSink:         src.getEnv("AUDIT_SINK", "jsonl"),
+		AuditFile:         src.getEnv("AUDIT_FILE", "logs/audit.jsonl"),
+		AuditMaxSizeMB:    src.getEnvAsInt("AUDIT_MAX_SIZE_MB", 100),
+		AuditMaxBackups:   src.getEnvAsInt("AUDIT_MAX_BACKUPS", 10),
+		AuditMaxAgeDays:   src.getEnvAsInt("AUDIT_MAX_AGE_DAYS", 90),
+		AuditCompress:     src.getEnvAsBool("AUDIT_COMPRESS", true),
+		AuditDatabase:     src.getEnv("AUDIT_DATABASE", "data/audit.db"),
+		AuditLogName:      src.getEnv("AUDIT_LOG_NAME", "gcp-automation-api-audit"),
+		AuditMaxBodyBytes: src.getEnvAsInt("AUDIT_MAX_BODY_BYTES", 64*1024),
+		AuditViewerGroups: src.getEnvAsSlice("AUDIT_VIEWER_GROUPS"),
+		// Idempotency Configuration
+		IdempotencyEnabled:            src.getEnvAsBool("IDEMPOTENCY_ENABLED", false),
+		IdempotencyStore:              src.getEnv("IDEMPOTENCY_STORE", "memory"),
+		IdempotencyDatabase:           src.getEnv("IDEMPOTENCY_DATABASE", "data/idempotency.db"),
+		IdempotencyTTLHours:           src.getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24),
+		IdempotencyLockTimeoutSeconds: src.getEnvAsInt("IDEMPOTENCY_LOCK_TIMEOUT_SECONDS", 300),
+		// Metrics Configuration
+		MetricsEnabled: src.getEnvAsBool("METRICS_ENABLED", true),
+		// Tracing Configuration
+		TracingEnabled:     src.getEnvAsBool("TRACING_ENABLED", false),
+		TracingEndpoint:    src.getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+		TracingProtocol:    src.getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc"),
+		TracingInsecure:    src.getEnvAsBool("OTEL_EXPORTER_OTLP_INSECURE", false),
+		TracingSampleRatio: src.getEnvAsFloat("TRACING_SAMPLE_RATIO", 1.0),
+		TracingServiceName: src.getEnv("OTEL_SERVICE_NAME", "gcp-automation-api"),
+		// Health Check Configuration
+		HealthCheckTimeoutSeconds: src.getEnvAsInt("HEALTH_CHECK_TIMEOUT_SECONDS", 5),
+		HealthCheckCacheSeconds:   src.getEnvAsInt("HEALTH_CHECK_CACHE_SECONDS", 10),
+		// Policy Configuration
+		PolicyFiles: src.getEnvAsSlice("POLICY_FILES"),
+		// Label Schema Configuration
+		LabelSchemaFile:        src.getEnv("LABEL_SCHEMA_FILE", ""),
+		LabelSchemaAdminGroups: src.getEnvAsSlice("LABEL_SCHEMA_ADMIN_GROUPS"),
+		// Rate Limit Configuration
+		RateLimitEnabled: src.getEnvAsBool("RATE_LIMIT_ENABLED", false),
+		RateLimitFile:    src.getEnv("RATE_LIMIT_FILE", ""),
+		RateLimits: &RateLimitConfig{
+			Default: RouteRateLimits{
+				Read: &TokenBucket{
+					PerSecond: src.getEnvAsFloat("RATE_LIMIT_READ_PER_SECOND", 10),
+					Burst:     src.getEnvAsInt("RATE_LIMIT_READ_BURST", 20),
+				},
+				Write: &TokenBucket{
+					PerSecond: src.getEnvAsFloat("RATE_LIMIT_WRITE_PER_SECOND", 1),
+					Burst:     src.getEnvAsInt("RATE_LIMIT_WRITE_BURST", 5),
+				},
+			},
+		},
+	}
+
+	if cfg.OIDCProvidersFile != "" {
+		providers, err := loadOIDCProviders(cfg.OIDCProvidersFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.OIDCProviders = providers
+	}
+	cfg.settings = src.settings
+	if err := src.err(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.LogFormat {
+	case "json", "text":
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q: must be \"json\" or \"text\"", cfg.LogFormat)
+	}
+
+	if cfg.LogMaxSizeMB < 0 || cfg.LogRotateHours < 0 || cfg.LogMaxBackups < 0 || cfg.LogMaxAgeDays < 0 {
+		return nil, fmt.Errorf("LOG_MAX_SIZE_MB, LOG_ROTATE_HOURS, LOG_MAX_BACKUPS and LOG_MAX_AGE_DAYS must not be negative")
+	}
+
+	switch cfg.GroupResolver {
+	case "", "google-directory":
+	case "static":
+		if cfg.GroupMappingsFile == "" {
+			return nil, fmt.Errorf("GROUP_RESOLVER=static requires GROUP_MAPPINGS_FILE")
+		}
+	default:
+		return nil, fmt.Errorf("invalid GROUP_RESOLVER %q: must be empty, \"static\" or \"google-directory\"", cfg.GroupResolver)
+	}
+
+	if cfg.GroupMappingsFile != "" {
+		mappings, err := loadGroupMappings(cfg.GroupMappingsFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GroupMappings = mappings
+	}
+
+	if cfg.AuditEnabled {
+		switch cfg.AuditSink {
+		case "jsonl", "sqlite":
+		case "cloudlogging":
+			if cfg.GCPProjectID == "" {
+				return nil, fmt.Errorf("AUDIT_SINK=cloudlogging requires GCP_PROJECT_ID")
+			}
+		default:
+			return nil, fmt.Errorf("invalid AUDIT_SINK %q: must be \"jsonl\", \"sqlite\" or \"cloudlogging\"", cfg.AuditSink)
+		}
+	}
+
+	if cfg.IdempotencyEnabled {
+		switch cfg.IdempotencyStore {
+		case "memory", "sqlite":
+		default:
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_STORE %q: must be \"memory\" or \"sqlite\"", cfg.IdempotencyStore)
+		}
+	}
+
+	if cfg.RateLimitEnabled {
+		if cfg.RateLimitFile != "" {
+			if err := loadRateLimits(cfg.RateLimitFile, cfg.RateLimits); err != nil {
+				return nil, err
+			}
+		}
+		if err := cfg.RateLimits.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.TracingEnabled {
+		switch cfg.TracingProtocol {
+		case "grpc", "http/protobuf":
+		default:
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_PROTOCOL %q: must be \"grpc\" or \"http/protobuf\"", cfg.TracingProtocol)
+		}
+		if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+			return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO %v: must be between 0 and 1", cfg.TracingSampleRatio)
+		}
+	}
+
+	if cfg.GCPImpersonationEnabled {
+		if cfg.GCPImpersonationFile == "" {
+			return nil, fmt.Errorf("GCP_IMPERSONATION_ENABLED requires GCP_IMPERSONATION_FILE")
+		}
+		impersonation, err := loadImpersonationConfig(cfg.GCPImpersonationFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GCPImpersonation = impersonation
 	}
 
 	return cfg, nil
 }
 
-// getEnv gets an environment variable with a fallback value
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// OIDCProviderConfig describes an external OpenID Connect identity provider
+type OIDCProviderConfig struct {
+	// Name is a short identifier for the provider (e.g. "contractors")
+	Name string `yaml:"name"`
+	// IssuerURL is the OIDC issuer; the discovery document is fetched from
+	// {IssuerURL}/.well-known/openid-configuration
+	IssuerURL string `yaml:"issuer_url"`
+	// ClientID and ClientSecret are used by auth-cli for the login flow
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// Audiences accepted in the "aud" claim (defaults to ClientID)
+	Audiences []string `yaml:"audiences"`
+	// AllowedDomains restricts logins to these email domains (empty allows all)
+	AllowedDomains []string `yaml:"allowed_domains"`
+	// AllowUnverifiedEmail skips the email_verified check for IdPs that omit
+	// it. It only takes effect when AllowedDomains is set, and unverified
+	// users are then matched on the hosted domain claim, never their email.
+	AllowUnverifiedEmail bool `yaml:"allow_unverified_email"`
+	// Scopes requested by auth-cli (defaults to openid email profile)
+	Scopes []string `yaml:"scopes"`
+	// Claims maps ID token claim names onto user fields
+	Claims OIDCClaimMapping `yaml:"claims"`
+}
+
+// OIDCClaimMapping maps ID token claim names onto user information fields.
+// Empty values fall back to the standard OIDC claim names.
+type OIDCClaimMapping struct {
+	Subject       string `yaml:"subject"`
+	Email         string `yaml:"email"`
+	EmailVerified string `yaml:"email_verified"`
+	Name          string `yaml:"name"`
+	GivenName     string `yaml:"given_name"`
+	FamilyName    string `yaml:"family_name"`
+	Picture       string `yaml:"picture"`
+	Locale        string `yaml:"locale"`
+	HostedDomain  string `yaml:"hosted_domain"`
+}
+
+// oidcProvidersFile is the on-disk layout of OIDC_PROVIDERS_FILE
+type oidcProvidersFile struct {
+	Providers []OIDCProviderConfig `yaml:"providers"`
+}
+
+// loadOIDCProviders reads and validates the OIDC providers file
+func loadOIDCProviders(path string) ([]OIDCProviderConfig, error) {
+	// #nosec G304 - path is supplied by the operator via OIDC_PROVIDERS_FILE
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC providers file: %w", err)
+	}
+
+	var file oidcProvidersFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse OIDC providers file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, p := range file.Providers {
+		if p.Name == "" {
+			return nil, fmt.Errorf("OIDC provider %d: name is required", i)
+		}
+		if p.IssuerURL == "" {
+			return nil, fmt.Errorf("OIDC provider %q: issuer_url is required", p.Name)
+		}
+		if p.ClientID == "" && len(p.Audiences) == 0 {
+			return nil, fmt.Errorf("OIDC provider %q: client_id or audiences is required", p.Name)
+		}
+		if seen[p.IssuerURL] {
+			return nil, fmt.Errorf("OIDC provider %q: duplicate issuer_url %s", p.Name, p.IssuerURL)
+		}
+		seen[p.IssuerURL] = true
+	}
+
+	return file.Providers, nil
+}
+
+// loadGroupMappings reads a static email-to-groups mapping file used by the
+// "static" group resolver
+func loadGroupMappings(path string) (map[string][]string, error) {
+	// #nosec G304 - path is supplied by the operator via GROUP_MAPPINGS_FILE
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group mappings file: %w", err)
 	}
-	return fallback
+
+	var file struct {
+		Members map[string][]string `yaml:"members"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse group mappings file: %w", err)
+	}
+
+	mappings := make(map[string][]string, len(file.Members))
+	for email, groups := range file.Members {
+		mappings[strings.ToLower(email)] = groups
+	}
+
+	return mappings, nil
+}
+
+// ImpersonationConfig maps API callers onto the service accounts the server
+// impersonates when calling GCP on their behalf
+type ImpersonationConfig struct {
+	// Users maps a caller email to a service account email
+	Users map[string]string `yaml:"users"`
+	// Groups maps group membership to a service account; the first matching
+	// entry wins
+	Groups []GroupServiceAccount `yaml:"groups"`
+	// DefaultServiceAccount is used when neither a user nor a group matches.
+	// When empty, unmapped callers are rejected.
+	DefaultServiceAccount string `yaml:"default_service_account"`
 }
 
-// getEnvAsBool gets an environment variable as boolean
-func getEnvAsBool(key string, fallback bool) bool {
-	if value := os.Getenv(key); value != "" {
+// GroupServiceAccount maps a group to a service account
+type GroupServiceAccount struct {
+	Group          string `yaml:"group"`
+	ServiceAccount string `yaml:"service_account"`
+}
+
+// loadImpersonationConfig reads the caller-to-service-account mapping file
+func loadImpersonationConfig(path string) (*ImpersonationConfig, error) {
+	// #nosec G304 - path is supplied by the operator via GCP_IMPERSONATION_FILE
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read impersonation file: %w", err)
+	}
+
+	var ic ImpersonationConfig
+	if err := yaml.Unmarshal(data, &ic); err != nil {
+		return nil, fmt.Errorf("failed to parse impersonation file: %w", err)
+	}
+
+	users := make(map[string]string, len(ic.Users))
+	for email, sa := range ic.Users {
+		users[strings.ToLower(email)] = sa
+	}
+	ic.Users = users
+
+	for i, g := range ic.Groups {
+		if g.Group == "" || g.ServiceAccount == "" {
+			return nil, fmt.Errorf("impersonation group entry %d: group and service_account are required", i)
+		}
+	}
+
+	return &ic, nil
+}
+
+// RateLimitConfig holds token-bucket limits per route group. A route group is
+// the first path segment under /api/v1, e.g. "buckets" for
+// /api/v1/buckets/:name/objects.
+type RateLimitConfig struct {
+	// Default applies to groups without their own entry
+	Default RouteRateLimits `yaml:"default"`
+	// Groups overrides the default for individual route groups
+	Groups map[string]RouteRateLimits `yaml:"groups"`
+}
+
+// RouteRateLimits are the limits for reads (GET, HEAD, OPTIONS) and writes
+// (POST, PUT, PATCH, DELETE). A nil limit inherits the default.
+type RouteRateLimits struct {
+	Read  *TokenBucket `yaml:"read"`
+	Write *TokenBucket `yaml:"write"`
+}
+
+// TokenBucket allows Burst requests at once, refilled at PerSecond
+type TokenBucket struct {
+	PerSecond float64 `yaml:"per_second"`
+	Burst     int     `yaml:"burst"`
+}
+
+// For returns the limit applied to reads or writes in group
+func (rc *RateLimitConfig) For(group string, write bool) TokenBucket {
+	limits, ok := rc.Groups[group]
+	if !ok {
+		limits = rc.Default
+	}
+
+	limit := limits.Read
+	fallback := rc.Default.Read
+	if write {
+		limit = limits.Write
+		fallback = rc.Default.Write
+	}
+	if limit == nil {
+		limit = fallback
+	}
+	return *limit
+}
+
+func (rc *RateLimitConfig) validate() error {
+	check := func(name string, limit *TokenBucket) error {
+		if limit != nil && (limit.PerSecond <= 0 || limit.Burst < 1) {
+			return fmt.Errorf("rate limit %s: per_second must be positive and burst at least 1", name)
+		}
+		return nil
+	}
+
+	if rc.Default.Read == nil || rc.Default.Write == nil {
+		return fmt.Errorf("rate limit default: read and write limits are required")
+	}
+	if err := check("default.read", rc.Default.Read); err != nil {
+		return err
+	}
+	if err := check("default.write", rc.Default.Write); err != nil {
+		return err
+	}
+	for group, limits := range rc.Groups {
+		if err := check(group+".read", limits.Read); err != nil {
+			return err
+		}
+		if err := check(group+".write", limits.Write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadRateLimits reads per-group rate limits, keeping the environment
+// defaults for anything the file leaves out
+func loadRateLimits(path string, limits *RateLimitConfig) error {
+	// #nosec G304 - path is supplied by the operator via RATE_LIMIT_FILE
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read rate limit file: %w", err)
+	}
+
+	var file RateLimitConfig
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse rate limit file: %w", err)
+	}
+
+	if file.Default.Read != nil {
+		limits.Default.Read = file.Default.Read
+	}
+	if file.Default.Write != nil {
+		limits.Default.Write = file.Default.Write
+	}
+	limits.Groups = file.Groups
+	return nil
+}
+
+// getEnv gets a setting with a fallback value
+func (src *source) getEnv(key, fallback string) string {
+	value, ok := src.lookup(key)
+	if !ok {
+		value = fallback
+	}
+	src.settings[key] = value
+	return value
+}
+
+// getEnvAsBool gets a setting as boolean
+func (src *source) getEnvAsBool(key string, fallback bool) bool {
+	result := fallback
+	if value, ok := src.lookup(key); ok {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
-			return boolVal
+			result = boolVal
+		} else {
+			src.errs = append(src.errs, fmt.Errorf("invalid %s %q: must be true or false", key, value))
 		}
 	}
-	return fallback
+	src.settings[key] = strconv.FormatBool(result)
+	return result
 }
 
-// getEnvAsInt gets an environment variable as integer
-func getEnvAsInt(key string, fallback int) int {
-	if value := os.Getenv(key); value != "" {
+// getEnvAsInt gets a setting as integer
+func (src *source) getEnvAsInt(key string, fallback int) int {
+	result := fallback
+	if value, ok := src.lookup(key); ok {
 		if intVal, err := strconv.Atoi(value); err == nil {
-			return intVal
+			result = intVal
+		} else {
+			src.errs = append(src.errs, fmt.Errorf("invalid %s %q: must be an integer", key, value))
 		}
 	}
-	return fallback
+	src.settings[key] = strconv.Itoa(result)
+	return result
+}
+
+// getEnvAsFloat gets a setting as a floating-point number
+func (src *source) getEnvAsFloat(key string, fallback float64) float64 {
+	result := fallback
+	if value, ok := src.lookup(key); ok {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			result = floatVal
+		} else {
+			src.errs = append(src.errs, fmt.Errorf("invalid %s %q: must be a number", key, value))
+		}
+	}
+	src.settings[key] = strconv.FormatFloat(result, 'g', -1, 64)
+	return result
+}
+
+// getEnvAsSlice gets a comma-separated setting as a string slice
+func (src *source) getEnvAsSlice(key string) []string {
+	value, _ := src.lookup(key)
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	src.settings[key] = strings.Join(result, ",")
+	return result
+}
+
+// getEnvAsIntMap gets a comma-separated list of name=integer pairs
+func (src *source) getEnvAsIntMap(key string) map[string]int {
+	result := map[string]int{}
+	var items []string
+	for _, item := range src.getEnvAsSlice(key) {
+		name, value, _ := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		intVal, err := strconv.Atoi(strings.TrimSpace(value))
+		if name == "" || err != nil {
+			src.errs = append(src.errs, fmt.Errorf("invalid %s entry %q: must be name=integer", key, item))
+			continue
+		}
+		result[name] = intVal
+		items = append(items, name+"="+strconv.Itoa(intVal))
+	}
+	sort.Strings(items)
+	src.settings[key] = strings.Join(items, ",")
+	return result
+}
+
+// GCPOperationTimeout returns how long a GCP service operation (e.g.
+// "CreateProject") may take; 0 means no limit
+func (c *Config) GCPOperationTimeout(operation string) time.Duration {
+	seconds, ok := c.GCPOperationTimeouts[operation]
+	if !ok {
+		seconds = c.GCPTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // IsProduction returns true if running in production environment
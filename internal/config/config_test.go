@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadFileLayersUnderEnvironment(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("PORT", "")
+
+	path := writeFile(t, "config.yaml", `
+port: 9090
+log_level: debug
+gcp_project_id: my-project
+rate_limit_read_per_second: 2.5
+login_allowed_domains: [example.com, example.org]
+`)
+	cfg, err := LoadFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, path, cfg.ConfigFile)
+	assert.Equal(t, "9090", cfg.Port)
+	assert.Equal(t, "warn", cfg.LogLevel, "environment variables take precedence")
+	assert.Equal(t, "my-project", cfg.GCPProjectID)
+	assert.Equal(t, 2.5, cfg.RateLimits.Default.Read.PerSecond)
+	assert.Equal(t, []string{"example.com", "example.org"}, cfg.LoginAllowedDomains)
+
+	// Without a file the defaults apply
+	cfg, err = LoadFile("")
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.False(t, cfg.AuditEnabled, "auditing is opt-in")
+	assert.False(t, cfg.IdempotencyEnabled, "idempotency keys are opt-in")
+	assert.False(t, cfg.RateLimitEnabled, "rate limiting is opt-in")
+}
+
+func TestLoadFileTOML(t *testing.T) {
+	cfg, err := LoadFile(writeFile(t, "config.toml", `
+port = 9090
+audit_enabled = true
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Port)
+	assert.True(t, cfg.AuditEnabled)
+}
+
+func TestLoadFileRejectsBadSettings(t *testing.T) {
+	_, err := LoadFile(writeFile(t, "config.yaml", "log_levle: debug\n"))
+	assert.ErrorContains(t, err, "unknown settings in config file: log_levle")
+
+	_, err = LoadFile(writeFile(t, "config.yaml", "jwt_expiration_hours: soon\n"))
+	assert.ErrorContains(t, err, `invalid JWT_EXPIRATION_HOURS "soon"`)
+
+	t.Setenv("AUDIT_COMPRESS", "maybe")
+	_, err = Load()
+	assert.ErrorContains(t, err, `invalid AUDIT_COMPRESS "maybe"`)
+
+	_, err = LoadFile(writeFile(t, "config.json", "{}"))
+	assert.ErrorContains(t, err, "unsupported config file")
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := LoadFile("")
+	require.NoError(t, err)
+	assert.ErrorContains(t, cfg.Validate(), "GCP_PROJECT_ID is required")
+
+	cfg.GCPProjectID = "my-project"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Port = "70000"
+	assert.ErrorContains(t, cfg.Validate(), "invalid PORT")
+
+	production, err := LoadFile(writeFile(t, "config.yaml", "environment: production\nenable_debug: true\n"))
+	require.NoError(t, err)
+	err = production.Validate()
+	require.Error(t, err)
+	for _, want := range []string{"GCP_PROJECT_ID is required", "JWT_SECRET must be changed", "ENABLE_DEBUG must be false"} {
+		assert.ErrorContains(t, err, want)
+	}
+
+	production.GCPProjectID = "my-project"
+	production.JWTSecret = strings.Repeat("s", 40)
+	production.EnableDebug = false
+	assert.NoError(t, production.Validate())
+}
+
+func TestGCPOperationTimeout(t *testing.T) {
+	t.Setenv("GCP_PROJECT_ID", "my-project")
+	t.Setenv("GCP_TIMEOUT_SECONDS", "20")
+	t.Setenv("GCP_OPERATION_TIMEOUTS", "CreateProject=120, ListObjects=0")
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	assert.Equal(t, 120*time.Second, cfg.GCPOperationTimeout("CreateProject"))
+	assert.Equal(t, time.Duration(0), cfg.GCPOperationTimeout("ListObjects"), "0 disables the limit")
+	assert.Equal(t, 20*time.Second, cfg.GCPOperationTimeout("GetBucket"))
+
+	cfg.GCPOperationTimeouts = map[string]int{"CreateProjct": 60, "DeleteBucket": -1}
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, `unknown operation "CreateProjct" in GCP_OPERATION_TIMEOUTS`)
+	assert.ErrorContains(t, err, "GCP_OPERATION_TIMEOUTS for DeleteBucket must not be negative")
+
+	t.Setenv("GCP_OPERATION_TIMEOUTS", "CreateProject")
+	_, err = Load()
+	assert.ErrorContains(t, err, `invalid GCP_OPERATION_TIMEOUTS entry "CreateProject"`)
+}
+
+func TestValidateRetrySettings(t *testing.T) {
+	t.Setenv("GCP_PROJECT_ID", "my-project")
+	t.Setenv("GCP_RETRY_BUDGETS", "CreateProject=6,GetLogs=0")
+	cfg, err := Load()
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, map[string]int{"CreateProject": 6, "GetLogs": 0}, cfg.GCPRetryBudgets)
+
+	cfg.GCPRetryBudgets["ListFolders"] = 1
+	cfg.GCPRetryMaxBackoffMS = 100
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, `unknown operation "ListFolders" in GCP_RETRY_BUDGETS`)
+	assert.ErrorContains(t, err, "GCP_RETRY_INITIAL_BACKOFF_MS must not be negative or exceed GCP_RETRY_MAX_BACKOFF_MS")
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "top-secret-value")
+	cfg, err := LoadFile("")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Print(&buf))
+	assert.Contains(t, buf.String(), "jwt_secret: REDACTED")
+	assert.Contains(t, buf.String(), `port: "8080"`)
+	assert.NotContains(t, buf.String(), "top-secret-value")
+
+	// The output can be read back as a config file
+	path := writeFile(t, "printed.yaml", buf.String())
+	t.Setenv("JWT_SECRET", "")
+	_, err = LoadFile(path)
+	assert.NoError(t, err)
+}
+
+func TestChanged(t *testing.T) {
+	before, err := LoadFile("")
+	require.NoError(t, err)
+
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("PORT", "9090")
+	after, err := LoadFile("")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"LOG_LEVEL", "PORT"}, before.Changed(after))
+	assert.True(t, ReloadableSettings["LOG_LEVEL"])
+	assert.False(t, ReloadableSettings["PORT"])
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultJWTSecret is the placeholder JWT secret used when JWT_SECRET is unset
+const defaultJWTSecret = "your-super-secret-jwt-key-change-in-production"
+
+// minProductionJWTSecretLength is the shortest JWT secret accepted in production
+const minProductionJWTSecretLength = 32
+
+// redacted replaces secret values in printed configuration
+const redacted = "REDACTED"
+
+// GCPOperations are the GCP service operations whose timeout can be set in
+// GCP_OPERATION_TIMEOUTS
+var GCPOperations = []string{
+	"CreateProject", "GetProject", "DeleteProject", "ListProjects",
+	"CreateBucket", "GetBucket", "DeleteBucket", "ListBuckets",
+	"ListObjects", "GetObject", "DeleteObject",
+}
+
+// CloudRunOperations are the Cloud Run service operations, whose retry
+// budget, like those of GCPOperations, can be set in GCP_RETRY_BUDGETS
+var CloudRunOperations = []string{
+	"ConfigureLogging", "GetLoggingConfig", "UpdateLoggingConfig",
+	"GetLogs", "GetServiceInfo",
+}
+
+// secretSettings are never printed
+var secretSettings = map[string]bool{
+	"JWT_SECRET":             true,
+	"GOOGLE_CLIENT_SECRET":   true,
+	"CREDENTIALS_PASSPHRASE": true,
+}
+
+// ReloadableSettings can be changed without a restart by editing the config
+// file and sending the server SIGHUP
+var ReloadableSettings = map[string]bool{
+	"LOG_LEVEL":                   true,
+	"POLICY_FILES":                true,
+	"RATE_LIMIT_FILE":             true,
+	"RATE_LIMIT_READ_PER_SECOND":  true,
+	"RATE_LIMIT_READ_BURST":       true,
+	"RATE_LIMIT_WRITE_PER_SECOND": true,
+	"RATE_LIMIT_WRITE_BURST":      true,
+}
+
+// source resolves settings from environment variables, falling back to the
+// config file, and records their effective values and any malformed ones
+type source struct {
+	file     map[string]string
+	settings map[string]string
+	errs     []error
+}
+
+// newSource creates a source backed by the config file at path, if any
+func newSource(path string) (*source, error) {
+	src := &source{file: map[string]string{}, settings: map[string]string{}}
+	if path == "" {
+		return src, nil
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src.file = file
+	return src, nil
+}
+
+// lookup returns a setting from the environment or, failing that, the config
+// file. Empty environment variables count as unset.
+func (src *source) lookup(key string) (string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	value, ok := src.file[key]
+	return value, ok
+}
+
+// err reports malformed values and config file keys that are not settings
+func (src *source) err() error {
+	errs := src.errs
+	var unknown []string
+	for key := range src.file {
+		if _, ok := src.settings[key]; !ok {
+			unknown = append(unknown, strings.ToLower(key))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		errs = append(errs, fmt.Errorf("unknown settings in config file: %s", strings.Join(unknown, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// readConfigFile reads a flat YAML or TOML file (by extension) whose keys are
+// the lowercase environment variable names, e.g. log_level: debug
+func readConfigFile(path string) (map[string]string, error) {
+	// #nosec G304 - path is supplied by the operator via --config or CONFIG_FILE
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s: must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for key, value := range raw {
+		str, err := settingString(value)
+		if err != nil {
+			return nil, fmt.Errorf("config file setting %s: %w", key, err)
+		}
+		settings[strings.ToUpper(key)] = str
+	}
+	return settings, nil
+}
+
+// settingString formats a config file value the way it would be written in an
+// environment variable; lists become comma-separated
+func settingString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := settingString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T", value)
+}
+
+// Validate checks the configuration for use by the API server: a usable port,
+// a GCP project for features that need one, and no insecure defaults in
+// production
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("invalid PORT %q: must be between 1 and 65535", c.Port))
+	}
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("invalid LOG_LEVEL %q: must be \"debug\", \"info\", \"warn\" or \"error\"", c.LogLevel))
+	}
+	switch c.Environment {
+	case "development", "staging", "production":
+	default:
+		errs = append(errs, fmt.Errorf("invalid ENVIRONMENT %q: must be \"development\", \"staging\" or \"production\"", c.Environment))
+	}
+	if c.JWTExpirationHours <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_EXPIRATION_HOURS must be positive"))
+	}
+	if c.IdempotencyEnabled && (c.IdempotencyTTLHours <= 0 || c.IdempotencyLockTimeoutSeconds <= 0) {
+		errs = append(errs, fmt.Errorf("IDEMPOTENCY_TTL_HOURS and IDEMPOTENCY_LOCK_TIMEOUT_SECONDS must be positive"))
+	}
+	if c.AuditMaxBodyBytes < 0 {
+		errs = append(errs, fmt.Errorf("AUDIT_MAX_BODY_BYTES must not be negative"))
+	}
+	if c.GCPTimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("GCP_TIMEOUT_SECONDS must not be negative"))
+	}
+	errs = append(errs, validateOperations("GCP_OPERATION_TIMEOUTS", c.GCPOperationTimeouts, GCPOperations)...)
+	if c.GCPRetryMax < 0 {
+		errs = append(errs, fmt.Errorf("GCP_RETRY_MAX must not be negative"))
+	}
+	errs = append(errs, validateOperations("GCP_RETRY_BUDGETS", c.GCPRetryBudgets,
+		slices.Concat(GCPOperations, CloudRunOperations))...)
+	if c.GCPRetryInitialBackoffMS < 0 || c.GCPRetryMaxBackoffMS < c.GCPRetryInitialBackoffMS {
+		errs = append(errs, fmt.Errorf("GCP_RETRY_INITIAL_BACKOFF_MS must not be negative or exceed GCP_RETRY_MAX_BACKOFF_MS"))
+	}
+	if c.HealthCheckTimeoutSeconds <= 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_CHECK_TIMEOUT_SECONDS must be positive"))
+	}
+	if c.HealthCheckCacheSeconds < 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_CHECK_CACHE_SECONDS must not be negative"))
+	}
+	if c.SwaggerScheme != "http" && c.SwaggerScheme != "https" {
+		errs = append(errs, fmt.Errorf("invalid SWAGGER_SCHEME %q: must be \"http\" or \"https\"", c.SwaggerScheme))
+	}
+
+	// Bucket, label compliance and Cloud Run endpoints act in the configured
+	// project, and the server always registers them
+	if c.GCPProjectID == "" {
+		errs = append(errs, fmt.Errorf("GCP_PROJECT_ID is required"))
+	}
+
+	if c.IsProduction() {
+		if c.JWTSecret == defaultJWTSecret {
+			errs = append(errs, fmt.Errorf("JWT_SECRET must be changed from its default in production"))
+		} else if len(c.JWTSecret) < minProductionJWTSecretLength {
+			errs = append(errs, fmt.Errorf("JWT_SECRET must be at least %d characters in production", minProductionJWTSecretLength))
+		}
+		if c.EnableDebug {
+			errs = append(errs, fmt.Errorf("ENABLE_DEBUG must be false in production"))
+		}
+		if c.TracingEnabled && c.TracingInsecure && !isLocalEndpoint(c.TracingEndpoint) {
+			errs = append(errs, fmt.Errorf("OTEL_EXPORTER_OTLP_INSECURE must be false in production for remote collectors"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isLocalEndpoint reports whether an OTLP endpoint (host:port or URL) is on
+// the loopback interface, e.g. a sidecar collector
+func isLocalEndpoint(endpoint string) bool {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+// Settings returns the effective value of every setting by environment
+// variable name, with secrets redacted
+func (c *Config) Settings() map[string]string {
+	settings := make(map[string]string, len(c.settings))
+	for key, value := range c.settings {
+		if secretSettings[key] && value != "" {
+			value = redacted
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// Print writes the effective configuration with secrets redacted, in the
+// config file format (lowercase keys, YAML)
+func (c *Config) Print(w io.Writer) error {
+	settings := c.Settings()
+	out := make(map[string]string, len(settings))
+	for key, value := range settings {
+		out[strings.ToLower(key)] = value
+	}
+
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+	return enc.Encode(out)
+}
+
+// Changed returns the names of the settings whose values differ in other
+func (c *Config) Changed(other *Config) []string {
+	var changed []string
+	for key, value := range c.settings {
+		if other.settings[key] != value {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// validateOperations checks the per-operation values of setting
+func validateOperations(setting string, values map[string]int, operations []string) []error {
+	var errs []error
+	for operation, value := range values {
+		if !slices.Contains(operations, operation) {
+			errs = append(errs, fmt.Errorf("unknown operation %q in %s: must be one of %s",
+				operation, setting, strings.Join(operations, ", ")))
+		} else if value < 0 {
+			errs = append(errs, fmt.Errorf("%s for %s must not be negative", setting, operation))
+		}
+	}
+	return errs
+}
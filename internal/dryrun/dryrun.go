@@ -0,0 +1,114 @@
+// Package dryrun supports previewing mutating requests: a request with
+// ?dry_run=true is validated and planned, but nothing is changed.
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Param is the query parameter that requests a dry run
+const Param = "dry_run"
+
+// Check statuses
+const (
+	CheckPassed  = "passed"
+	CheckSkipped = "skipped"
+)
+
+// Requested reports whether r asks for a dry run. A value that is not a
+// boolean is an error rather than false, so that a mistyped dry run is
+// never executed for real.
+func Requested(r *http.Request) (bool, error) {
+	query := r.URL.Query()
+	if !query.Has(Param) {
+		return false, nil
+	}
+	value := query.Get(Param)
+	if value == "" {
+		return true, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", Param, value)
+	}
+	return dryRun, nil
+}
+
+// Diff compares two states of a resource, each marshalled to JSON, field by
+// field. Nested fields are named by their path (e.g. versioning.enabled); a
+// nil state has no fields, so creates and deletes list every field.
+func Diff(current, proposed interface{}) ([]models.FieldChange, error) {
+	before, err := flatten(current)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatten(proposed)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := []models.FieldChange{}
+	for _, field := range fields {
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changes = append(changes, models.FieldChange{
+				Field:    field,
+				Current:  before[field],
+				Proposed: after[field],
+			})
+		}
+	}
+	return changes, nil
+}
+
+// flatten maps the leaf fields of v's JSON form to their values
+func flatten(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource state: %w", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode resource state: %w", err)
+	}
+	flattenInto(fields, "", decoded)
+	return fields, nil
+}
+
+func flattenInto(fields map[string]interface{}, prefix string, v interface{}) {
+	object, ok := v.(map[string]interface{})
+	if !ok || len(object) == 0 {
+		if prefix != "" {
+			fields[prefix] = v
+		}
+		return
+	}
+	for key, value := range object {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		flattenInto(fields, key, value)
+	}
+}
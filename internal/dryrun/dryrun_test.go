@@ -0,0 +1,52 @@
+package dryrun
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+func TestRequested(t *testing.T) {
+	tests := map[string]bool{
+		"/api/v1/buckets":               false,
+		"/api/v1/buckets?dry_run":       true,
+		"/api/v1/buckets?dry_run=true":  true,
+		"/api/v1/buckets?dry_run=1":     true,
+		"/api/v1/buckets?dry_run=false": false,
+	}
+	for target, want := range tests {
+		dryRun, err := Requested(httptest.NewRequest("POST", target, nil))
+		require.NoError(t, err, target)
+		assert.Equal(t, want, dryRun, target)
+	}
+
+	_, err := Requested(httptest.NewRequest("POST", "/api/v1/buckets?dry_run=yes", nil))
+	assert.EqualError(t, err, `invalid dry_run "yes": must be true or false`)
+}
+
+func TestDiff(t *testing.T) {
+	current := &models.ProjectResponse{ProjectID: "my-project", State: "ACTIVE", Labels: map[string]string{"env": "dev"}}
+	deleted := *current
+	deleted.State = "DELETE_REQUESTED"
+
+	changes, err := Diff(current, &deleted)
+	require.NoError(t, err)
+	assert.Equal(t, []models.FieldChange{{Field: "state", Current: "ACTIVE", Proposed: "DELETE_REQUESTED"}}, changes)
+
+	changes, err = Diff(nil, &models.BucketRequest{Name: "my-bucket", Location: "US", Labels: map[string]string{"env": "dev"}})
+	require.NoError(t, err)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "labels.env", Proposed: "dev"},
+		{Field: "location", Proposed: "US"},
+		{Field: "name", Proposed: "my-bucket"},
+	}, changes)
+
+	var none *models.BucketResponse
+	changes, err = Diff(&models.BucketResponse{Name: "my-bucket"}, none)
+	require.NoError(t, err)
+	assert.Contains(t, changes, models.FieldChange{Field: "name", Current: "my-bucket"})
+}
@@ -0,0 +1,188 @@
+// Package gcperrors classifies errors from GCP APIs, and errors raised by the
+// server itself, into canonical codes (the gRPC codes Google APIs use) and
+// maps those codes onto HTTP statuses and machine-readable names.
+package gcperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error is an error with a known code, for failures the server detects
+// itself, e.g. an invalid argument rejected before calling GCP
+type Error struct {
+	Code codes.Code
+	Err  error
+}
+
+// New returns an error with the given code; format may wrap other errors
+// with %w
+func New(code codes.Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Error returns the message of the underlying error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Code classifies err. Codes set explicitly with New take precedence over
+// those of the GCP errors they wrap. Transport failures such as a reset
+// connection are Unavailable; other errors that are not recognized are
+// Unknown.
+func Code(err error) codes.Code {
+	var typed *Error
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.As(err, &typed):
+		return typed.Code
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, storage.ErrBucketNotExist), errors.Is(err, storage.ErrObjectNotExist):
+		return codes.NotFound
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return FromHTTPStatus(apiErr.Code)
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return grpcErr.GRPCStatus().Code()
+	}
+
+	if isTransportError(err) {
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
+
+// isTransportError reports whether err is a network failure that happened
+// before a response was received, e.g. a reset connection
+func isTransportError(err error) bool {
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr) ||
+		errors.As(err, &urlErr)
+}
+
+// Is reports whether err is classified as code
+func Is(err error, code codes.Code) bool {
+	return Code(err) == code
+}
+
+// FromHTTPStatus maps an HTTP status onto the code Google APIs use for it
+func FromHTTPStatus(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if httpStatus >= 500 {
+		return codes.Internal
+	}
+	if httpStatus < 400 {
+		return codes.OK
+	}
+	return codes.Unknown
+}
+
+// HTTPStatus returns the HTTP status a response failing with code should
+// have, following the mapping Google APIs use
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// names are the machine-readable names of the codes, as in google.rpc.Code
+var names = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+// Name returns the machine-readable name of code, e.g. NOT_FOUND
+func Name(code codes.Code) string {
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return names[codes.Unknown]
+}
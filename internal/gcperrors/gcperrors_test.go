@@ -0,0 +1,67 @@
+package gcperrors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{nil, codes.OK},
+		{fmt.Errorf("failed to get bucket: %w", storage.ErrBucketNotExist), codes.NotFound},
+		{storage.ErrObjectNotExist, codes.NotFound},
+		{&googleapi.Error{Code: http.StatusConflict}, codes.AlreadyExists},
+		{fmt.Errorf("wrapped: %w", &googleapi.Error{Code: http.StatusTooManyRequests}), codes.ResourceExhausted},
+		{&googleapi.Error{Code: http.StatusBadGateway}, codes.Internal},
+		{status.Error(codes.PermissionDenied, "denied"), codes.PermissionDenied},
+		{fmt.Errorf("list logs: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{New(codes.InvalidArgument, "invalid region: %w", &googleapi.Error{Code: http.StatusNotFound}), codes.InvalidArgument},
+		{&url.Error{Op: "Get", URL: "https://storage.googleapis.com", Err: syscall.ECONNRESET}, codes.Unavailable},
+		{fmt.Errorf("read object: %w", io.ErrUnexpectedEOF), codes.Unavailable},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, codes.Unavailable},
+		{fmt.Errorf("boom"), codes.Unknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Code(tt.err), "%v", tt.err)
+	}
+
+	assert.True(t, Is(&googleapi.Error{Code: http.StatusConflict}, codes.AlreadyExists))
+}
+
+func TestHTTPStatusAndName(t *testing.T) {
+	tests := []struct {
+		code   codes.Code
+		status int
+		name   string
+	}{
+		{codes.InvalidArgument, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{codes.FailedPrecondition, http.StatusBadRequest, "FAILED_PRECONDITION"},
+		{codes.Unauthenticated, http.StatusUnauthorized, "UNAUTHENTICATED"},
+		{codes.PermissionDenied, http.StatusForbidden, "PERMISSION_DENIED"},
+		{codes.NotFound, http.StatusNotFound, "NOT_FOUND"},
+		{codes.AlreadyExists, http.StatusConflict, "ALREADY_EXISTS"},
+		{codes.ResourceExhausted, http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+		{codes.Unavailable, http.StatusServiceUnavailable, "UNAVAILABLE"},
+		{codes.DeadlineExceeded, http.StatusGatewayTimeout, "DEADLINE_EXCEEDED"},
+		{codes.Unknown, http.StatusInternalServerError, "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, HTTPStatus(tt.code), tt.name)
+		assert.Equal(t, tt.name, Name(tt.code))
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/audit"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"google.golang.org/grpc/codes"
+)
+
+// ListAuditEntries handles audit log queries
+// @Summary Query the audit log
+// @Description Retrieve recorded mutating API calls, newest first
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param user query string false "User ID or email of the caller"
+// @Param resource_type query string false "Resource type (projects, folders, buckets)"
+// @Param resource query string false "Resource name, e.g. a bucket name"
+// @Param method query string false "HTTP method (POST, PUT, PATCH, DELETE)"
+// @Param outcome query string false "Outcome (success or failure)"
+// @Param request_id query string false "Request ID"
+// @Param since query string false "Earliest timestamp (RFC3339)"
+// @Param until query string false "Latest timestamp, exclusive (RFC3339)"
+// @Param limit query int false "Maximum number of entries (default: 100, max: 1000)"
+// @Success 200 {object} models.SuccessResponse{data=[]models.AuditEntry}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /audit [get]
+func (h *Handler) ListAuditEntries(c echo.Context) error {
+	if h.auditSink == nil {
+		return gcperrors.New(codes.Unavailable, "the audit log is disabled on this server (AUDIT_ENABLED=false)")
+	}
+
+	// Without viewer groups nobody may read the audit log
+	if !callerInGroup(c, h.auditViewerGroups) {
+		metrics.RecordAuthFailure(metrics.AuthReasonForbidden)
+		return gcperrors.New(codes.PermissionDenied, "reading the audit log requires membership of an audit viewer group")
+	}
+
+	filter := audit.Filter{
+		User:         c.QueryParam("user"),
+		ResourceType: c.QueryParam("resource_type"),
+		Resource:     c.QueryParam("resource"),
+		Method:       c.QueryParam("method"),
+		Outcome:      c.QueryParam("outcome"),
+		RequestID:    c.QueryParam("request_id"),
+	}
+
+	if filter.Outcome != "" && filter.Outcome != models.AuditOutcomeSuccess && filter.Outcome != models.AuditOutcomeFailure {
+		return gcperrors.New(codes.InvalidArgument, "outcome must be \"success\" or \"failure\"")
+	}
+
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v := c.QueryParam(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return gcperrors.New(codes.InvalidArgument, "invalid %s format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)", name)
+		}
+		*target = t
+	}
+
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > audit.MaxQueryLimit {
+			return gcperrors.New(codes.InvalidArgument, "limit must be a number between 1 and 1000")
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.auditSink.Query(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Audit entries retrieved successfully",
+		Data:    entries,
+	})
+}
@@ -4,7 +4,14 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+	"github.com/stuartshay/gcp-automation-api/internal/services"
+	"google.golang.org/grpc/codes"
 )
 
 // CreateBucket handles bucket creation requests
@@ -21,41 +28,67 @@ import (
 // @Produce json
 // @Security BearerAuth
 // @Param bucket body models.BucketRequest true "Bucket creation request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Param dry_run query bool false "Check and describe the change without making it"
 // @Success 201 {object} models.SuccessResponse{data=models.BucketResponse}
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Dry run"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /buckets [post]
 func (h *Handler) CreateBucket(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	var req models.BucketRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "invalid request format: %w", err)
 	}
 
 	// Validate the request
 	if err := h.validator.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Validation failed",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "%w", err)
+	}
+
+	decisions, err := h.applyPolicies(c, policy.ResourceBucket, &req)
+	if err != nil {
+		return err
 	}
 
-	bucket, err := h.gcpService.CreateBucket(&req)
+	// Enforce the label schema, adding its defaults
+	var labelWarnings []models.LabelViolation
+	req.Labels, labelWarnings, err = h.labelSchemas.Apply(labels.ResourceBucket, req.Labels)
+	if err != nil {
+		return err
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanCreateBucket(c.Request().Context(), &req)
+		return dryRunResponse(c, plan, decisions, labelWarnings, err)
+	}
+
+	bucket, err := gcpService.CreateBucket(c.Request().Context(), &req)
+	if err != nil && services.IsAlreadyExists(err) && idempotency.IsRetry(c) {
+		// An earlier attempt with this Idempotency-Key created it
+		bucket, err = gcpService.GetBucket(c.Request().Context(), req.Name)
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to create bucket",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, models.SuccessResponse{
-		Message: "Bucket created successfully",
-		Data:    bucket,
+		Message:         "Bucket created successfully",
+		Data:            bucket,
+		PolicyDecisions: decisions,
+		LabelWarnings:   labelWarnings,
 	})
 }
 
@@ -70,25 +103,23 @@ func (h *Handler) CreateBucket(c echo.Context) error {
 // @Success 200 {object} models.SuccessResponse{data=models.BucketResponse}
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /buckets/{name} [get]
 func (h *Handler) GetBucket(c echo.Context) error {
 	bucketName := c.Param("name")
 	if bucketName == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing bucket name",
-			Message: "Bucket name is required",
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "bucket name is required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
 	}
 
-	bucket, err := h.gcpService.GetBucket(bucketName)
+	bucket, err := gcpService.GetBucket(c.Request().Context(), bucketName)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Bucket not found",
-			Message: err.Error(),
-			Code:    http.StatusNotFound,
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
@@ -105,26 +136,35 @@ func (h *Handler) GetBucket(c echo.Context) error {
 // @Produce json
 // @Security BearerAuth
 // @Param name path string true "Bucket name"
-// @Success 200 {object} models.SuccessResponse
+// @Param dry_run query bool false "Check and describe the change without making it"
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Deleted, or for a dry run the change that would be made"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /buckets/{name} [delete]
 func (h *Handler) DeleteBucket(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	bucketName := c.Param("name")
 	if bucketName == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing bucket name",
-			Message: "Bucket name is required",
-			Code:    http.StatusBadRequest,
-		})
-	}
-
-	if err := h.gcpService.DeleteBucket(bucketName); err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to delete bucket",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return gcperrors.New(codes.InvalidArgument, "bucket name is required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanDeleteBucket(c.Request().Context(), bucketName)
+		return dryRunResponse(c, plan, nil, nil, err)
+	}
+
+	if err := gcpService.DeleteBucket(c.Request().Context(), bucketName); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
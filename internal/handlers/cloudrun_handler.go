@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
 
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 	"github.com/stuartshay/gcp-automation-api/internal/services"
 	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
@@ -39,40 +42,24 @@ func (h *CloudRunHandler) ConfigureLogging(c *gin.Context) {
 	var req models.CloudRunLoggingConfigRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: "Invalid request body: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid request body: %w", err))
 		return
 	}
 
 	// Validate required fields
 	if req.ServiceName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Service name is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "service name is required"))
 		return
 	}
 
 	if req.Region == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Region is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "region is required"))
 		return
 	}
 
 	response, err := h.cloudRunService.ConfigureLogging(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "configuration_failed",
-			Message: "Failed to configure logging: " + err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		renderError(c, fmt.Errorf("failed to configure logging: %w", err))
 		return
 	}
 
@@ -96,49 +83,29 @@ func (h *CloudRunHandler) GetLoggingConfig(c *gin.Context) {
 	region := c.Param("region")
 
 	if serviceName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Service name is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "service name is required"))
 		return
 	}
 
 	if region == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Region is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "region is required"))
 		return
 	}
 
 	// Validate service name and region
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid service name: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err))
 		return
 	}
 
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid region: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err))
 		return
 	}
 
 	response, err := h.cloudRunService.GetLoggingConfig(c.Request.Context(), serviceName, region)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "service_not_found",
-			Message: "Failed to get logging configuration: " + err.Error(),
-			Code:    http.StatusNotFound,
-		})
+		renderError(c, fmt.Errorf("failed to get logging configuration: %w", err))
 		return
 	}
 
@@ -165,58 +132,34 @@ func (h *CloudRunHandler) UpdateLoggingConfig(c *gin.Context) {
 
 	var req models.CloudRunLoggingConfigUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: "Invalid request body: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid request body: %w", err))
 		return
 	}
 
 	if serviceName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Service name is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "service name is required"))
 		return
 	}
 
 	if region == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Region is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "region is required"))
 		return
 	}
 
 	// Validate service name and region
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid service name: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err))
 		return
 	}
 
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid region: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err))
 		return
 	}
 
 	response, err := h.cloudRunService.UpdateLoggingConfig(c.Request.Context(), serviceName, region, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "update_failed",
-			Message: "Failed to update logging configuration: " + err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		renderError(c, fmt.Errorf("failed to update logging configuration: %w", err))
 		return
 	}
 
@@ -244,39 +187,23 @@ func (h *CloudRunHandler) GetLogs(c *gin.Context) {
 	region := c.Param("region")
 
 	if serviceName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Service name is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "service name is required"))
 		return
 	}
 
 	if region == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Region is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "region is required"))
 		return
 	}
 
 	// Validate service name and region
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid service name: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err))
 		return
 	}
 
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid region: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err))
 		return
 	}
 
@@ -292,11 +219,7 @@ func (h *CloudRunHandler) GetLogs(c *gin.Context) {
 	if startTimeStr := c.Query("startTime"); startTimeStr != "" {
 		startTime, err := time.Parse(time.RFC3339, startTimeStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_failed",
-				Message: "Invalid startTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)",
-				Code:    http.StatusBadRequest,
-			})
+			renderError(c, gcperrors.New(codes.InvalidArgument, "invalid startTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)"))
 			return
 		}
 		req.StartTime = startTime
@@ -306,11 +229,7 @@ func (h *CloudRunHandler) GetLogs(c *gin.Context) {
 	if endTimeStr := c.Query("endTime"); endTimeStr != "" {
 		endTime, err := time.Parse(time.RFC3339, endTimeStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_failed",
-				Message: "Invalid endTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)",
-				Code:    http.StatusBadRequest,
-			})
+			renderError(c, gcperrors.New(codes.InvalidArgument, "invalid endTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)"))
 			return
 		}
 		req.EndTime = endTime
@@ -320,19 +239,11 @@ func (h *CloudRunHandler) GetLogs(c *gin.Context) {
 	if pageSizeStr := c.Query("pageSize"); pageSizeStr != "" {
 		pageSize, err := strconv.Atoi(pageSizeStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_failed",
-				Message: "Invalid pageSize format. Must be a number",
-				Code:    http.StatusBadRequest,
-			})
+			renderError(c, gcperrors.New(codes.InvalidArgument, "invalid pageSize format. Must be a number"))
 			return
 		}
 		if pageSize <= 0 || pageSize > 1000 {
-			c.JSON(http.StatusBadRequest, models.ErrorResponse{
-				Error:   "validation_failed",
-				Message: "pageSize must be between 1 and 1000",
-				Code:    http.StatusBadRequest,
-			})
+			renderError(c, gcperrors.New(codes.InvalidArgument, "pageSize must be between 1 and 1000"))
 			return
 		}
 		req.PageSize = pageSize
@@ -340,11 +251,7 @@ func (h *CloudRunHandler) GetLogs(c *gin.Context) {
 
 	response, err := h.cloudRunService.GetLogs(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "logs_retrieval_failed",
-			Message: "Failed to retrieve logs: " + err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		renderError(c, fmt.Errorf("failed to retrieve logs: %w", err))
 		return
 	}
 
@@ -368,49 +275,29 @@ func (h *CloudRunHandler) GetServiceInfo(c *gin.Context) {
 	region := c.Param("region")
 
 	if serviceName == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Service name is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "service name is required"))
 		return
 	}
 
 	if region == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Region is required",
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "region is required"))
 		return
 	}
 
 	// Validate service name and region
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid service name: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err))
 		return
 	}
 
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_failed",
-			Message: "Invalid region: " + err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		renderError(c, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err))
 		return
 	}
 
 	response, err := h.cloudRunService.GetServiceInfo(c.Request.Context(), serviceName, region)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "service_not_found",
-			Message: "Failed to get service information: " + err.Error(),
-			Code:    http.StatusNotFound,
-		})
+		renderError(c, fmt.Errorf("failed to get service information: %w", err))
 		return
 	}
 
@@ -436,3 +323,9 @@ func (h *CloudRunHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		cloudRun.GET("/service/:serviceName/:region", h.GetServiceInfo)
 	}
 }
+
+// renderError renders err in the same format as ErrorHandler
+func renderError(c *gin.Context, err error) {
+	status, resp := errorResponse(err)
+	c.JSON(status, resp)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
+	"google.golang.org/grpc/codes"
+)
+
+// GetCloudRunLogs handles Cloud Run log retrieval requests
+// @Summary Get Cloud Run service logs
+// @Description Retrieve log entries for a Cloud Run service
+// @Tags cloudrun
+// @Produce json
+// @Security BearerAuth
+// @Param serviceName path string true "Cloud Run service name"
+// @Param region path string true "Cloud Run service region"
+// @Param startTime query string false "Start time for logs (RFC3339 format)"
+// @Param endTime query string false "End time for logs (RFC3339 format)"
+// @Param filter query string false "Additional log filter"
+// @Param pageSize query int false "Number of logs to return (default: 100, max: 1000)"
+// @Param pageToken query string false "Page token from a previous response's next_page_token"
+// @Success 200 {object} models.SuccessResponse{data=models.CloudRunLogsResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /cloudrun/logs/{serviceName}/{region} [get]
+func (h *Handler) GetCloudRunLogs(c echo.Context) error {
+	if h.cloudRunService == nil {
+		return gcperrors.New(codes.Unavailable, "Cloud Run endpoints are unavailable: the server could not create its Cloud Run clients")
+	}
+
+	req := &models.CloudRunLogsRequest{
+		ServiceName: c.Param("serviceName"),
+		Region:      c.Param("region"),
+		Filter:      c.QueryParam("filter"),
+		PageSize:    100,
+		PageToken:   c.QueryParam("pageToken"),
+	}
+
+	if err := gcp.ValidateCloudRunServiceName(req.ServiceName); err != nil {
+		return gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err)
+	}
+	if err := gcp.ValidateCloudRunRegion(req.Region); err != nil {
+		return gcperrors.New(codes.InvalidArgument, "invalid region: %w", err)
+	}
+
+	if v := c.QueryParam("startTime"); v != "" {
+		startTime, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return gcperrors.New(codes.InvalidArgument, "invalid startTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)")
+		}
+		req.StartTime = startTime
+	}
+	if v := c.QueryParam("endTime"); v != "" {
+		endTime, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return gcperrors.New(codes.InvalidArgument, "invalid endTime format. Use RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)")
+		}
+		req.EndTime = endTime
+	}
+	if v := c.QueryParam("pageSize"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize <= 0 || pageSize > 1000 {
+			return gcperrors.New(codes.InvalidArgument, "pageSize must be a number between 1 and 1000")
+		}
+		req.PageSize = pageSize
+	}
+
+	logs, err := h.cloudRunService.GetLogs(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Logs retrieved successfully",
+		Data:    logs,
+	})
+}
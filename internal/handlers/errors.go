@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+)
+
+// ErrorHandler renders errors returned by handlers and middleware. GCP
+// errors are reported with the HTTP status matching their canonical code,
+// e.g. 404 for a missing bucket, and that code's name (NOT_FOUND) in the
+// error field.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, resp := errorResponse(err)
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, resp)
+	}
+	if err != nil {
+		logging.FromContext(c.Request().Context()).Error("Failed to send error response", "error", err)
+	}
+}
+
+// errorResponse returns the status and body of the response reporting err
+func errorResponse(err error) (int, models.ErrorResponse) {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		name := gcperrors.Name(gcperrors.FromHTTPStatus(httpErr.Code))
+		if name == gcperrors.Name(codes.Unknown) {
+			// e.g. METHOD_NOT_ALLOWED, which has no canonical code
+			name = strings.ToUpper(strings.ReplaceAll(http.StatusText(httpErr.Code), " ", "_"))
+		}
+		return httpErr.Code, models.ErrorResponse{
+			Error:   name,
+			Message: fmt.Sprint(httpErr.Message),
+			Code:    httpErr.Code,
+		}
+	}
+
+	code := gcperrors.Code(err)
+	status := gcperrors.HTTPStatus(code)
+	resp := models.ErrorResponse{
+		Error:   gcperrors.Name(code),
+		Message: err.Error(),
+		Code:    status,
+	}
+	var denied *policy.DeniedError
+	if errors.As(err, &denied) {
+		resp.PolicyDecisions = denied.Decisions
+	}
+	var violations *labels.ViolationError
+	if errors.As(err, &violations) {
+		resp.LabelViolations = violations.Violations
+	}
+	return status, resp
+}
@@ -4,7 +4,11 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+	"google.golang.org/grpc/codes"
 )
 
 // CreateFolder handles folder creation requests
@@ -21,41 +25,55 @@ import (
 // @Produce json
 // @Security BearerAuth
 // @Param folder body models.FolderRequest true "Folder creation request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Param dry_run query bool false "Check and describe the change without making it"
 // @Success 201 {object} models.SuccessResponse{data=models.FolderResponse}
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Dry run"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /folders [post]
 func (h *Handler) CreateFolder(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	var req models.FolderRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "invalid request format: %w", err)
 	}
 
 	// Validate the request
 	if err := h.validator.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Validation failed",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "%w", err)
+	}
+
+	decisions, err := h.applyPolicies(c, policy.ResourceFolder, &req)
+	if err != nil {
+		return err
 	}
 
-	folder, err := h.gcpService.CreateFolder(&req)
+	gcpService, err := h.gcpServiceFor(c)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to create folder",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanCreateFolder(c.Request().Context(), &req)
+		return dryRunResponse(c, plan, decisions, nil, err)
+	}
+
+	folder, err := gcpService.CreateFolder(c.Request().Context(), &req)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, models.SuccessResponse{
-		Message: "Folder created successfully",
-		Data:    folder,
+		Message:         "Folder created successfully",
+		Data:            folder,
+		PolicyDecisions: decisions,
 	})
 }
 
@@ -70,25 +88,23 @@ func (h *Handler) CreateFolder(c echo.Context) error {
 // @Success 200 {object} models.SuccessResponse{data=models.FolderResponse}
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /folders/{id} [get]
 func (h *Handler) GetFolder(c echo.Context) error {
 	folderID := c.Param("id")
 	if folderID == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing folder ID",
-			Message: "Folder ID is required",
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "folder ID is required")
 	}
 
-	folder, err := h.gcpService.GetFolder(folderID)
+	gcpService, err := h.gcpServiceFor(c)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Folder not found",
-			Message: err.Error(),
-			Code:    http.StatusNotFound,
-		})
+		return callerIdentityError(err)
+	}
+
+	folder, err := gcpService.GetFolder(c.Request().Context(), folderID)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
@@ -105,26 +121,35 @@ func (h *Handler) GetFolder(c echo.Context) error {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Folder ID"
-// @Success 200 {object} models.SuccessResponse
+// @Param dry_run query bool false "Check and describe the change without making it"
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Deleted, or for a dry run the change that would be made"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /folders/{id} [delete]
 func (h *Handler) DeleteFolder(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	folderID := c.Param("id")
 	if folderID == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing folder ID",
-			Message: "Folder ID is required",
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "folder ID is required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanDeleteFolder(c.Request().Context(), folderID)
+		return dryRunResponse(c, plan, nil, nil, err)
 	}
 
-	if err := h.gcpService.DeleteFolder(folderID); err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to delete folder",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+	if err := gcpService.DeleteFolder(c.Request().Context(), folderID); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
@@ -1,15 +1,38 @@
 package handlers
 
 import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/audit"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
 	"github.com/stuartshay/gcp-automation-api/internal/services"
 	"github.com/stuartshay/gcp-automation-api/internal/validators"
 )
 
 // Handler contains all HTTP handlers
 type Handler struct {
-	gcpService  services.GCPServiceInterface
-	authService *services.AuthService
-	validator   *validators.CustomValidator
+	gcpService      services.GCPServiceInterface
+	serviceResolver services.GCPServiceResolver
+	cloudRunService services.CloudRunServiceInterface
+	auditSink       audit.Sink
+	policies        *policy.Engine
+	labelSchemas    *labels.Registry
+	authService     *services.AuthService
+	validator       *validators.CustomValidator
+
+	auditViewerGroups      []string
+	labelSchemaAdminGroups []string
 }
 
 // NewHandler creates a new handler instance
@@ -20,3 +43,128 @@ func NewHandler(gcpService services.GCPServiceInterface, authService *services.A
 		validator:   validators.NewValidator(),
 	}
 }
+
+// WithGCPServiceResolver makes handlers perform GCP operations with the
+// service returned for each caller instead of the shared service
+func (h *Handler) WithGCPServiceResolver(resolver services.GCPServiceResolver) *Handler {
+	h.serviceResolver = resolver
+	return h
+}
+
+// WithCloudRunService enables the Cloud Run endpoints
+func (h *Handler) WithCloudRunService(cloudRunService services.CloudRunServiceInterface) *Handler {
+	h.cloudRunService = cloudRunService
+	return h
+}
+
+// WithAuditLog enables the audit query endpoint. Callers must belong to one
+// of viewerGroups to read the audit log, so without viewer groups nobody
+// can.
+func (h *Handler) WithAuditLog(sink audit.Sink, viewerGroups []string) *Handler {
+	h.auditSink = sink
+	h.auditViewerGroups = viewerGroups
+	return h
+}
+
+// WithPolicies makes create requests subject to the guardrail policies of
+// engine
+func (h *Handler) WithPolicies(engine *policy.Engine) *Handler {
+	h.policies = engine
+	return h
+}
+
+// WithLabelSchemas enables the label schema endpoints and enforces the
+// schemas of registry on create requests. Callers must belong to one of
+// adminGroups to change schemas, so without admin groups schemas can only
+// be read.
+func (h *Handler) WithLabelSchemas(registry *labels.Registry, adminGroups []string) *Handler {
+	h.labelSchemas = registry
+	h.labelSchemaAdminGroups = adminGroups
+	return h
+}
+
+// gcpServiceFor returns the GCP service that acts on behalf of the caller
+func (h *Handler) gcpServiceFor(c echo.Context) (services.GCPServiceInterface, error) {
+	if h.serviceResolver == nil {
+		return h.gcpService, nil
+	}
+
+	_, email, _ := authmiddleware.GetUserFromContext(c)
+	return h.serviceResolver.ServiceFor(c.Request().Context(), services.CallerIdentity{
+		Email:         email,
+		EmailVerified: authmiddleware.GetUserEmailVerifiedFromContext(c),
+		Groups:        authmiddleware.GetUserGroupsFromContext(c),
+	})
+}
+
+// callerInGroup reports whether the caller belongs to any of groups. Group
+// emails are compared ignoring case, as in the login policy and
+// impersonation mappings.
+func callerInGroup(c echo.Context, groups []string) bool {
+	return slices.ContainsFunc(authmiddleware.GetUserGroupsFromContext(c), func(group string) bool {
+		return slices.ContainsFunc(groups, func(allowed string) bool {
+			return strings.EqualFold(allowed, group)
+		})
+	})
+}
+
+// callerIdentityError renders a failure to obtain GCP credentials for the caller
+func callerIdentityError(err error) error {
+	metrics.RecordAuthFailure(metrics.AuthReasonNoGCPIdentity)
+	return gcperrors.New(codes.PermissionDenied, "no GCP identity for caller: %w", err)
+}
+
+// invalidDryRunError renders an invalid dry_run parameter
+func invalidDryRunError(err error) error {
+	return gcperrors.New(codes.InvalidArgument, "invalid dry_run parameter: %w", err)
+}
+
+// applyPolicies evaluates the guardrail policies for resource against req,
+// which mutate policies may change; a changed request is validated again.
+// It returns the decisions of the matching policies, and a
+// *policy.DeniedError if the request is denied.
+func (h *Handler) applyPolicies(c echo.Context, resource string, req interface{}) ([]models.PolicyDecision, error) {
+	userID, email, name := authmiddleware.GetUserFromContext(c)
+	decisions, err := h.policies.Evaluate(resource, req, policy.Caller{
+		UserID: userID,
+		Email:  email,
+		Name:   name,
+		Groups: authmiddleware.GetUserGroupsFromContext(c),
+	})
+
+	logger := logging.FromContext(c.Request().Context())
+	mutated := false
+	for _, decision := range decisions {
+		switch decision.Effect {
+		case policy.EffectMutate:
+			mutated = true
+			logger.Info("Policy changed request", "policy", decision.Policy, "changes", decision.Changes)
+		default:
+			logger.Warn("Policy matched request", "policy", decision.Policy, "effect", decision.Effect, "message", decision.Message)
+		}
+	}
+	if err != nil {
+		return decisions, err
+	}
+
+	if mutated {
+		if err := h.validator.Validate(req); err != nil {
+			return decisions, gcperrors.New(codes.InvalidArgument, "request changed by policy is invalid: %w", err)
+		}
+	}
+	return decisions, nil
+}
+
+// dryRunResponse renders the plan of a dry run, or the error that the
+// request would have failed with
+func dryRunResponse(c echo.Context, plan *models.DryRunResponse, decisions []models.PolicyDecision, labelWarnings []models.LabelViolation, err error) error {
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message:         "Dry run: no changes were made",
+		Data:            plan,
+		PolicyDecisions: decisions,
+		LabelWarnings:   labelWarnings,
+	})
+}
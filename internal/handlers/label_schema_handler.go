@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// ListLabelSchemas handles label schema listing requests
+// @Summary List label schemas
+// @Description List the label schema of each resource type that has one
+// @Tags Label Schemas
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.SuccessResponse{data=[]models.LabelSchema}
+// @Failure 503 {object} models.ErrorResponse
+// @Router /label-schemas [get]
+func (h *Handler) ListLabelSchemas(c echo.Context) error {
+	if h.labelSchemas == nil {
+		return labelSchemasDisabledError()
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Label schemas retrieved successfully",
+		Data:    h.labelSchemas.List(),
+	})
+}
+
+// GetLabelSchema handles label schema retrieval requests
+// @Summary Get a label schema
+// @Description Retrieve the label schema of a resource type
+// @Tags Label Schemas
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type path string true "Resource type (bucket or project)"
+// @Success 200 {object} models.SuccessResponse{data=models.LabelSchema}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /label-schemas/{resource_type} [get]
+func (h *Handler) GetLabelSchema(c echo.Context) error {
+	if h.labelSchemas == nil {
+		return labelSchemasDisabledError()
+	}
+
+	schema, err := h.labelSchemas.Get(c.Param("resource_type"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Label schema retrieved successfully",
+		Data:    schema,
+	})
+}
+
+// PutLabelSchema handles label schema creation and replacement requests
+// @Summary Create or replace a label schema
+// @Description Set the rules for the labels of a resource type: required keys, allowed values, patterns, defaults and deprecated keys. The schema applies to resources created afterwards.
+// @Tags Label Schemas
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type path string true "Resource type (bucket or project)"
+// @Param schema body models.LabelSchemaRequest true "Label schema"
+// @Success 200 {object} models.SuccessResponse{data=models.LabelSchema}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /label-schemas/{resource_type} [put]
+func (h *Handler) PutLabelSchema(c echo.Context) error {
+	if h.labelSchemas == nil {
+		return labelSchemasDisabledError()
+	}
+	if !h.isLabelSchemaAdmin(c) {
+		return labelSchemaAdminError()
+	}
+
+	var req models.LabelSchemaRequest
+	if err := c.Bind(&req); err != nil {
+		return gcperrors.New(codes.InvalidArgument, "invalid request format: %w", err)
+	}
+
+	// Validate the request
+	if err := h.validator.Validate(&req); err != nil {
+		return gcperrors.New(codes.InvalidArgument, "%w", err)
+	}
+
+	_, email, _ := authmiddleware.GetUserFromContext(c)
+	schema, err := h.labelSchemas.Put(models.LabelSchema{
+		ResourceType: c.Param("resource_type"),
+		Keys:         req.Keys,
+		UpdatedAt:    time.Now().UTC(),
+		UpdatedBy:    email,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Label schema saved successfully",
+		Data:    schema,
+	})
+}
+
+// DeleteLabelSchema handles label schema deletion requests
+// @Summary Delete a label schema
+// @Description Delete the label schema of a resource type, so that its labels are no longer checked
+// @Tags Label Schemas
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type path string true "Resource type (bucket or project)"
+// @Success 200 {object} models.SuccessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /label-schemas/{resource_type} [delete]
+func (h *Handler) DeleteLabelSchema(c echo.Context) error {
+	if h.labelSchemas == nil {
+		return labelSchemasDisabledError()
+	}
+	if !h.isLabelSchemaAdmin(c) {
+		return labelSchemaAdminError()
+	}
+
+	if err := h.labelSchemas.Delete(c.Param("resource_type")); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Label schema deleted successfully",
+	})
+}
+
+// GetLabelCompliance handles label compliance report requests
+// @Summary Report resources whose labels break their schema
+// @Description List the existing buckets (in the server's project) or projects whose labels do not follow the label schema of their resource type, including those still using deprecated keys
+// @Tags Label Schemas
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type path string true "Resource type (bucket or project)"
+// @Success 200 {object} models.SuccessResponse{data=models.LabelComplianceReport}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /label-schemas/{resource_type}/compliance [get]
+func (h *Handler) GetLabelCompliance(c echo.Context) error {
+	if h.labelSchemas == nil {
+		return labelSchemasDisabledError()
+	}
+
+	resourceType := c.Param("resource_type")
+	if _, err := h.labelSchemas.Get(resourceType); err != nil {
+		return err
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	// The labels of each resource, by name
+	resources := make(map[string]map[string]string)
+	var names []string
+	switch resourceType {
+	case labels.ResourceBucket:
+		buckets, err := gcpService.ListBuckets(c.Request().Context())
+		if err != nil {
+			return err
+		}
+		for _, bucket := range buckets {
+			names = append(names, bucket.Name)
+			resources[bucket.Name] = bucket.Labels
+		}
+	case labels.ResourceProject:
+		projects, err := gcpService.ListProjects(c.Request().Context())
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			names = append(names, project.ProjectID)
+			resources[project.ProjectID] = project.Labels
+		}
+	}
+
+	report := models.LabelComplianceReport{
+		ResourceType: resourceType,
+		Checked:      len(names),
+		NonCompliant: []models.NonCompliantResource{},
+	}
+	for _, name := range names {
+		if violations := h.labelSchemas.Check(resourceType, resources[name]); len(violations) > 0 {
+			report.NonCompliant = append(report.NonCompliant, models.NonCompliantResource{
+				Name:       name,
+				Labels:     resources[name],
+				Violations: violations,
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Label compliance report generated successfully",
+		Data:    report,
+	})
+}
+
+// isLabelSchemaAdmin reports whether the caller may change label schemas.
+// Without admin groups nobody may.
+func (h *Handler) isLabelSchemaAdmin(c echo.Context) bool {
+	return callerInGroup(c, h.labelSchemaAdminGroups)
+}
+
+// labelSchemaAdminError renders a change to a label schema by a caller who
+// is not a label schema admin
+func labelSchemaAdminError() error {
+	metrics.RecordAuthFailure(metrics.AuthReasonForbidden)
+	return gcperrors.New(codes.PermissionDenied, "changing label schemas requires membership of a label schema admin group")
+}
+
+// labelSchemasDisabledError renders a request to the label schema endpoints
+// of a server without a label schema registry
+func labelSchemasDisabledError() error {
+	return gcperrors.New(codes.Unavailable, "the label schema registry is not enabled on this server")
+}
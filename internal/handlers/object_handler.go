@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"google.golang.org/grpc/codes"
+)
+
+// ListObjects handles object listing requests
+// @Summary List objects in a Cloud Storage bucket
+// @Description List the objects in a Google Cloud Storage bucket, optionally filtered by name prefix
+// @Tags Objects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Bucket name"
+// @Param prefix query string false "Object name prefix"
+// @Param page_size query int false "Number of objects to return (default: 100, max: 1000)"
+// @Param page_token query string false "Page token from a previous response's next_page_token"
+// @Success 200 {object} models.SuccessResponse{data=models.ObjectListResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /buckets/{name}/objects [get]
+func (h *Handler) ListObjects(c echo.Context) error {
+	bucketName := c.Param("name")
+	if bucketName == "" {
+		return gcperrors.New(codes.InvalidArgument, "bucket name is required")
+	}
+
+	req := &models.ObjectListRequest{
+		Bucket:    bucketName,
+		Prefix:    c.QueryParam("prefix"),
+		PageSize:  100,
+		PageToken: c.QueryParam("page_token"),
+	}
+	if v := c.QueryParam("page_size"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil || pageSize <= 0 || pageSize > 1000 {
+			return gcperrors.New(codes.InvalidArgument, "page_size must be a number between 1 and 1000")
+		}
+		req.PageSize = pageSize
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	objects, err := gcpService.ListObjects(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Objects retrieved successfully",
+		Data:    objects,
+	})
+}
+
+// GetObject handles object metadata retrieval requests
+// @Summary Get a Cloud Storage object
+// @Description Retrieve the metadata of an object in a Google Cloud Storage bucket
+// @Tags Objects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Bucket name"
+// @Param object path string true "Object name (may contain slashes)"
+// @Success 200 {object} models.SuccessResponse{data=models.ObjectResponse}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /buckets/{name}/objects/{object} [get]
+func (h *Handler) GetObject(c echo.Context) error {
+	bucketName, objectName := objectParams(c)
+	if bucketName == "" || objectName == "" {
+		return gcperrors.New(codes.InvalidArgument, "bucket and object names are required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	object, err := gcpService.GetObject(c.Request().Context(), bucketName, objectName)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Object retrieved successfully",
+		Data:    object,
+	})
+}
+
+// DeleteObject handles object deletion requests
+// @Summary Delete a Cloud Storage object
+// @Description Delete an object from a Google Cloud Storage bucket
+// @Tags Objects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Bucket name"
+// @Param object path string true "Object name (may contain slashes)"
+// @Param dry_run query bool false "Check and describe the change without making it"
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Deleted, or for a dry run the change that would be made"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /buckets/{name}/objects/{object} [delete]
+func (h *Handler) DeleteObject(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
+	bucketName, objectName := objectParams(c)
+	if bucketName == "" || objectName == "" {
+		return gcperrors.New(codes.InvalidArgument, "bucket and object names are required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanDeleteObject(c.Request().Context(), bucketName, objectName)
+		return dryRunResponse(c, plan, nil, nil, err)
+	}
+
+	if err := gcpService.DeleteObject(c.Request().Context(), bucketName, objectName); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.SuccessResponse{
+		Message: "Object deleted successfully",
+	})
+}
+
+// objectParams reads the bucket and object names from a /buckets/:name/objects/* route
+func objectParams(c echo.Context) (string, string) {
+	return c.Param("name"), strings.TrimPrefix(c.Param("*"), "/")
+}
@@ -4,7 +4,14 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+	"github.com/stuartshay/gcp-automation-api/internal/services"
+	"google.golang.org/grpc/codes"
 )
 
 // CreateProject handles project creation requests
@@ -21,41 +28,67 @@ import (
 // @Produce json
 // @Security BearerAuth
 // @Param project body models.ProjectRequest true "Project creation request"
+// @Param Idempotency-Key header string false "Key that makes retries of this request safe"
+// @Param dry_run query bool false "Check and describe the change without making it"
 // @Success 201 {object} models.SuccessResponse{data=models.ProjectResponse}
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Dry run"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /projects [post]
 func (h *Handler) CreateProject(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	var req models.ProjectRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Invalid request format",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "invalid request format: %w", err)
 	}
 
 	// Validate the request
 	if err := h.validator.Validate(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Validation failed",
-			Message: err.Error(),
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "%w", err)
+	}
+
+	decisions, err := h.applyPolicies(c, policy.ResourceProject, &req)
+	if err != nil {
+		return err
 	}
 
-	project, err := h.gcpService.CreateProject(&req)
+	// Enforce the label schema, adding its defaults
+	var labelWarnings []models.LabelViolation
+	req.Labels, labelWarnings, err = h.labelSchemas.Apply(labels.ResourceProject, req.Labels)
+	if err != nil {
+		return err
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanCreateProject(c.Request().Context(), &req)
+		return dryRunResponse(c, plan, decisions, labelWarnings, err)
+	}
+
+	project, err := gcpService.CreateProject(c.Request().Context(), &req)
+	if err != nil && services.IsAlreadyExists(err) && idempotency.IsRetry(c) {
+		// An earlier attempt with this Idempotency-Key created it
+		project, err = gcpService.GetProject(c.Request().Context(), req.ProjectID)
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to create project",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusCreated, models.SuccessResponse{
-		Message: "Project created successfully",
-		Data:    project,
+		Message:         "Project created successfully",
+		Data:            project,
+		PolicyDecisions: decisions,
+		LabelWarnings:   labelWarnings,
 	})
 }
 
@@ -70,25 +103,23 @@ func (h *Handler) CreateProject(c echo.Context) error {
 // @Success 200 {object} models.SuccessResponse{data=models.ProjectResponse}
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /projects/{id} [get]
 func (h *Handler) GetProject(c echo.Context) error {
 	projectID := c.Param("id")
 	if projectID == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing project ID",
-			Message: "Project ID is required",
-			Code:    http.StatusBadRequest,
-		})
+		return gcperrors.New(codes.InvalidArgument, "project ID is required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
 	}
 
-	project, err := h.gcpService.GetProject(projectID)
+	project, err := gcpService.GetProject(c.Request().Context(), projectID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, models.ErrorResponse{
-			Error:   "Project not found",
-			Message: err.Error(),
-			Code:    http.StatusNotFound,
-		})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
@@ -105,26 +136,35 @@ func (h *Handler) GetProject(c echo.Context) error {
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Project ID"
-// @Success 200 {object} models.SuccessResponse
+// @Param dry_run query bool false "Check and describe the change without making it"
+// @Success 200 {object} models.SuccessResponse{data=models.DryRunResponse} "Deleted, or for a dry run the change that would be made"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /projects/{id} [delete]
 func (h *Handler) DeleteProject(c echo.Context) error {
+	dryRun, err := dryrun.Requested(c.Request())
+	if err != nil {
+		return invalidDryRunError(err)
+	}
+
 	projectID := c.Param("id")
 	if projectID == "" {
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "Missing project ID",
-			Message: "Project ID is required",
-			Code:    http.StatusBadRequest,
-		})
-	}
-
-	if err := h.gcpService.DeleteProject(projectID); err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to delete project",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return gcperrors.New(codes.InvalidArgument, "project ID is required")
+	}
+
+	gcpService, err := h.gcpServiceFor(c)
+	if err != nil {
+		return callerIdentityError(err)
+	}
+
+	if dryRun {
+		plan, err := gcpService.PlanDeleteProject(c.Request().Context(), projectID)
+		return dryRunResponse(c, plan, nil, nil, err)
+	}
+
+	if err := gcpService.DeleteProject(c.Request().Context(), projectID); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, models.SuccessResponse{
@@ -0,0 +1,182 @@
+// Package health reports whether the server is alive and ready to serve
+// requests. A probe runs pluggable checks of the server's dependencies, each
+// with a timeout, and caches their results so that frequent probing does not
+// put load on GCP.
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Check and probe statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Checker checks a dependency, returning an error if it is unusable
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to a Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Pinger is implemented by stores that can verify their connection
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Options configures a probe
+type Options struct {
+	// Timeout bounds each check
+	Timeout time.Duration
+	// CacheTTL is how long a check result is reused (0 runs checks on every
+	// probe)
+	CacheTTL time.Duration
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	LatencyMS int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Cached is set when the result was reused from an earlier probe
+	Cached bool `json:"cached"`
+}
+
+// Report is the combined outcome of a probe's checks
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+// Probe runs a set of checks; it is ready when all of them pass
+type Probe struct {
+	opts   Options
+	checks []*check
+}
+
+// check is a named checker with its last result
+type check struct {
+	name    string
+	checker Checker
+	mu      sync.Mutex
+	result  Result
+	expires time.Time
+}
+
+// NewProbe creates a probe without checks
+func NewProbe(opts Options) *Probe {
+	return &Probe{opts: opts}
+}
+
+// Add registers a check. Checks must be added before the probe is served.
+func (p *Probe) Add(name string, checker Checker) {
+	p.checks = append(p.checks, &check{name: name, checker: checker})
+}
+
+// Run runs all checks concurrently, reusing results younger than the cache TTL
+func (p *Probe) Run(ctx context.Context) Report {
+	results := make([]Result, len(p.checks))
+	var wg sync.WaitGroup
+	for i, c := range p.checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, p.opts)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFailed
+		}
+	}
+	return report
+}
+
+// run returns the cached result of the check or runs it. Concurrent probes
+// wait for a single run rather than checking the dependency several times.
+func (c *check) run(ctx context.Context, opts Options) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expires) {
+		cached := c.result
+		cached.Cached = true
+		return cached
+	}
+
+	// A probe whose client hangs up still completes the check, so that the
+	// cached result reflects the dependency rather than the client
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- c.checker.Check(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", opts.Timeout)
+	}
+
+	result := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+
+	// Log transitions rather than every failed probe
+	switch {
+	case result.Status == StatusFailed && c.result.Status != StatusFailed:
+		slog.Warn("Health check failed", "check", c.name, "error", err)
+	case result.Status == StatusOK && c.result.Status == StatusFailed:
+		slog.Info("Health check recovered", "check", c.name)
+	}
+
+	c.result = result
+	c.expires = start.Add(opts.CacheTTL)
+	return result
+}
+
+// Handler serves the probe: 200 when all checks pass and 503 otherwise. With
+// the verbose query parameter the response lists every check's status and
+// latency.
+func (p *Probe) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := p.Run(c.Request().Context())
+
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		if !c.QueryParams().Has("verbose") {
+			report.Checks = nil
+		}
+		return c.JSON(status, report)
+	}
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probe serves p at /readyz and returns the status code and decoded report
+func probe(t *testing.T, p *Probe, target string) (int, Report) {
+	e := echo.New()
+	e.GET("/readyz", p.Handler())
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestProbeReportsFailedChecks(t *testing.T) {
+	p := NewProbe(Options{Timeout: time.Second})
+	p.Add("storage", CheckerFunc(func(context.Context) error { return nil }))
+	p.Add("audit", CheckerFunc(func(context.Context) error { return errors.New("database is locked") }))
+
+	code, report := probe(t, p, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Empty(t, report.Checks, "checks are only listed in verbose mode")
+
+	_, report = probe(t, p, "/readyz?verbose")
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "storage", report.Checks[0].Name)
+	assert.Equal(t, StatusOK, report.Checks[0].Status)
+	assert.Equal(t, "audit", report.Checks[1].Name)
+	assert.Equal(t, StatusFailed, report.Checks[1].Status)
+	assert.Equal(t, "database is locked", report.Checks[1].Error)
+}
+
+func TestProbeWithoutChecks(t *testing.T) {
+	code, report := probe(t, NewProbe(Options{Timeout: time.Second}), "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+}
+
+func TestProbeTimesOutChecks(t *testing.T) {
+	p := NewProbe(Options{Timeout: 20 * time.Millisecond})
+	p.Add("resourcemanager", CheckerFunc(func(context.Context) error {
+		// Ignores its context, like a client without deadline support
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	start := time.Now()
+	report := p.Run(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Equal(t, "timed out after 20ms", report.Checks[0].Error)
+}
+
+func TestProbeCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	p := NewProbe(Options{Timeout: time.Second, CacheTTL: 50 * time.Millisecond})
+	p.Add("logging", CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	first := p.Run(context.Background())
+	assert.False(t, first.Checks[0].Cached)
+	second := p.Run(context.Background())
+	assert.True(t, second.Checks[0].Cached)
+	assert.Equal(t, first.Checks[0].CheckedAt, second.Checks[0].CheckedAt)
+	assert.Equal(t, int32(1), calls.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.False(t, p.Run(context.Background()).Checks[0].Cached)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestProbeCompletesChecksAfterClientCancels(t *testing.T) {
+	p := NewProbe(Options{Timeout: time.Second, CacheTTL: time.Minute})
+	p.Add("config", CheckerFunc(func(ctx context.Context) error { return ctx.Err() }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, StatusOK, p.Run(ctx).Status)
+}
@@ -0,0 +1,117 @@
+// Package idempotency lets clients safely retry create requests. A request
+// carrying an Idempotency-Key header is recorded with a fingerprint of its
+// body and, once it finishes, its response; duplicates replay the stored
+// response instead of creating the resource again.
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// Record states
+const (
+	StatePending   = "pending"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+)
+
+// Record is the stored state of an idempotency key
+type Record struct {
+	Key         string
+	Fingerprint string
+	State       string
+	// Attempts counts how many times a request with this key was executed
+	Attempts  int
+	Response  *Response
+	CreatedAt time.Time
+	// UpdatedAt is when the record last changed state; a pending record that
+	// has not changed for longer than the lock timeout is considered abandoned
+	UpdatedAt time.Time
+}
+
+// Response is a stored HTTP response
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Options configures how long keys are remembered
+type Options struct {
+	// TTL is how long a key is remembered after it was first used
+	TTL time.Duration
+	// LockTimeout is how long a pending request holds its key before a
+	// retry may take over, e.g. after the server crashed mid-request
+	LockTimeout time.Duration
+}
+
+// Store persists idempotency records
+type Store interface {
+	// Begin claims key for a request with the given fingerprint. When acquired
+	// is true the caller must run the request and then call Finish or
+	// Abandon; otherwise rec is the record currently holding the key.
+	Begin(ctx context.Context, key, fingerprint string) (rec *Record, acquired bool, err error)
+	// Finish stores the final response for key
+	Finish(ctx context.Context, key string, resp *Response) error
+	// Abandon releases key after a failed attempt so that it can be retried
+	Abandon(ctx context.Context, key string) error
+	// Close releases the store
+	Close() error
+}
+
+// NewStore creates the store selected by IDEMPOTENCY_STORE
+func NewStore(cfg *config.Config) (Store, error) {
+	opts := Options{
+		TTL:         time.Duration(cfg.IdempotencyTTLHours) * time.Hour,
+		LockTimeout: time.Duration(cfg.IdempotencyLockTimeoutSeconds) * time.Second,
+	}
+
+	switch cfg.IdempotencyStore {
+	case "memory", "":
+		return NewMemoryStore(opts), nil
+	case "sqlite":
+		return NewSQLiteStore(cfg.IdempotencyDatabase, opts)
+	default:
+		return nil, fmt.Errorf("unknown idempotency store %q", cfg.IdempotencyStore)
+	}
+}
+
+// claim decides whether a request may take over key given its current
+// record. It returns the record to store and whether it was acquired; when
+// not acquired the returned record is the unchanged existing one.
+func claim(existing *Record, key, fingerprint string, now time.Time, opts Options) (*Record, bool) {
+	if existing == nil || (opts.TTL > 0 && now.Sub(existing.CreatedAt) > opts.TTL) {
+		return &Record{
+			Key:         key,
+			Fingerprint: fingerprint,
+			State:       StatePending,
+			Attempts:    1,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}, true
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return existing, false
+	}
+
+	switch existing.State {
+	case StateFailed:
+	case StatePending:
+		if opts.LockTimeout <= 0 || now.Sub(existing.UpdatedAt) <= opts.LockTimeout {
+			return existing, false
+		}
+	default:
+		return existing, false
+	}
+
+	retry := *existing
+	retry.State = StatePending
+	retry.Attempts++
+	retry.UpdatedAt = now
+	return &retry, true
+}
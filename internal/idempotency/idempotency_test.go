@@ -0,0 +1,149 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+)
+
+func TestClaim(t *testing.T) {
+	now := time.Now()
+	opts := Options{TTL: time.Hour, LockTimeout: time.Minute}
+
+	rec, acquired := claim(nil, "k", "fp", now, opts)
+	require.True(t, acquired)
+	assert.Equal(t, StatePending, rec.State)
+	assert.Equal(t, 1, rec.Attempts)
+
+	// A running request holds the key until the lock times out
+	_, acquired = claim(rec, "k", "fp", now.Add(30*time.Second), opts)
+	assert.False(t, acquired)
+	retry, acquired := claim(rec, "k", "fp", now.Add(2*time.Minute), opts)
+	require.True(t, acquired)
+	assert.Equal(t, 2, retry.Attempts)
+
+	// A failed attempt may be retried, but only with the same request
+	failed := &Record{Key: "k", Fingerprint: "fp", State: StateFailed, Attempts: 1, CreatedAt: now, UpdatedAt: now}
+	_, acquired = claim(failed, "k", "other", now, opts)
+	assert.False(t, acquired)
+	retry, acquired = claim(failed, "k", "fp", now, opts)
+	require.True(t, acquired)
+	assert.Equal(t, 2, retry.Attempts)
+
+	// Completed keys are never re-run until they expire
+	completed := &Record{Key: "k", Fingerprint: "fp", State: StateCompleted, Attempts: 1, CreatedAt: now, UpdatedAt: now}
+	_, acquired = claim(completed, "k", "fp", now, opts)
+	assert.False(t, acquired)
+	rec, acquired = claim(completed, "k", "other", now.Add(2*time.Hour), opts)
+	require.True(t, acquired)
+	assert.Equal(t, 1, rec.Attempts)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "idempotency.db"), Options{TTL: time.Hour})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+
+	_, acquired, err := store.Begin(ctx, "user:k", "fp")
+	require.NoError(t, err)
+	require.True(t, acquired)
+	require.NoError(t, store.Finish(ctx, "user:k", &Response{Status: 201, ContentType: "application/json", Body: []byte(`{}`)}))
+
+	rec, acquired, err := store.Begin(ctx, "user:k", "fp")
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, StateCompleted, rec.State)
+	require.NotNil(t, rec.Response)
+	assert.Equal(t, 201, rec.Response.Status)
+	assert.Equal(t, `{}`, string(rec.Response.Body))
+}
+
+// setupRoutes serves a create endpoint that returns status and counts calls
+func setupRoutes(store Store, status *int, calls *int) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.NoContent(gcperrors.HTTPStatus(gcperrors.Code(err)))
+	}
+	g := e.Group("/api/v1", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user_id", c.Request().Header.Get("X-Test-User"))
+			return next(c)
+		}
+	}, Middleware(store))
+	g.POST("/buckets", func(c echo.Context) error {
+		*calls++
+		return c.JSON(*status, map[string]interface{}{"call": *calls, "retry": IsRetry(c)})
+	})
+	return e
+}
+
+func post(e *echo.Echo, user, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/buckets", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-Test-User", user)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddlewareReplaysCompletedRequests(t *testing.T) {
+	status, calls := http.StatusCreated, 0
+	e := setupRoutes(NewMemoryStore(Options{TTL: time.Hour}), &status, &calls)
+
+	first := post(e, "alice", "key-1", `{"name":"b"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	replay := post(e, "alice", "key-1", `{"name":"b"}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get(HeaderReplayed))
+	assert.JSONEq(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Another body under the same key is rejected
+	assert.Equal(t, http.StatusBadRequest, post(e, "alice", "key-1", `{"name":"c"}`).Code)
+
+	// Keys are scoped per caller, and requests without a key always run
+	assert.Equal(t, http.StatusCreated, post(e, "bob", "key-1", `{"name":"c"}`).Code)
+	assert.Equal(t, http.StatusCreated, post(e, "alice", "", `{"name":"b"}`).Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestMiddlewareRetriesServerErrors(t *testing.T) {
+	status, calls := http.StatusInternalServerError, 0
+	e := setupRoutes(NewMemoryStore(Options{TTL: time.Hour}), &status, &calls)
+
+	assert.Equal(t, http.StatusInternalServerError, post(e, "alice", "key-1", `{}`).Code)
+
+	status = http.StatusCreated
+	retry := post(e, "alice", "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.JSONEq(t, `{"call":2,"retry":true}`, retry.Body.String())
+}
+
+func TestMiddlewareRejectsConcurrentDuplicates(t *testing.T) {
+	store := NewMemoryStore(Options{TTL: time.Hour, LockTimeout: time.Minute})
+	_, acquired, err := store.Begin(context.Background(), "alice:key-1", fingerprint(
+		httptest.NewRequest(http.MethodPost, "/api/v1/buckets", nil), []byte(`{}`)))
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	status, calls := http.StatusCreated, 0
+	e := setupRoutes(store, &status, &calls)
+
+	assert.Equal(t, http.StatusConflict, post(e, "alice", "key-1", `{}`).Code)
+	assert.Equal(t, 0, calls)
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in process memory. Keys are lost on restart and
+// are not shared between instances.
+type MemoryStore struct {
+	mu      sync.Mutex
+	opts    Options
+	records map[string]*Record
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore(opts Options) *MemoryStore {
+	return &MemoryStore{
+		opts:    opts,
+		records: make(map[string]*Record),
+		now:     time.Now,
+	}
+}
+
+// Begin claims key, dropping expired records on the way
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.expire(now)
+
+	rec, acquired := claim(s.records[key], key, fingerprint, now, s.opts)
+	if acquired {
+		s.records[key] = rec
+	}
+	copied := *rec
+	return &copied, acquired, nil
+}
+
+// Finish stores the final response for key
+func (s *MemoryStore) Finish(ctx context.Context, key string, resp *Response) error {
+	return s.update(key, StateCompleted, resp)
+}
+
+// Abandon marks the attempt for key as failed
+func (s *MemoryStore) Abandon(ctx context.Context, key string) error {
+	return s.update(key, StateFailed, nil)
+}
+
+// Close does nothing
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func (s *MemoryStore) update(key, state string, resp *Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rec, ok := s.records[key]; ok {
+		rec.State = state
+		rec.Response = resp
+		rec.UpdatedAt = s.now()
+	}
+	return nil
+}
+
+// expire removes records older than the TTL
+func (s *MemoryStore) expire(now time.Time) {
+	if s.opts.TTL <= 0 {
+		return
+	}
+	for key, rec := range s.records {
+		if now.Sub(rec.CreatedAt) > s.opts.TTL {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+)
+
+// Headers
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderReplayed is set to "true" on responses replayed from the store
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// Limits
+const (
+	// MaxKeyLength is the longest accepted Idempotency-Key
+	MaxKeyLength = 255
+	// maxRequestBody is the largest create request that can be fingerprinted
+	maxRequestBody = 1 << 20
+	// maxStoredResponse is the largest response that is stored for replay
+	maxStoredResponse = 1 << 20
+)
+
+// retryContextKey marks a request that re-runs a key whose earlier attempt
+// did not complete
+const retryContextKey = "idempotency_retry"
+
+// Middleware makes POST requests carrying an Idempotency-Key header safe to
+// retry. Keys are scoped to the caller, so it must run after
+// authentication. The first request with a key runs normally and its
+// response is stored unless it failed with a server error; a duplicate with
+// the same method, path and body replays that response, while reusing a key
+// for a different request is rejected as FAILED_PRECONDITION. Requests
+// without the header and dry runs are unaffected.
+func Middleware(store Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if req.Method != http.MethodPost || key == "" {
+				return next(c)
+			}
+			if dryRun, err := dryrun.Requested(req); dryRun || err != nil {
+				// Dry runs change nothing, so they are safe to repeat
+				return next(c)
+			}
+
+			if len(key) > MaxKeyLength {
+				return gcperrors.New(codes.InvalidArgument, "Idempotency-Key must be at most 255 characters")
+			}
+
+			body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestBody+1))
+			if err != nil {
+				return gcperrors.New(codes.InvalidArgument, "invalid request format: %w", err)
+			}
+			if len(body) > maxRequestBody {
+				return gcperrors.New(codes.InvalidArgument, "requests with an Idempotency-Key are limited to 1 MiB")
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID, _, _ := authmiddleware.GetUserFromContext(c)
+			scopedKey := userID + ":" + key
+
+			fp := fingerprint(req, body)
+			rec, acquired, err := store.Begin(req.Context(), scopedKey, fp)
+			if err != nil {
+				logging.FromContext(req.Context()).ErrorContext(req.Context(), "Failed to look up idempotency key", "error", err)
+				return gcperrors.New(codes.Unavailable, "the request was not executed; retry later with the same Idempotency-Key")
+			}
+
+			if !acquired {
+				return conflict(c, rec, fp)
+			}
+			if rec.Attempts > 1 {
+				c.Set(retryContextKey, true)
+			}
+
+			capture := &responseCapture{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = capture
+
+			handlerErr := next(c)
+			if handlerErr != nil {
+				// Render the error now so that it is stored like any other response
+				c.Error(handlerErr)
+			}
+
+			status := c.Response().Status
+			if status >= http.StatusInternalServerError || capture.overflow {
+				if err := store.Abandon(req.Context(), scopedKey); err != nil {
+					logging.FromContext(req.Context()).ErrorContext(req.Context(), "Failed to release idempotency key", "error", err)
+				}
+				return handlerErr
+			}
+
+			resp := &Response{
+				Status:      status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        capture.body.Bytes(),
+			}
+			if err := store.Finish(req.Context(), scopedKey, resp); err != nil {
+				logging.FromContext(req.Context()).ErrorContext(req.Context(), "Failed to store idempotent response", "error", err)
+			}
+			return handlerErr
+		}
+	}
+}
+
+// IsRetry reports whether the request re-runs an idempotency key whose
+// earlier attempt failed or was abandoned. Create handlers use it to treat
+// "already exists" as success, since the earlier attempt may have created
+// the resource before failing.
+func IsRetry(c echo.Context) bool {
+	retry, _ := c.Get(retryContextKey).(bool)
+	return retry
+}
+
+// conflict answers a request whose key is held by rec
+func conflict(c echo.Context, rec *Record, fp string) error {
+	switch {
+	case rec.Fingerprint != fp:
+		return gcperrors.New(codes.FailedPrecondition, "Idempotency-Key was already used for a different request")
+	case rec.State == StateCompleted && rec.Response != nil:
+		c.Response().Header().Set(HeaderReplayed, "true")
+		contentType := rec.Response.ContentType
+		if contentType == "" {
+			contentType = echo.MIMEApplicationJSON
+		}
+		return c.Blob(rec.Response.Status, contentType, rec.Response.Body)
+	default:
+		return gcperrors.New(codes.Aborted, "a request with this Idempotency-Key is still being processed")
+	}
+}
+
+// fingerprint identifies a request by method, path and body
+func fingerprint(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseCapture keeps a copy of the response body for replay
+type responseCapture struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *responseCapture) Write(b []byte) (int, error) {
+	if w.body.Len()+len(b) > maxStoredResponse {
+		w.overflow = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
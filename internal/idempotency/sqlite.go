@@ -0,0 +1,144 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	// Registers the pure-Go "sqlite" driver, so builds with CGO_ENABLED=0 work
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	scoped_key    TEXT PRIMARY KEY,
+	fingerprint   TEXT NOT NULL,
+	state         TEXT NOT NULL,
+	attempts      INTEGER NOT NULL,
+	status        INTEGER,
+	content_type  TEXT,
+	body          BLOB,
+	created_at    INTEGER NOT NULL,
+	updated_at    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at ON idempotency_keys (created_at);
+`
+
+// SQLiteStore keeps records in a SQLite database so that keys survive
+// restarts
+type SQLiteStore struct {
+	db   *sql.DB
+	opts Options
+}
+
+// NewSQLiteStore opens or creates the database at path
+func NewSQLiteStore(path string, opts Options) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency database directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open idempotency database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize idempotency database: %w", err)
+	}
+
+	return &SQLiteStore{db: db, opts: opts}, nil
+}
+
+// Begin claims key inside a write transaction
+func (s *SQLiteStore) Begin(ctx context.Context, key, fingerprint string) (*Record, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin idempotency transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	if s.opts.TTL > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`,
+			now.Add(-s.opts.TTL).UnixNano()); err != nil {
+			return nil, false, fmt.Errorf("failed to expire idempotency keys: %w", err)
+		}
+	}
+
+	existing, err := s.get(ctx, tx, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rec, acquired := claim(existing, key, fingerprint, now, s.opts)
+	if !acquired {
+		return rec, false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO idempotency_keys
+		(scoped_key, fingerprint, state, attempts, status, content_type, body, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NULL, NULL, NULL, ?, ?)`,
+		rec.Key, rec.Fingerprint, rec.State, rec.Attempts, rec.CreatedAt.UnixNano(), rec.UpdatedAt.UnixNano()); err != nil {
+		return nil, false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return rec, true, nil
+}
+
+// Finish stores the final response for key
+func (s *SQLiteStore) Finish(ctx context.Context, key string, resp *Response) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE idempotency_keys
+		SET state = ?, status = ?, content_type = ?, body = ?, updated_at = ? WHERE scoped_key = ?`,
+		StateCompleted, resp.Status, resp.ContentType, resp.Body, time.Now().UnixNano(), key)
+	return err
+}
+
+// Abandon marks the attempt for key as failed
+func (s *SQLiteStore) Abandon(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE idempotency_keys SET state = ?, updated_at = ? WHERE scoped_key = ?`,
+		StateFailed, time.Now().UnixNano(), key)
+	return err
+}
+
+// Ping checks the database connection
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) get(ctx context.Context, tx *sql.Tx, key string) (*Record, error) {
+	var (
+		rec         Record
+		status      sql.NullInt64
+		contentType sql.NullString
+		body        []byte
+		createdAt   int64
+		updatedAt   int64
+	)
+	err := tx.QueryRowContext(ctx, `SELECT scoped_key, fingerprint, state, attempts, status, content_type, body,
+		created_at, updated_at FROM idempotency_keys WHERE scoped_key = ?`, key).
+		Scan(&rec.Key, &rec.Fingerprint, &rec.State, &rec.Attempts, &status, &contentType, &body, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency key: %w", err)
+	}
+
+	rec.CreatedAt = time.Unix(0, createdAt)
+	rec.UpdatedAt = time.Unix(0, updatedAt)
+	if status.Valid {
+		rec.Response = &Response{Status: int(status.Int64), ContentType: contentType.String, Body: body}
+	}
+	return &rec, nil
+}
@@ -0,0 +1,71 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"google.golang.org/api/idtoken"
+
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+const (
+	// GoogleProviderName is the provider name recorded for Google sign-ins
+	GoogleProviderName = "google"
+	// GoogleIssuer is the canonical issuer of Google ID tokens
+	GoogleIssuer = "https://accounts.google.com"
+)
+
+// GoogleProvider verifies Google ID tokens
+type GoogleProvider struct {
+	clientID  string
+	audiences []string
+}
+
+// NewGoogleProvider creates a Google identity provider for the given OAuth
+// client ID, also accepting tokens issued to any of the further audiences
+func NewGoogleProvider(clientID string, audiences ...string) *GoogleProvider {
+	return &GoogleProvider{clientID: clientID, audiences: audiences}
+}
+
+// Name returns the provider name
+func (p *GoogleProvider) Name() string {
+	return GoogleProviderName
+}
+
+// Issuers returns both issuer spellings Google uses
+func (p *GoogleProvider) Issuers() []string {
+	return []string{GoogleIssuer, "accounts.google.com"}
+}
+
+// Verify validates a Google ID token and extracts user information
+func (p *GoogleProvider) Verify(ctx context.Context, rawIDToken string) (*models.GoogleUserInfo, error) {
+	// With further audiences the audience is checked below
+	audience := p.clientID
+	if len(p.audiences) > 0 {
+		audience = ""
+	}
+	payload, err := idtoken.Validate(ctx, rawIDToken, audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate Google ID token: %w", err)
+	}
+	if len(p.audiences) > 0 && payload.Audience != p.clientID && !slices.Contains(p.audiences, payload.Audience) {
+		return nil, fmt.Errorf("Google ID token audience %q is not accepted", payload.Audience)
+	}
+
+	userInfo := mapClaims(payload.Claims, defaultClaimMapping)
+	userInfo.Sub = payload.Subject
+	userInfo.Provider = GoogleProviderName
+
+	// Verify required fields
+	if userInfo.Email == "" {
+		return nil, fmt.Errorf("email not found in Google ID token")
+	}
+
+	if !userInfo.EmailVerified {
+		return nil, fmt.Errorf("Google account email not verified")
+	}
+
+	return userInfo, nil
+}
@@ -0,0 +1,195 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// Group resolver types accepted by GROUP_RESOLVER
+const (
+	GroupResolverStatic          = "static"
+	GroupResolverGoogleDirectory = "google-directory"
+)
+
+// GroupResolver looks up the groups a user belongs to
+type GroupResolver interface {
+	ResolveGroups(ctx context.Context, email string) ([]string, error)
+}
+
+// NewGroupResolver creates the group resolver selected by GROUP_RESOLVER.
+// It returns nil when group resolution is disabled.
+func NewGroupResolver(cfg *config.Config) GroupResolver {
+	var resolver GroupResolver
+	switch cfg.GroupResolver {
+	case GroupResolverStatic:
+		resolver = NewStaticGroupResolver(cfg.GroupMappings)
+	case GroupResolverGoogleDirectory:
+		resolver = NewDirectoryGroupResolver(cfg.DirectoryCredentials, cfg.DirectoryAdminEmail, cfg.DirectoryCustomerID)
+	default:
+		return nil
+	}
+
+	if cfg.GroupCacheTTLMinutes > 0 {
+		resolver = NewCachedGroupResolver(resolver, time.Duration(cfg.GroupCacheTTLMinutes)*time.Minute)
+	}
+	return resolver
+}
+
+// StaticGroupResolver resolves groups from a fixed email-to-groups mapping
+type StaticGroupResolver struct {
+	members map[string][]string
+}
+
+// NewStaticGroupResolver creates a resolver from an email-to-groups mapping
+func NewStaticGroupResolver(members map[string][]string) *StaticGroupResolver {
+	normalized := make(map[string][]string, len(members))
+	for email, groups := range members {
+		normalized[strings.ToLower(email)] = groups
+	}
+	return &StaticGroupResolver{members: normalized}
+}
+
+// ResolveGroups returns the configured groups for the email
+func (r *StaticGroupResolver) ResolveGroups(ctx context.Context, email string) ([]string, error) {
+	return r.members[strings.ToLower(email)], nil
+}
+
+// DirectoryGroupResolver resolves Google Workspace group membership through
+// the Admin SDK Directory API. The service account needs domain-wide
+// delegation for the admin.directory.group.readonly scope and impersonates
+// adminEmail.
+type DirectoryGroupResolver struct {
+	credentialsFile string
+	adminEmail      string
+	customerID      string
+
+	mu      sync.Mutex
+	service *admin.Service
+}
+
+// NewDirectoryGroupResolver creates a Directory API group resolver
+func NewDirectoryGroupResolver(credentialsFile, adminEmail, customerID string) *DirectoryGroupResolver {
+	return &DirectoryGroupResolver{
+		credentialsFile: credentialsFile,
+		adminEmail:      adminEmail,
+		customerID:      customerID,
+	}
+}
+
+// ResolveGroups lists the groups the user is a direct member of
+func (r *DirectoryGroupResolver) ResolveGroups(ctx context.Context, email string) ([]string, error) {
+	service, err := r.getService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
+	call := service.Groups.List().UserKey(email)
+	if r.customerID != "" {
+		call = call.Customer(r.customerID)
+	}
+	err = call.Pages(ctx, func(page *admin.Groups) error {
+		for _, g := range page.Groups {
+			groups = append(groups, strings.ToLower(g.Email))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory groups for %s: %w", email, err)
+	}
+
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// getService lazily creates the Directory API client
+func (r *DirectoryGroupResolver) getService(ctx context.Context) (*admin.Service, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.service != nil {
+		return r.service, nil
+	}
+
+	if r.credentialsFile == "" || r.adminEmail == "" {
+		return nil, fmt.Errorf("directory group resolver requires DIRECTORY_CREDENTIALS and DIRECTORY_ADMIN_EMAIL")
+	}
+
+	// #nosec G304 - credentials path is supplied by the operator
+	data, err := os.ReadFile(r.credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory credentials: %w", err)
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON(data, admin.AdminDirectoryGroupReadonlyScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse directory credentials: %w", err)
+	}
+	jwtConfig.Subject = r.adminEmail
+
+	// Use a background context so the token source outlives this request
+	service, err := admin.NewService(context.Background(), option.WithTokenSource(jwtConfig.TokenSource(context.Background())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create directory client: %w", err)
+	}
+
+	r.service = service
+	return service, nil
+}
+
+// CachedGroupResolver caches another resolver's results for a fixed TTL
+type CachedGroupResolver struct {
+	next GroupResolver
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedGroups
+}
+
+type cachedGroups struct {
+	groups    []string
+	expiresAt time.Time
+}
+
+// NewCachedGroupResolver wraps a resolver with a TTL cache
+func NewCachedGroupResolver(next GroupResolver, ttl time.Duration) *CachedGroupResolver {
+	return &CachedGroupResolver{
+		next:    next,
+		ttl:     ttl,
+		entries: make(map[string]cachedGroups),
+	}
+}
+
+// ResolveGroups returns cached groups or resolves and caches them
+func (r *CachedGroupResolver) ResolveGroups(ctx context.Context, email string) ([]string, error) {
+	key := strings.ToLower(email)
+
+	r.mu.Lock()
+	entry, ok := r.entries[key]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.groups, nil
+	}
+
+	groups, err := r.next.ResolveGroups(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[key] = cachedGroups{groups: groups, expiresAt: time.Now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return groups, nil
+}
@@ -0,0 +1,250 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// testIssuer is a minimal OIDC issuer serving discovery and JWKS documents
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ti := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                ti.server.URL,
+			"authorization_endpoint":                ti.server.URL + "/authorize",
+			"token_endpoint":                        ti.server.URL + "/token",
+			"jwks_uri":                              ti.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test-key",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	ti.server = httptest.NewServer(mux)
+	t.Cleanup(ti.server.Close)
+
+	return ti
+}
+
+func (ti *testIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(ti.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func (ti *testIssuer) claims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":            ti.server.URL,
+		"sub":            "contractor-42",
+		"aud":            "gcp-automation-api",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"email":          "jane@contractor.example.com",
+		"email_verified": true,
+		"name":           "Jane Contractor",
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestOIDCProviderVerify(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:           "contractors",
+		IssuerURL:      ti.server.URL,
+		ClientID:       "gcp-automation-api",
+		AllowedDomains: []string{"contractor.example.com"},
+	})
+
+	tests := []struct {
+		name      string
+		claims    jwt.MapClaims
+		expectErr string
+	}{
+		{
+			name:   "Valid token",
+			claims: ti.claims(nil),
+		},
+		{
+			name:      "Wrong audience",
+			claims:    ti.claims(jwt.MapClaims{"aud": "someone-else"}),
+			expectErr: "audience",
+		},
+		{
+			name:      "Disallowed domain",
+			claims:    ti.claims(jwt.MapClaims{"email": "eve@evil.example.com"}),
+			expectErr: "not allowed",
+		},
+		{
+			name:      "Unverified email",
+			claims:    ti.claims(jwt.MapClaims{"email_verified": false}),
+			expectErr: "not verified",
+		},
+		{
+			name:      "Expired token",
+			claims:    ti.claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+			expectErr: "expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userInfo, err := provider.Verify(context.Background(), ti.sign(t, tt.claims))
+			if tt.expectErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "contractor-42", userInfo.Sub)
+			assert.Equal(t, "jane@contractor.example.com", userInfo.Email)
+			assert.Equal(t, "Jane Contractor", userInfo.Name)
+			assert.Equal(t, "contractors", userInfo.Provider)
+		})
+	}
+}
+
+func TestOIDCProviderClaimMapping(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:                 "azure",
+		IssuerURL:            ti.server.URL,
+		Audiences:            []string{"api://gcp-automation", "gcp-automation-api"},
+		AllowedDomains:       []string{"9188040d-6c67-4c5b-b112-36a304b66dad"},
+		AllowUnverifiedEmail: true,
+		Claims: config.OIDCClaimMapping{
+			Subject:       "oid",
+			Email:         "upn",
+			EmailVerified: "xms_edov",
+			HostedDomain:  "tid",
+		},
+	})
+
+	token := ti.sign(t, ti.claims(jwt.MapClaims{
+		"aud":      "api://gcp-automation",
+		"oid":      "object-id-1",
+		"upn":      "jane@corp.example.com",
+		"tid":      "9188040d-6c67-4c5b-b112-36a304b66dad",
+		"xms_edov": nil,
+	}))
+
+	userInfo, err := provider.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "object-id-1", userInfo.Sub)
+	assert.Equal(t, "jane@corp.example.com", userInfo.Email)
+	assert.False(t, userInfo.EmailVerified)
+}
+
+func TestOIDCProviderRejectsUnverifiedEmailWithoutAllowedDomains(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:                 "azure",
+		IssuerURL:            ti.server.URL,
+		ClientID:             "gcp-automation-api",
+		AllowUnverifiedEmail: true,
+	})
+
+	token := ti.sign(t, ti.claims(jwt.MapClaims{
+		"email":          "ceo@corp.example.com",
+		"email_verified": false,
+	}))
+
+	_, err := provider.Verify(context.Background(), token)
+	assert.ErrorContains(t, err, "not verified")
+}
+
+func TestOIDCProviderMatchesUnverifiedEmailOnHostedDomainOnly(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:                 "azure",
+		IssuerURL:            ti.server.URL,
+		ClientID:             "gcp-automation-api",
+		AllowedDomains:       []string{"corp.example.com"},
+		AllowUnverifiedEmail: true,
+	})
+
+	// Anyone can register an unverified address in an allowed domain
+	token := ti.sign(t, ti.claims(jwt.MapClaims{
+		"email":          "victim@corp.example.com",
+		"email_verified": false,
+	}))
+	_, err := provider.Verify(context.Background(), token)
+	assert.ErrorContains(t, err, "no allowed hosted domain")
+
+	token = ti.sign(t, ti.claims(jwt.MapClaims{
+		"email":          "jane@corp.example.com",
+		"email_verified": false,
+		"hd":             "corp.example.com",
+	}))
+	userInfo, err := provider.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@corp.example.com", userInfo.Email)
+}
+
+func TestRegistryRoutesByIssuer(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	registry := NewRegistry(&config.Config{
+		EnableGoogleAuth: true,
+		OIDCProviders: []config.OIDCProviderConfig{{
+			Name:      "contractors",
+			IssuerURL: ti.server.URL,
+			ClientID:  "gcp-automation-api",
+		}},
+	})
+
+	assert.Equal(t, []string{"contractors", "google"}, registry.Names())
+
+	p, ok := registry.Lookup("accounts.google.com")
+	assert.True(t, ok)
+	assert.Equal(t, GoogleProviderName, p.Name())
+
+	userInfo, err := registry.Verify(context.Background(), ti.sign(t, ti.claims(nil)))
+	require.NoError(t, err)
+	assert.Equal(t, "contractors", userInfo.Provider)
+
+	unknown := ti.sign(t, ti.claims(jwt.MapClaims{"iss": "https://unknown.example.com"}))
+	_, err = registry.Verify(context.Background(), unknown)
+	assert.ErrorContains(t, err, "no identity provider configured")
+}
@@ -0,0 +1,199 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// defaultClaimMapping uses the standard OIDC claim names
+var defaultClaimMapping = config.OIDCClaimMapping{
+	Subject:       "sub",
+	Email:         "email",
+	EmailVerified: "email_verified",
+	Name:          "name",
+	GivenName:     "given_name",
+	FamilyName:    "family_name",
+	Picture:       "picture",
+	Locale:        "locale",
+	HostedDomain:  "hd",
+}
+
+// OIDCProvider verifies ID tokens from any OpenID Connect compliant issuer.
+// The discovery document and signing keys are fetched on first use.
+type OIDCProvider struct {
+	config config.OIDCProviderConfig
+	claims config.OIDCClaimMapping
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// NewOIDCProvider creates a generic OIDC provider from configuration
+func NewOIDCProvider(cfg config.OIDCProviderConfig) *OIDCProvider {
+	return &OIDCProvider{
+		config: cfg,
+		claims: withDefaults(cfg.Claims),
+	}
+}
+
+// Name returns the provider name
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// Issuers returns the configured issuer URL
+func (p *OIDCProvider) Issuers() []string {
+	return []string{p.config.IssuerURL}
+}
+
+// Config returns the provider configuration
+func (p *OIDCProvider) Config() config.OIDCProviderConfig {
+	return p.config
+}
+
+// Discover returns the provider's discovery metadata, fetching it if needed.
+// A failed discovery is retried on the next call.
+func (p *OIDCProvider) Discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %q: %w", p.config.Name, err)
+	}
+
+	p.provider = provider
+	return provider, nil
+}
+
+// Verify validates the ID token signature, issuer, expiry and audience, then
+// maps its claims and applies the provider's domain rules
+func (p *OIDCProvider) Verify(ctx context.Context, rawIDToken string) (*models.GoogleUserInfo, error) {
+	provider, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Audience is checked below so that several client IDs can be accepted
+	verifier := provider.Verifier(&oidc.Config{SkipClientIDCheck: true})
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate %s ID token: %w", p.config.Name, err)
+	}
+
+	if !p.audienceAllowed(idToken.Audience) {
+		return nil, fmt.Errorf("%s ID token audience %v is not accepted", p.config.Name, idToken.Audience)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode %s ID token claims: %w", p.config.Name, err)
+	}
+
+	userInfo := mapClaims(claims, p.claims)
+	userInfo.Provider = p.config.Name
+	if userInfo.Sub == "" {
+		userInfo.Sub = idToken.Subject
+	}
+
+	if userInfo.Email == "" {
+		return nil, fmt.Errorf("email not found in %s ID token", p.config.Name)
+	}
+
+	// An unverified email could be any address, so AllowUnverifiedEmail only
+	// applies to providers restricted to domains, and checkAllowedDomain then
+	// matches the hosted domain or tenant claim instead of the email
+	if !userInfo.EmailVerified && (!p.config.AllowUnverifiedEmail || len(p.config.AllowedDomains) == 0) {
+		return nil, fmt.Errorf("%s account email not verified", p.config.Name)
+	}
+
+	if err := checkAllowedDomain(userInfo, p.config.AllowedDomains); err != nil {
+		return nil, err
+	}
+
+	return userInfo, nil
+}
+
+// audienceAllowed reports whether any token audience is configured for this provider
+func (p *OIDCProvider) audienceAllowed(audiences []string) bool {
+	allowed := p.config.Audiences
+	if len(allowed) == 0 {
+		allowed = []string{p.config.ClientID}
+	}
+
+	for _, aud := range audiences {
+		for _, a := range allowed {
+			if aud == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withDefaults fills empty claim names with the standard OIDC names
+func withDefaults(m config.OIDCClaimMapping) config.OIDCClaimMapping {
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+
+	fill(&m.Subject, defaultClaimMapping.Subject)
+	fill(&m.Email, defaultClaimMapping.Email)
+	fill(&m.EmailVerified, defaultClaimMapping.EmailVerified)
+	fill(&m.Name, defaultClaimMapping.Name)
+	fill(&m.GivenName, defaultClaimMapping.GivenName)
+	fill(&m.FamilyName, defaultClaimMapping.FamilyName)
+	fill(&m.Picture, defaultClaimMapping.Picture)
+	fill(&m.Locale, defaultClaimMapping.Locale)
+	fill(&m.HostedDomain, defaultClaimMapping.HostedDomain)
+
+	return m
+}
+
+// mapClaims extracts user information from raw claims using the given mapping
+func mapClaims(claims map[string]interface{}, m config.OIDCClaimMapping) *models.GoogleUserInfo {
+	// Helper function to safely extract string from claims
+	getString := func(key string) string {
+		if val, ok := claims[key]; ok {
+			if str, ok := val.(string); ok {
+				return str
+			}
+		}
+		return ""
+	}
+
+	// Helper function to safely extract bool from claims; some IdPs send "true"
+	getBool := func(key string) bool {
+		switch val := claims[key].(type) {
+		case bool:
+			return val
+		case string:
+			return val == "true"
+		}
+		return false
+	}
+
+	return &models.GoogleUserInfo{
+		Sub:           getString(m.Subject),
+		Email:         getString(m.Email),
+		EmailVerified: getBool(m.EmailVerified),
+		Name:          getString(m.Name),
+		GivenName:     getString(m.GivenName),
+		FamilyName:    getString(m.FamilyName),
+		Picture:       getString(m.Picture),
+		Locale:        getString(m.Locale),
+		HostedDomain:  getString(m.HostedDomain),
+	}
+}
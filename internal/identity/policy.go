@@ -0,0 +1,140 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Reasons reported by PolicyError
+const (
+	ReasonEmailDenied       = "email_denied"
+	ReasonEmailNotAllowed   = "email_not_allowed"
+	ReasonDomainNotAllowed  = "domain_not_allowed"
+	ReasonGroupRequired     = "group_required"
+	ReasonGroupLookupFailed = "group_lookup_failed"
+)
+
+// PolicyError is returned when a login is rejected by the login policy
+type PolicyError struct {
+	Reason  string
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("login denied: %s", e.Message)
+}
+
+// LoginPolicy decides whether an authenticated user may obtain an API token
+// and resolves the groups embedded in that token.
+//
+// Rules are applied in order:
+//  1. Emails on the deny list are always rejected.
+//  2. Emails on the allow list are accepted regardless of domain.
+//  3. Otherwise, if allowed domains are configured, the user's hosted domain
+//     ("hd" claim) must match one of them. Users of non-Google providers
+//     without an "hd" claim are matched on their email domain.
+//  4. Otherwise, if only an allow list is configured, the user is rejected.
+//  5. If required groups are configured, the user must belong to at least one.
+//
+// An unverified email could be any address, so it is never matched against
+// the allow list, used as the domain, or used to resolve groups.
+type LoginPolicy struct {
+	allowedDomains []string
+	allowedEmails  map[string]bool
+	deniedEmails   map[string]bool
+	requiredGroups []string
+	resolver       GroupResolver
+}
+
+// NewLoginPolicy creates a login policy from configuration
+func NewLoginPolicy(cfg *config.Config) *LoginPolicy {
+	return &LoginPolicy{
+		allowedDomains: cfg.LoginAllowedDomains,
+		allowedEmails:  toSet(cfg.LoginAllowedEmails),
+		deniedEmails:   toSet(cfg.LoginDeniedEmails),
+		requiredGroups: cfg.LoginRequiredGroups,
+		resolver:       NewGroupResolver(cfg),
+	}
+}
+
+// WithGroupResolver replaces the group resolver, e.g. with a test double
+func (p *LoginPolicy) WithGroupResolver(resolver GroupResolver) *LoginPolicy {
+	p.resolver = resolver
+	return p
+}
+
+// Evaluate applies the policy to an authenticated user and returns the
+// user's groups. A *PolicyError is returned when the login is rejected.
+func (p *LoginPolicy) Evaluate(ctx context.Context, userInfo *models.GoogleUserInfo) ([]string, error) {
+	email := strings.ToLower(userInfo.Email)
+
+	if p.deniedEmails[email] {
+		return nil, &PolicyError{Reason: ReasonEmailDenied, Message: fmt.Sprintf("%s is on the deny list", userInfo.Email)}
+	}
+
+	if !userInfo.EmailVerified || !p.allowedEmails[email] {
+		switch {
+		case len(p.allowedDomains) > 0:
+			domain := userInfo.HostedDomain
+			if domain == "" && userInfo.EmailVerified && userInfo.Provider != "" && userInfo.Provider != GoogleProviderName {
+				domain = EmailDomain(userInfo.Email)
+			}
+			if !containsFold(p.allowedDomains, domain) {
+				if domain == "" {
+					return nil, &PolicyError{Reason: ReasonDomainNotAllowed, Message: "account does not belong to an allowed hosted domain"}
+				}
+				return nil, &PolicyError{Reason: ReasonDomainNotAllowed, Message: fmt.Sprintf("hosted domain %q is not allowed", domain)}
+			}
+		case len(p.allowedEmails) > 0:
+			return nil, &PolicyError{Reason: ReasonEmailNotAllowed, Message: fmt.Sprintf("%s is not on the allow list", userInfo.Email)}
+		}
+	}
+
+	var groups []string
+	if p.resolver != nil && userInfo.EmailVerified {
+		var err error
+		groups, err = p.resolver.ResolveGroups(ctx, userInfo.Email)
+		if err != nil {
+			return nil, &PolicyError{Reason: ReasonGroupLookupFailed, Message: err.Error()}
+		}
+	}
+
+	if len(p.requiredGroups) > 0 && !intersectsFold(p.requiredGroups, groups) {
+		return nil, &PolicyError{Reason: ReasonGroupRequired, Message: fmt.Sprintf("%s is not a member of any required group", userInfo.Email)}
+	}
+
+	return groups, nil
+}
+
+// toSet builds a lower-cased lookup set
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// containsFold reports whether value is in list, ignoring case
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// intersectsFold reports whether any element of a is in b, ignoring case
+func intersectsFold(a, b []string) bool {
+	for _, item := range a {
+		if containsFold(b, item) {
+			return true
+		}
+	}
+	return false
+}
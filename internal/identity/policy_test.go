@@ -0,0 +1,154 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// failingResolver always returns an error
+type failingResolver struct{}
+
+func (failingResolver) ResolveGroups(ctx context.Context, email string) ([]string, error) {
+	return nil, errors.New("directory unavailable")
+}
+
+func TestLoginPolicyEvaluate(t *testing.T) {
+	members := map[string][]string{
+		"alice@example.com":   {"platform-admins@example.com"},
+		"bob@example.com":     {"developers@example.com"},
+		"partner@partner.com": {"platform-admins@example.com"},
+	}
+
+	tests := []struct {
+		name         string
+		cfg          config.Config
+		user         models.GoogleUserInfo
+		expectReason string
+		expectGroups []string
+	}{
+		{
+			name: "No rules allows everyone",
+			user: models.GoogleUserInfo{Email: "anyone@gmail.com", EmailVerified: true},
+		},
+		{
+			name: "Hosted domain allowed",
+			cfg:  config.Config{LoginAllowedDomains: []string{"example.com"}},
+			user: models.GoogleUserInfo{Email: "alice@example.com", EmailVerified: true, HostedDomain: "example.com"},
+		},
+		{
+			name:         "Consumer account without hd rejected",
+			cfg:          config.Config{LoginAllowedDomains: []string{"example.com"}},
+			user:         models.GoogleUserInfo{Email: "alice@example.com", EmailVerified: true},
+			expectReason: ReasonDomainNotAllowed,
+		},
+		{
+			name: "OIDC provider falls back to email domain",
+			cfg:  config.Config{LoginAllowedDomains: []string{"contractor.com"}},
+			user: models.GoogleUserInfo{Email: "jane@contractor.com", EmailVerified: true, Provider: "contractors"},
+		},
+		{
+			name:         "OIDC provider with unverified email is not matched on email domain",
+			cfg:          config.Config{LoginAllowedDomains: []string{"contractor.com"}},
+			user:         models.GoogleUserInfo{Email: "jane@contractor.com", Provider: "contractors"},
+			expectReason: ReasonDomainNotAllowed,
+		},
+		{
+			name: "Unverified email is matched on hosted domain",
+			cfg:  config.Config{LoginAllowedDomains: []string{"contractor.com"}},
+			user: models.GoogleUserInfo{Email: "jane@contractor.com", HostedDomain: "contractor.com", Provider: "contractors"},
+		},
+		{
+			name:         "Unverified email is not matched on allow list",
+			cfg:          config.Config{LoginAllowedEmails: []string{"alice@example.com"}},
+			user:         models.GoogleUserInfo{Email: "alice@example.com", Provider: "contractors"},
+			expectReason: ReasonEmailNotAllowed,
+		},
+		{
+			name: "Unverified email does not resolve groups",
+			cfg: config.Config{
+				LoginRequiredGroups: []string{"platform-admins@example.com"},
+				GroupResolver:       GroupResolverStatic,
+				GroupMappings:       members,
+			},
+			user:         models.GoogleUserInfo{Email: "alice@example.com", Provider: "contractors"},
+			expectReason: ReasonGroupRequired,
+		},
+		{
+			name: "Allow list bypasses domain rule",
+			cfg: config.Config{
+				LoginAllowedDomains: []string{"example.com"},
+				LoginAllowedEmails:  []string{"Partner@Partner.com"},
+			},
+			user: models.GoogleUserInfo{Email: "partner@partner.com", EmailVerified: true},
+		},
+		{
+			name:         "Allow list only rejects others",
+			cfg:          config.Config{LoginAllowedEmails: []string{"alice@example.com"}},
+			user:         models.GoogleUserInfo{Email: "bob@example.com", EmailVerified: true, HostedDomain: "example.com"},
+			expectReason: ReasonEmailNotAllowed,
+		},
+		{
+			name: "Deny list wins over allow list",
+			cfg: config.Config{
+				LoginAllowedEmails: []string{"alice@example.com"},
+				LoginDeniedEmails:  []string{"alice@example.com"},
+			},
+			user:         models.GoogleUserInfo{Email: "alice@example.com", EmailVerified: true},
+			expectReason: ReasonEmailDenied,
+		},
+		{
+			name: "Groups resolved and required group satisfied",
+			cfg: config.Config{
+				LoginRequiredGroups: []string{"platform-admins@example.com"},
+				GroupResolver:       GroupResolverStatic,
+				GroupMappings:       members,
+			},
+			user:         models.GoogleUserInfo{Email: "alice@example.com", EmailVerified: true},
+			expectGroups: []string{"platform-admins@example.com"},
+		},
+		{
+			name: "Required group missing",
+			cfg: config.Config{
+				LoginRequiredGroups: []string{"platform-admins@example.com"},
+				GroupResolver:       GroupResolverStatic,
+				GroupMappings:       members,
+			},
+			user:         models.GoogleUserInfo{Email: "bob@example.com", EmailVerified: true},
+			expectReason: ReasonGroupRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewLoginPolicy(&tt.cfg)
+			groups, err := policy.Evaluate(context.Background(), &tt.user)
+
+			if tt.expectReason != "" {
+				var policyErr *PolicyError
+				require.ErrorAs(t, err, &policyErr)
+				assert.Equal(t, tt.expectReason, policyErr.Reason)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectGroups, groups)
+		})
+	}
+}
+
+func TestLoginPolicyGroupLookupFailure(t *testing.T) {
+	policy := NewLoginPolicy(&config.Config{}).WithGroupResolver(failingResolver{})
+
+	_, err := policy.Evaluate(context.Background(), &models.GoogleUserInfo{Email: "alice@example.com", EmailVerified: true})
+
+	var policyErr *PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, ReasonGroupLookupFailed, policyErr.Reason)
+}
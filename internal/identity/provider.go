@@ -0,0 +1,155 @@
+// Package identity verifies ID tokens issued by Google and other OpenID
+// Connect identity providers and maps their claims onto user information.
+//
+// Providers are registered by issuer. An incoming ID token is routed to the
+// provider whose issuer matches the token's "iss" claim, so several IdPs can
+// be accepted side by side.
+package identity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Provider verifies ID tokens for a single identity provider
+type Provider interface {
+	// Name returns the short provider identifier stored in issued JWTs
+	Name() string
+	// Issuers returns the "iss" values this provider accepts
+	Issuers() []string
+	// Verify validates the raw ID token and returns the mapped user information
+	Verify(ctx context.Context, rawIDToken string) (*models.GoogleUserInfo, error)
+}
+
+// Registry holds the configured identity providers keyed by issuer
+type Registry struct {
+	byIssuer map[string]Provider
+	byName   map[string]Provider
+}
+
+// NewRegistry creates a registry from configuration. Google is registered when
+// ENABLE_GOOGLE_AUTH is set; every entry in OIDC_PROVIDERS_FILE is registered
+// as a generic OIDC provider.
+func NewRegistry(cfg *config.Config) *Registry {
+	r := &Registry{
+		byIssuer: make(map[string]Provider),
+		byName:   make(map[string]Provider),
+	}
+
+	if cfg.EnableGoogleAuth {
+		r.Register(NewGoogleProvider(cfg.GoogleClientID, cfg.GoogleAudiences...))
+	}
+
+	for _, pc := range cfg.OIDCProviders {
+		r.Register(NewOIDCProvider(pc))
+	}
+
+	return r
+}
+
+// Register adds a provider, replacing any provider with the same name or issuer
+func (r *Registry) Register(p Provider) {
+	r.byName[p.Name()] = p
+	for _, iss := range p.Issuers() {
+		r.byIssuer[normalizeIssuer(iss)] = p
+	}
+}
+
+// Get returns the provider with the given name
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.byName[name]
+	return p, ok
+}
+
+// Lookup returns the provider that accepts the given issuer
+func (r *Registry) Lookup(issuer string) (Provider, bool) {
+	p, ok := r.byIssuer[normalizeIssuer(issuer)]
+	return p, ok
+}
+
+// Names returns the registered provider names in sorted order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Verify routes the ID token to the provider matching its issuer and verifies it
+func (r *Registry) Verify(ctx context.Context, rawIDToken string) (*models.GoogleUserInfo, error) {
+	issuer, err := PeekIssuer(rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := r.Lookup(issuer)
+	if !ok {
+		return nil, fmt.Errorf("no identity provider configured for issuer %q", issuer)
+	}
+
+	return p.Verify(ctx, rawIDToken)
+}
+
+// PeekIssuer extracts the "iss" claim from a JWT without verifying its
+// signature. It is only used to select the provider that performs the real
+// verification.
+func PeekIssuer(rawIDToken string) (string, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(rawIDToken, claims); err != nil {
+		return "", fmt.Errorf("malformed ID token: %w", err)
+	}
+
+	issuer, err := claims.GetIssuer()
+	if err != nil || issuer == "" {
+		return "", fmt.Errorf("ID token has no issuer claim")
+	}
+
+	return issuer, nil
+}
+
+// normalizeIssuer trims trailing slashes so "https://idp/" and "https://idp" match
+func normalizeIssuer(issuer string) string {
+	return strings.TrimRight(issuer, "/")
+}
+
+// checkAllowedDomain verifies the user's email or hosted domain is in the
+// allow list. An unverified email could be any address, so only the hosted
+// domain (or tenant) claim set by the IdP is matched for it.
+func checkAllowedDomain(userInfo *models.GoogleUserInfo, allowedDomains []string) error {
+	if len(allowedDomains) == 0 {
+		return nil
+	}
+
+	domain := EmailDomain(userInfo.Email)
+	for _, allowed := range allowedDomains {
+		if userInfo.HostedDomain != "" && strings.EqualFold(allowed, userInfo.HostedDomain) {
+			return nil
+		}
+		if userInfo.EmailVerified && strings.EqualFold(allowed, domain) {
+			return nil
+		}
+	}
+
+	if !userInfo.EmailVerified {
+		return fmt.Errorf("unverified email %q has no allowed hosted domain for this identity provider", userInfo.Email)
+	}
+	return fmt.Errorf("email domain %q is not allowed for this identity provider", domain)
+}
+
+// EmailDomain returns the lower-cased domain part of an email address
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
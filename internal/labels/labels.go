@@ -0,0 +1,356 @@
+// Package labels keeps a registry of label schemas, the rules the labels of
+// each resource type must follow beyond GCP's syntax: required keys, allowed
+// values, patterns, defaults and deprecated keys. Schemas are enforced on
+// create requests and used to report existing resources that break them.
+package labels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Resource types that can have a label schema
+const (
+	ResourceBucket  = "bucket"
+	ResourceProject = "project"
+)
+
+// Violation reasons
+const (
+	ReasonMissing         = "missing"
+	ReasonNotAllowed      = "not_allowed"
+	ReasonPatternMismatch = "pattern_mismatch"
+	ReasonDeprecated      = "deprecated"
+)
+
+// ViolationError is returned for labels that break their schema. It is
+// classified as INVALID_ARGUMENT.
+type ViolationError struct {
+	ResourceType string
+	Violations   []models.LabelViolation
+}
+
+// Error lists the violations
+func (e *ViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return fmt.Sprintf("labels do not follow the %s label schema: %s", e.ResourceType, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the error's classification
+func (e *ViolationError) Unwrap() error {
+	return gcperrors.New(codes.InvalidArgument, "labels do not follow the %s label schema", e.ResourceType)
+}
+
+// schema is a label schema with its patterns compiled
+type schema struct {
+	models.LabelSchema
+	patterns map[string]*regexp.Regexp
+}
+
+// Registry holds the label schema of each resource type. Schemas are saved
+// to a JSON file, if one is set, whenever they change. A nil Registry has
+// no schemas.
+type Registry struct {
+	mu      sync.RWMutex
+	path    string
+	schemas map[string]*schema
+}
+
+// NewRegistry returns a registry that keeps its schemas in the file at path,
+// loading those already there. An empty path keeps them in memory only.
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, schemas: make(map[string]*schema)}
+	if path == "" {
+		return r, nil
+	}
+
+	// #nosec G304 - path is supplied by the operator via LABEL_SCHEMA_FILE
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label schema file: %w", err)
+	}
+	var stored []models.LabelSchema
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse label schema file %s: %w", path, err)
+	}
+	for _, s := range stored {
+		compiled, err := compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("label schema file %s: %w", path, err)
+		}
+		r.schemas[s.ResourceType] = compiled
+	}
+	return r, nil
+}
+
+// List returns the schemas, ordered by resource type
+func (r *Registry) List() []models.LabelSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list()
+}
+
+func (r *Registry) list() []models.LabelSchema {
+	schemas := make([]models.LabelSchema, 0, len(r.schemas))
+	for _, s := range r.schemas {
+		schemas = append(schemas, s.LabelSchema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].ResourceType < schemas[j].ResourceType })
+	return schemas
+}
+
+// Get returns the schema of resourceType, failing with NOT_FOUND if it has
+// none
+func (r *Registry) Get(resourceType string) (*models.LabelSchema, error) {
+	if err := checkResourceType(resourceType); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.schemas[resourceType]
+	if !ok {
+		return nil, gcperrors.New(codes.NotFound, "no label schema for resource type %s", resourceType)
+	}
+	result := s.LabelSchema
+	return &result, nil
+}
+
+// Put creates or replaces the schema of a resource type. Invalid schemas
+// fail with INVALID_ARGUMENT.
+func (r *Registry) Put(s models.LabelSchema) (*models.LabelSchema, error) {
+	if s.UpdatedAt.IsZero() {
+		s.UpdatedAt = time.Now().UTC()
+	}
+	compiled, err := compile(s)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, existed := r.schemas[s.ResourceType]
+	r.schemas[s.ResourceType] = compiled
+	if err := r.save(); err != nil {
+		if existed {
+			r.schemas[s.ResourceType] = previous
+		} else {
+			delete(r.schemas, s.ResourceType)
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Delete removes the schema of resourceType, failing with NOT_FOUND if it
+// has none
+func (r *Registry) Delete(resourceType string) error {
+	if err := checkResourceType(resourceType); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous, ok := r.schemas[resourceType]
+	if !ok {
+		return gcperrors.New(codes.NotFound, "no label schema for resource type %s", resourceType)
+	}
+	delete(r.schemas, resourceType)
+	if err := r.save(); err != nil {
+		r.schemas[resourceType] = previous
+		return err
+	}
+	return nil
+}
+
+// save writes the schemas to the registry's file, replacing it atomically
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.list(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode label schemas: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o750); err != nil {
+		return fmt.Errorf("failed to save label schemas: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save label schemas: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save label schemas: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save label schemas: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to save label schemas: %w", err)
+	}
+	return nil
+}
+
+// Apply enforces the schema of resourceType on the labels of a new
+// resource. It returns the labels with the defaults of missing keys added,
+// and warnings for deprecated keys; labels that break the schema fail with
+// a *ViolationError. Resource types without a schema accept any labels.
+func (r *Registry) Apply(resourceType string, labels map[string]string) (map[string]string, []models.LabelViolation, error) {
+	if r == nil {
+		return labels, nil, nil
+	}
+	r.mu.RLock()
+	s, ok := r.schemas[resourceType]
+	r.mu.RUnlock()
+	if !ok {
+		return labels, nil, nil
+	}
+
+	for _, rule := range s.Keys {
+		if _, set := labels[rule.Key]; !set && rule.Default != "" {
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[rule.Key] = rule.Default
+		}
+	}
+
+	var warnings, violations []models.LabelViolation
+	for _, violation := range s.check(labels) {
+		if violation.Reason == ReasonDeprecated {
+			warnings = append(warnings, violation)
+		} else {
+			violations = append(violations, violation)
+		}
+	}
+	if len(violations) > 0 {
+		return labels, warnings, &ViolationError{ResourceType: resourceType, Violations: violations}
+	}
+	return labels, warnings, nil
+}
+
+// Check lists the ways the labels of an existing resource break the schema
+// of resourceType, including deprecated keys it still has
+func (r *Registry) Check(resourceType string, labels map[string]string) []models.LabelViolation {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	s, ok := r.schemas[resourceType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return s.check(labels)
+}
+
+// check lists the violations of labels, in the order of the schema's keys
+func (s *schema) check(labels map[string]string) []models.LabelViolation {
+	var violations []models.LabelViolation
+	for _, rule := range s.Keys {
+		value, set := labels[rule.Key]
+		switch {
+		case !set:
+			if rule.Required {
+				violations = append(violations, models.LabelViolation{
+					Key:     rule.Key,
+					Reason:  ReasonMissing,
+					Message: fmt.Sprintf("label %s is required", rule.Key),
+				})
+			}
+		case rule.Deprecated:
+			message := fmt.Sprintf("label %s is deprecated", rule.Key)
+			if rule.ReplacedBy != "" {
+				message += "; use " + rule.ReplacedBy
+			}
+			violations = append(violations, models.LabelViolation{Key: rule.Key, Value: value, Reason: ReasonDeprecated, Message: message})
+		case len(rule.AllowedValues) > 0 && !slices.Contains(rule.AllowedValues, value):
+			violations = append(violations, models.LabelViolation{
+				Key:     rule.Key,
+				Value:   value,
+				Reason:  ReasonNotAllowed,
+				Message: fmt.Sprintf("label %s must be one of %s", rule.Key, strings.Join(rule.AllowedValues, ", ")),
+			})
+		case s.patterns[rule.Key] != nil && !s.patterns[rule.Key].MatchString(value):
+			violations = append(violations, models.LabelViolation{
+				Key:     rule.Key,
+				Value:   value,
+				Reason:  ReasonPatternMismatch,
+				Message: fmt.Sprintf("label %s must match %s", rule.Key, rule.Pattern),
+			})
+		}
+	}
+	return violations
+}
+
+// compile checks that s is consistent and compiles its patterns. The
+// syntax of keys and values is checked by the request validator.
+func compile(s models.LabelSchema) (*schema, error) {
+	if err := checkResourceType(s.ResourceType); err != nil {
+		return nil, err
+	}
+
+	compiled := &schema{LabelSchema: s, patterns: make(map[string]*regexp.Regexp)}
+	seen := make(map[string]bool)
+	for _, rule := range s.Keys {
+		if rule.Key == "" {
+			return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: key is required", s.ResourceType)
+		}
+		if seen[rule.Key] {
+			return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: duplicate key %s", s.ResourceType, rule.Key)
+		}
+		seen[rule.Key] = true
+
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile("^(?:" + rule.Pattern + ")$")
+			if err != nil {
+				return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: invalid pattern for %s: %w", s.ResourceType, rule.Key, err)
+			}
+			compiled.patterns[rule.Key] = pattern
+		}
+		if rule.Deprecated && (rule.Required || rule.Default != "") {
+			return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: deprecated key %s cannot be required or have a default", s.ResourceType, rule.Key)
+		}
+		if rule.ReplacedBy != "" && (!rule.Deprecated || rule.ReplacedBy == rule.Key) {
+			return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: replaced_by is only allowed on a deprecated key, naming another key", s.ResourceType)
+		}
+		if rule.Default != "" {
+			if len(rule.AllowedValues) > 0 && !slices.Contains(rule.AllowedValues, rule.Default) ||
+				compiled.patterns[rule.Key] != nil && !compiled.patterns[rule.Key].MatchString(rule.Default) {
+				return nil, gcperrors.New(codes.InvalidArgument, "label schema for %s: default of %s is not an allowed value", s.ResourceType, rule.Key)
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// checkResourceType fails with INVALID_ARGUMENT for resource types that
+// cannot have a schema
+func checkResourceType(resourceType string) error {
+	switch resourceType {
+	case ResourceBucket, ResourceProject:
+		return nil
+	}
+	return gcperrors.New(codes.InvalidArgument, "invalid resource type %q: must be %q or %q", resourceType, ResourceBucket, ResourceProject)
+}
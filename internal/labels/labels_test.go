@@ -0,0 +1,123 @@
+package labels
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+var bucketSchema = models.LabelSchema{
+	ResourceType: ResourceBucket,
+	Keys: []models.LabelKeyRule{
+		{Key: "environment", Required: true, AllowedValues: []string{"dev", "staging", "prod"}, Default: "dev"},
+		{Key: "cost_center", Required: true, Pattern: `cc-[0-9]{4}`},
+		{Key: "team", Pattern: `[a-z]+`},
+		{Key: "env", Deprecated: true, ReplacedBy: "environment"},
+	},
+}
+
+func TestApply(t *testing.T) {
+	registry, err := NewRegistry("")
+	require.NoError(t, err)
+	_, err = registry.Put(bucketSchema)
+	require.NoError(t, err)
+
+	// Defaults are added, and deprecated keys are allowed with a warning
+	labels, warnings, err := registry.Apply(ResourceBucket, map[string]string{"cost_center": "cc-1234", "env": "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cost_center": "cc-1234", "env": "dev", "environment": "dev"}, labels)
+	assert.Equal(t, []models.LabelViolation{{
+		Key: "env", Value: "dev", Reason: ReasonDeprecated, Message: "label env is deprecated; use environment",
+	}}, warnings)
+
+	_, _, err = registry.Apply(ResourceBucket, map[string]string{"environment": "qa", "team": "data-eng"})
+	var violationErr *ViolationError
+	require.True(t, errors.As(err, &violationErr))
+	assert.Equal(t, codes.InvalidArgument, gcperrors.Code(err))
+	assert.Equal(t, []models.LabelViolation{
+		{Key: "environment", Value: "qa", Reason: ReasonNotAllowed, Message: "label environment must be one of dev, staging, prod"},
+		{Key: "cost_center", Reason: ReasonMissing, Message: "label cost_center is required"},
+		{Key: "team", Value: "data-eng", Reason: ReasonPatternMismatch, Message: "label team must match [a-z]+"},
+	}, violationErr.Violations)
+
+	// Resource types without a schema accept any labels
+	labels, warnings, err = registry.Apply(ResourceProject, nil)
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+	assert.Empty(t, warnings)
+}
+
+func TestCheckReportsExistingResources(t *testing.T) {
+	registry, err := NewRegistry("")
+	require.NoError(t, err)
+	_, err = registry.Put(bucketSchema)
+	require.NoError(t, err)
+
+	// Defaults are not assumed for existing resources
+	violations := registry.Check(ResourceBucket, map[string]string{"cost_center": "cc-1234", "env": "prod"})
+	assert.Equal(t, []string{ReasonMissing, ReasonDeprecated}, []string{violations[0].Reason, violations[1].Reason})
+	assert.Empty(t, registry.Check(ResourceBucket, map[string]string{"cost_center": "cc-1234", "environment": "prod"}))
+}
+
+func TestPutRejectsInvalidSchemas(t *testing.T) {
+	registry, err := NewRegistry("")
+	require.NoError(t, err)
+
+	tests := map[string]models.LabelSchema{
+		`invalid resource type "folder"`: {ResourceType: "folder"},
+		"duplicate key team": {ResourceType: ResourceBucket, Keys: []models.LabelKeyRule{
+			{Key: "team"}, {Key: "team"},
+		}},
+		"invalid pattern for team": {ResourceType: ResourceBucket, Keys: []models.LabelKeyRule{
+			{Key: "team", Pattern: "[a-z"},
+		}},
+		"default of environment is not an allowed value": {ResourceType: ResourceBucket, Keys: []models.LabelKeyRule{
+			{Key: "environment", AllowedValues: []string{"dev", "prod"}, Default: "qa"},
+		}},
+		"deprecated key env cannot be required": {ResourceType: ResourceBucket, Keys: []models.LabelKeyRule{
+			{Key: "env", Deprecated: true, Required: true},
+		}},
+		"replaced_by is only allowed on a deprecated key": {ResourceType: ResourceBucket, Keys: []models.LabelKeyRule{
+			{Key: "env", ReplacedBy: "environment"},
+		}},
+	}
+	for want, schema := range tests {
+		_, err := registry.Put(schema)
+		assert.ErrorContains(t, err, want)
+		assert.Equal(t, codes.InvalidArgument, gcperrors.Code(err), want)
+	}
+	assert.Empty(t, registry.List())
+}
+
+func TestRegistryPersistsSchemas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "label-schemas.json")
+	registry, err := NewRegistry(path)
+	require.NoError(t, err)
+	_, err = registry.Put(bucketSchema)
+	require.NoError(t, err)
+	_, err = registry.Put(models.LabelSchema{ResourceType: ResourceProject, Keys: []models.LabelKeyRule{{Key: "owner", Required: true}}})
+	require.NoError(t, err)
+	require.NoError(t, registry.Delete(ResourceBucket))
+	assert.Equal(t, codes.NotFound, gcperrors.Code(registry.Delete(ResourceBucket)))
+
+	reloaded, err := NewRegistry(path)
+	require.NoError(t, err)
+	assert.Equal(t, registry.List(), reloaded.List())
+	_, err = reloaded.Get(ResourceBucket)
+	assert.Equal(t, codes.NotFound, gcperrors.Code(err))
+	schema, err := reloaded.Get(ResourceProject)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", schema.Keys[0].Key)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err = NewRegistry(path)
+	assert.ErrorContains(t, err, "failed to parse label schema file")
+}
@@ -0,0 +1,175 @@
+// Package logging provides the server's structured logger: JSON (or text)
+// output via log/slog with Cloud Logging field names, a runtime-adjustable
+// level, and per-request loggers carried in the request context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// Output formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Cloud Logging special fields; see
+// https://cloud.google.com/logging/docs/structured-logging
+const (
+	KeySeverity     = "severity"
+	KeyMessage      = "message"
+	KeyTrace        = "logging.googleapis.com/trace"
+	KeySpanID       = "logging.googleapis.com/spanId"
+	KeyTraceSampled = "logging.googleapis.com/trace_sampled"
+)
+
+// level is shared by every handler created by Setup so that it can be
+// changed at runtime
+var level = new(slog.LevelVar)
+
+// Setup creates the server's logger writing to stdout and, if configured, the
+// rotated cfg.LogFile, and installs it as the default for both log/slog and
+// the standard log package. Close the returned output on exit.
+func Setup(cfg *config.Config) (*slog.Logger, *Output, error) {
+	lvl, err := ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, nil, err
+	}
+	level.Set(lvl)
+
+	out, err := NewOutput(FileOptions{
+		Path:        cfg.LogFile,
+		MaxSizeMB:   cfg.LogMaxSizeMB,
+		RotateEvery: time.Duration(cfg.LogRotateHours) * time.Hour,
+		MaxBackups:  cfg.LogMaxBackups,
+		MaxAgeDays:  cfg.LogMaxAgeDays,
+		Compress:    cfg.LogCompress,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger := New(out, cfg.LogFormat, cfg.GCPProjectID)
+	slog.SetDefault(logger)
+	return logger, out, nil
+}
+
+// New creates a logger writing to w in the given format. Records logged with
+// a context carrying a span get the Cloud Logging trace fields; projectID is
+// used to qualify the trace name.
+func New(w io.Writer, format, projectID string) *slog.Logger {
+	var handler slog.Handler
+	if format == FormatText {
+		handler = slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})
+	} else {
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: cloudLoggingAttr})
+	}
+	return slog.New(&traceHandler{Handler: handler, projectID: projectID})
+}
+
+// ParseLevel parses a LOG_LEVEL value: debug, info, warn (or warning) or error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q: must be \"debug\", \"info\", \"warn\" or \"error\"", s)
+}
+
+// SetLevel changes the minimum level of every logger created by Setup or New
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Level returns the current minimum level
+func Level() slog.Level {
+	return level.Level()
+}
+
+// cloudLoggingAttr renames the level and message to the fields Cloud Logging
+// recognizes, using its severity names
+func cloudLoggingAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		return slog.String(KeySeverity, severity(a.Value.Any().(slog.Level)))
+	case slog.MessageKey:
+		a.Key = KeyMessage
+	}
+	return a
+}
+
+// severity maps a slog level onto a Cloud Logging severity
+func severity(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "DEBUG"
+	case l < slog.LevelWarn:
+		return "INFO"
+	case l < slog.LevelError:
+		return "WARNING"
+	}
+	return "ERROR"
+}
+
+// traceHandler adds the trace and span IDs of the span in a record's context
+type traceHandler struct {
+	slog.Handler
+	projectID string
+}
+
+func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		traceName := sc.TraceID().String()
+		if h.projectID != "" {
+			traceName = "projects/" + h.projectID + "/traces/" + traceName
+		}
+		r.AddAttrs(
+			slog.String(KeyTrace, traceName),
+			slog.String(KeySpanID, sc.SpanID().String()),
+			slog.Bool(KeyTraceSampled, sc.IsSampled()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithAttrs(attrs), projectID: h.projectID}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{Handler: h.Handler.WithGroup(name), projectID: h.projectID}
+}
+
+// loggerKey is the context key of the request logger
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request logger in ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// entries decodes the JSON lines written to buf
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestParseLevel(t *testing.T) {
+	for in, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "": slog.LevelInfo,
+		"warn": slog.LevelWarn, "warning": slog.LevelWarn, "error": slog.LevelError,
+	} {
+		got, err := ParseLevel(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestCloudLoggingFields(t *testing.T) {
+	defer SetLevel(Level())
+	SetLevel(slog.LevelInfo)
+
+	var buf bytes.Buffer
+	logger := New(&buf, FormatJSON, "my-project")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.DebugContext(ctx, "hidden")
+	logger.WarnContext(ctx, "bucket is public", "bucket", "b")
+	logger.Error("no trace")
+
+	got := entries(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "WARNING", got[0][KeySeverity])
+	assert.Equal(t, "bucket is public", got[0][KeyMessage])
+	assert.Equal(t, "b", got[0]["bucket"])
+	assert.Equal(t, "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", got[0][KeyTrace])
+	assert.Equal(t, "00f067aa0ba902b7", got[0][KeySpanID])
+	assert.Equal(t, true, got[0][KeyTraceSampled])
+	assert.NotContains(t, got[0], slog.LevelKey)
+
+	assert.Equal(t, "ERROR", got[1][KeySeverity])
+	assert.NotContains(t, got[1], KeyTrace)
+
+	// The level can be changed at runtime
+	SetLevel(slog.LevelDebug)
+	logger.Debug("shown")
+	assert.Len(t, entries(t, &buf), 3)
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	e := echo.New()
+	e.Use(Middleware(New(&buf, FormatJSON, "")))
+	e.GET("/api/v1/buckets/:name", func(c echo.Context) error {
+		With(c, "user_id", "alice")
+		FromContext(c.Request().Context()).Info("looking up bucket")
+		return c.NoContent(http.StatusNotFound)
+	})
+
+	send := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/b", nil)
+		if requestID != "" {
+			req.Header.Set(HeaderRequestID, requestID)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send("req-123")
+	assert.Equal(t, "req-123", rec.Header().Get(HeaderRequestID))
+
+	got := entries(t, &buf)
+	require.Len(t, got, 2)
+	for _, entry := range got {
+		assert.Equal(t, "req-123", entry["request_id"])
+		assert.Equal(t, "/api/v1/buckets/:name", entry["route"])
+		assert.Equal(t, "alice", entry["user_id"])
+	}
+	assert.Equal(t, "request completed", got[1][KeyMessage])
+	assert.Equal(t, "WARNING", got[1][KeySeverity])
+	httpRequest := got[1]["httpRequest"].(map[string]interface{})
+	assert.Equal(t, float64(http.StatusNotFound), httpRequest["status"])
+	assert.Equal(t, "/api/v1/buckets/b", httpRequest["requestUrl"])
+
+	// Missing and malformed IDs are replaced
+	assert.Len(t, send("").Header().Get(HeaderRequestID), 36)
+	generated := send("bad id\n").Header().Get(HeaderRequestID)
+	assert.Len(t, generated, 36)
+	assert.Len(t, send(strings.Repeat("x", 200)).Header().Get(HeaderRequestID), 36)
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderRequestID is the request and response header carrying the request ID
+const HeaderRequestID = echo.HeaderXRequestID
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// Middleware assigns each request an ID, taken from a well-formed
+// X-Request-ID header or generated, and echoes it in the response. It
+// attaches a logger carrying the request ID, method and route to the request
+// context and logs every completed request with a Cloud Logging httpRequest
+// field. It should run after tracing so that entries carry the trace ID.
+func Middleware(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			req := c.Request()
+
+			id := req.Header.Get(HeaderRequestID)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+				req.Header.Set(HeaderRequestID, id)
+			}
+			c.Response().Header().Set(HeaderRequestID, id)
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			reqLogger := logger.With(
+				slog.String("request_id", id),
+				slog.String("method", req.Method),
+				slog.String("route", route),
+			)
+			c.SetRequest(req.WithContext(NewContext(req.Context(), reqLogger)))
+
+			err := next(c)
+			if err != nil {
+				// Render the error now so that the logged status is the one sent
+				c.Error(err)
+			}
+
+			res := c.Response()
+			lvl := slog.LevelInfo
+			switch {
+			case res.Status >= http.StatusInternalServerError:
+				lvl = slog.LevelError
+			case res.Status >= http.StatusBadRequest:
+				lvl = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.Group("httpRequest",
+					slog.String("requestMethod", req.Method),
+					slog.String("requestUrl", req.RequestURI),
+					slog.Int("status", res.Status),
+					slog.String("responseSize", fmt.Sprint(res.Size)),
+					slog.String("userAgent", req.UserAgent()),
+					slog.String("remoteIp", c.RealIP()),
+					slog.String("latency", fmt.Sprintf("%.6fs", time.Since(start).Seconds())),
+				),
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+			}
+			ctx := c.Request().Context()
+			FromContext(ctx).LogAttrs(ctx, lvl, "request completed", attrs...)
+			return err
+		}
+	}
+}
+
+// With adds attributes to the request logger of c, e.g. the authenticated
+// user, so that every later entry for the request carries them
+func With(c echo.Context, attrs ...any) {
+	req := c.Request()
+	ctx := req.Context()
+	c.SetRequest(req.WithContext(NewContext(ctx, FromContext(ctx).With(attrs...))))
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to
+// reuse: non-empty, bounded and printable ASCII
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
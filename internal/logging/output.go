@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// unlimitedSizeMB stands in for "no size limit"; lumberjack treats 0 as its
+// 100 MB default
+const unlimitedSizeMB = 1 << 30
+
+// FileOptions configures the rotated log file
+type FileOptions struct {
+	// Path of the active log file
+	Path string
+	// MaxSizeMB is the size at which the file is rotated (0 disables size rotation)
+	MaxSizeMB int
+	// RotateEvery rotates the file at this interval (0 disables age rotation)
+	RotateEvery time.Duration
+	// MaxBackups is the number of rotated files kept (0 keeps all)
+	MaxBackups int
+	// MaxAgeDays is how long rotated files are kept (0 keeps them forever)
+	MaxAgeDays int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// Output is where the server's logs are written: stdout and, optionally, a
+// log file rotated by size and age
+type Output struct {
+	io.Writer
+	file    *lumberjack.Logger
+	written atomic.Bool // set by writes since the last timed rotation
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewOutput creates an output writing to stdout and, if opts.Path is set, to
+// a rotated log file
+func NewOutput(opts FileOptions) (*Output, error) {
+	out := &Output{Writer: os.Stdout}
+	if opts.Path == "" {
+		return out, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	maxSize := opts.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = unlimitedSizeMB
+	}
+	out.file = &lumberjack.Logger{
+		Filename:   opts.Path,
+		MaxSize:    maxSize,
+		MaxBackups: opts.MaxBackups,
+		MaxAge:     opts.MaxAgeDays,
+		Compress:   opts.Compress,
+	}
+	out.Writer = io.MultiWriter(os.Stdout, fileWriter{out})
+
+	if opts.RotateEvery > 0 {
+		out.stop = make(chan struct{})
+		go out.rotateEvery(opts.RotateEvery)
+	}
+	return out, nil
+}
+
+// fileWriter writes to the log file, noting that it has new entries
+type fileWriter struct{ o *Output }
+
+func (w fileWriter) Write(p []byte) (int, error) {
+	w.o.written.Store(true)
+	return w.o.file.Write(p)
+}
+
+// rotateEvery rotates the log file at a fixed interval until Close, skipping
+// intervals without new entries
+func (o *Output) rotateEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !o.written.Swap(false) {
+				continue
+			}
+			if err := o.file.Rotate(); err != nil {
+				slog.Error("Failed to rotate log file", "error", err)
+			}
+		case <-o.stop:
+			return
+		}
+	}
+}
+
+// Reopen closes the log file so that the next entry reopens it by name. Call
+// it after an external tool such as logrotate has moved the file away.
+func (o *Output) Reopen() error {
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
+
+// Rotate moves the current log file aside as a backup and starts a new one
+func (o *Output) Rotate() error {
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Rotate()
+}
+
+// Close stops age-based rotation and closes the log file
+func (o *Output) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	o.once.Do(func() {
+		if o.stop != nil {
+			close(o.stop)
+		}
+	})
+	return o.file.Close()
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputRotatesAndReopens(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	out, err := NewOutput(FileOptions{Path: path, MaxBackups: 2})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = out.Close() })
+
+	_, err = out.Write([]byte("first\n"))
+	require.NoError(t, err)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Rotation keeps the old entries in a timestamped backup
+	require.NoError(t, out.Rotate())
+	_, err = out.Write([]byte("second\n"))
+	require.NoError(t, err)
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	data, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(data))
+
+	// After an external tool moves the file away, Reopen starts a new one
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, out.Reopen())
+	_, err = out.Write([]byte("third\n"))
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "third\n", string(data))
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+}
+
+func TestOutputRotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	out, err := NewOutput(FileOptions{Path: filepath.Join(dir, "app.log"), RotateEvery: 20 * time.Millisecond})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = out.Close() })
+
+	_, err = out.Write([]byte("entry\n"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		backups, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		return len(backups) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Intervals without new entries do not create empty backups
+	time.Sleep(100 * time.Millisecond)
+	backups, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestOutputWithoutFile(t *testing.T) {
+	out, err := NewOutput(FileOptions{})
+	require.NoError(t, err)
+	assert.NoError(t, out.Reopen())
+	assert.NoError(t, out.Rotate())
+	assert.NoError(t, out.Close())
+}
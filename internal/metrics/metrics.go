@@ -0,0 +1,201 @@
+// Package metrics exposes Prometheus metrics for HTTP requests, GCP API
+// calls, authentication failures and build information.
+package metrics
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+)
+
+const namespace = "gcp_automation_api"
+
+// GCP services reported in the service label
+const (
+	ServiceResourceManager = "resourcemanager"
+	ServiceStorage         = "storage"
+	ServiceRun             = "run"
+	ServiceLogging         = "logging"
+)
+
+// Registry holds every metric exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"method", "route", "status"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	gcpCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_calls_total",
+		Help:      "GCP API calls by service and method.",
+	}, []string{"service", "method"})
+
+	gcpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_call_duration_seconds",
+		Help:      "GCP API call latency by service and method.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"service", "method"})
+
+	gcpErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_call_errors_total",
+		Help:      "Failed GCP API calls by service, method and canonical error code.",
+	}, []string{"service", "method", "code"})
+
+	gcpRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gcp_api_call_retries_total",
+		Help:      "Retried GCP API calls by service, method and the canonical code of the failed attempt.",
+	}, []string{"service", "method", "code"})
+
+	policyDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "policy_decisions_total",
+		Help:      "Policies that matched a request, by policy name and effect.",
+	}, []string{"policy", "effect"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Rejected authentication and authorization attempts by reason.",
+	}, []string{"reason"})
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build information; the value is always 1.",
+	}, []string{"version", "revision", "goversion"})
+)
+
+func init() {
+	Registry.MustRegister(
+		httpRequests, httpDuration, httpInFlight,
+		gcpCalls, gcpDuration, gcpErrors, gcpRetries,
+		policyDecisions, authFailures, buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// SetBuildInfo publishes the server version. The VCS revision is read from
+// the binary's build information when available.
+func SetBuildInfo(version string) {
+	revision := "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				revision = setting.Value
+			}
+		}
+	}
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(version, revision, runtime.Version()).Set(1)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware records the count, latency and in-flight number of HTTP
+// requests. Routes are labelled with their template (e.g.
+// /api/v1/buckets/:name) to keep cardinality bounded.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			httpInFlight.Inc()
+			defer httpInFlight.Dec()
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Render the error now so that the counted status is the one sent
+				c.Error(err)
+			}
+			status := c.Response().Status
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+			httpRequests.WithLabelValues(labels...).Inc()
+			httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// ObserveGCPCall starts timing a GCP API call; call the returned function
+// with the call's error when it completes:
+//
+//	done := metrics.ObserveGCPCall(metrics.ServiceStorage, "buckets.get")
+//	attrs, err := bucket.Attrs(ctx)
+//	done(err)
+func ObserveGCPCall(service, method string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		gcpCalls.WithLabelValues(service, method).Inc()
+		gcpDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			gcpErrors.WithLabelValues(service, method, ErrorCode(err)).Inc()
+		}
+	}
+}
+
+// RecordGCPRetry counts a GCP API call retried after failing with err
+func RecordGCPRetry(service, method string, err error) {
+	gcpRetries.WithLabelValues(service, method, ErrorCode(err)).Inc()
+}
+
+// RecordPolicyDecision counts a policy that matched a request
+func RecordPolicyDecision(policy, effect string) {
+	policyDecisions.WithLabelValues(policy, effect).Inc()
+}
+
+// Authentication failure reasons
+const (
+	AuthReasonMissingToken  = "missing_token"
+	AuthReasonInvalidToken  = "invalid_token"
+	AuthReasonExpiredToken  = "expired_token"
+	AuthReasonNoGCPIdentity = "no_gcp_identity"
+	AuthReasonForbidden     = "forbidden"
+)
+
+// RecordAuthFailure counts a rejected authentication or authorization
+// attempt
+func RecordAuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// ErrorCode returns the canonical (gRPC) code name of a GCP error, mapping
+// REST status codes onto their gRPC equivalents
+func ErrorCode(err error) string {
+	return gcperrors.Code(err).String()
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMiddlewareLabelsRequestsByRouteTemplate(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware())
+	e.GET("/api/v1/buckets/:name", func(c echo.Context) error {
+		if c.Param("name") == "missing" {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/metrics", Handler())
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/api/v1/buckets/:name", "404"))
+	for _, name := range []string{"a", "b", "missing"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/buckets/"+name, nil))
+	}
+
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/api/v1/buckets/:name", "404")))
+	assert.GreaterOrEqual(t, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/api/v1/buckets/:name", "200")), 2.0)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `gcp_automation_api_http_request_duration_seconds_bucket{method="GET",route="/api/v1/buckets/:name",status="200"`)
+	assert.Contains(t, rec.Body.String(), "gcp_automation_api_http_requests_in_flight 1")
+}
+
+func TestObserveGCPCallCountsErrorsByCode(t *testing.T) {
+	ObserveGCPCall(ServiceStorage, "buckets.get")(nil)
+	ObserveGCPCall(ServiceStorage, "buckets.get")(fmt.Errorf("failed to get bucket: %w", storage.ErrBucketNotExist))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(gcpCalls.WithLabelValues(ServiceStorage, "buckets.get")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(gcpErrors.WithLabelValues(ServiceStorage, "buckets.get", "NotFound")))
+
+	RecordGCPRetry(ServiceStorage, "buckets.get", status.Error(codes.Unavailable, "try again"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(gcpRetries.WithLabelValues(ServiceStorage, "buckets.get", "Unavailable")))
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := map[string]error{
+		"OK":                nil,
+		"PermissionDenied":  &googleapi.Error{Code: http.StatusForbidden},
+		"ResourceExhausted": fmt.Errorf("wrapped: %w", &googleapi.Error{Code: http.StatusTooManyRequests}),
+		"Unavailable":       status.Error(codes.Unavailable, "try again"),
+		"Unknown":           fmt.Errorf("boom"),
+	}
+	for want, err := range tests {
+		assert.Equal(t, want, ErrorCode(err), want)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	SetBuildInfo("1.2.3")
+
+	rec := httptest.NewRecorder()
+	Handler()(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/metrics", nil), rec))
+	line := ""
+	for _, l := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(l, "gcp_automation_api_build_info{") {
+			line = l
+		}
+	}
+	assert.Contains(t, line, `version="1.2.3"`)
+	assert.True(t, strings.HasSuffix(line, " 1"), line)
+}
@@ -2,29 +2,36 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
-	"google.golang.org/api/idtoken"
+	"google.golang.org/grpc/codes"
 
 	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/identity"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 )
 
 // AuthMiddleware provides JWT authentication middleware
 type AuthMiddleware struct {
-	config *config.Config
+	config    *config.Config
+	providers *identity.Registry
 }
 
-// NewAuthMiddleware creates a new authentication middleware instance
-func NewAuthMiddleware(cfg *config.Config) *AuthMiddleware {
+// NewAuthMiddleware creates a new authentication middleware instance.
+// providers is shared with the AuthService, e.g. its IdentityProviders(), so
+// that the server keeps a single set of provider discovery and key caches.
+func NewAuthMiddleware(cfg *config.Config, providers *identity.Registry) *AuthMiddleware {
 	return &AuthMiddleware{
-		config: cfg,
+		config:    cfg,
+		providers: providers,
 	}
 }
 
@@ -37,11 +44,8 @@ func (am *AuthMiddleware) JWTMiddleware() echo.MiddlewareFunc {
 			return &models.JWTClaims{}
 		},
 		ErrorHandler: func(c echo.Context, err error) error {
-			return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "unauthorized",
-				Message: "Invalid or missing JWT token",
-				Code:    http.StatusUnauthorized,
-			})
+			metrics.RecordAuthFailure(authFailureReason(err))
+			return gcperrors.New(codes.Unauthenticated, "invalid or missing JWT token")
 		},
 		SuccessHandler: func(c echo.Context) {
 			// Extract user information from JWT claims and add to context
@@ -49,43 +53,26 @@ func (am *AuthMiddleware) JWTMiddleware() echo.MiddlewareFunc {
 			if claims, ok := token.Claims.(*models.JWTClaims); ok && token.Valid {
 				c.Set("user_id", claims.UserID)
 				c.Set("user_email", claims.Email)
+				c.Set("user_email_verified", claims.EmailVerified != nil && *claims.EmailVerified)
 				c.Set("user_name", claims.Name)
+				c.Set("user_provider", claims.Provider)
+				c.Set("user_groups", claims.Groups)
+				logging.With(c, "user_id", claims.UserID, "user_email", claims.Email)
 			}
 		},
 	})
 }
 
-// GenerateJWT generates a new JWT token with user information
-func (am *AuthMiddleware) GenerateJWT(userID, email, name, picture, googleSub string) (string, error) {
-	// Set token expiration
-	expirationTime := time.Now().Add(time.Duration(am.config.JWTExpirationHours) * time.Hour)
-
-	// Create claims
-	claims := &models.JWTClaims{
-		UserID:    userID,
-		Email:     email,
-		Name:      name,
-		Picture:   picture,
-		GoogleSub: googleSub,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "gcp-automation-api",
-			Subject:   userID,
-		},
-	}
-
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Generate signed token string
-	tokenString, err := token.SignedString([]byte(am.config.JWTSecret))
-	if err != nil {
-		return "", fmt.Errorf("failed to sign JWT token: %w", err)
+// authFailureReason classifies a JWT middleware error for metrics
+func authFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return metrics.AuthReasonExpiredToken
+	case errors.Is(err, echojwt.ErrJWTMissing):
+		return metrics.AuthReasonMissingToken
+	default:
+		return metrics.AuthReasonInvalidToken
 	}
-
-	return tokenString, nil
 }
 
 // ValidateJWT validates a JWT token and returns the claims
@@ -116,51 +103,18 @@ func (am *AuthMiddleware) ValidateJWT(tokenString string) (*models.JWTClaims, er
 
 // ValidateGoogleIDToken validates a Google ID token and extracts user information
 func (am *AuthMiddleware) ValidateGoogleIDToken(ctx context.Context, idToken string) (*models.GoogleUserInfo, error) {
-	if !am.config.EnableGoogleAuth {
-		return nil, fmt.Errorf("Google authentication is disabled")
-	}
-
-	// Validate the Google ID token
-	payload, err := idtoken.Validate(ctx, idToken, am.config.GoogleClientID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to validate Google ID token: %w", err)
-	}
-
-	// Safely extract claims with type assertions
-	email, ok := payload.Claims["email"].(string)
+	provider, ok := am.providers.Get(identity.GoogleProviderName)
 	if !ok {
-		return nil, fmt.Errorf("missing or invalid 'email' claim in Google ID token")
-	}
-	emailVerified, ok := payload.Claims["email_verified"].(bool)
-	if !ok {
-		return nil, fmt.Errorf("missing or invalid 'email_verified' claim in Google ID token")
-	}
-
-	// Optional fields - use safe extraction with fallback to empty string
-	name, _ := payload.Claims["name"].(string)
-	givenName, _ := payload.Claims["given_name"].(string)
-	familyName, _ := payload.Claims["family_name"].(string)
-	picture, _ := payload.Claims["picture"].(string)
-	locale, _ := payload.Claims["locale"].(string)
-
-	// Extract user information from payload
-	userInfo := &models.GoogleUserInfo{
-		Sub:           payload.Subject,
-		Email:         email,
-		EmailVerified: emailVerified,
-		Name:          name,
-		GivenName:     givenName,
-		FamilyName:    familyName,
-		Picture:       picture,
-		Locale:        locale,
+		return nil, fmt.Errorf("Google authentication is disabled")
 	}
 
-	// Verify email is verified
-	if !userInfo.EmailVerified {
-		return nil, fmt.Errorf("Google account email not verified")
-	}
+	return provider.Verify(ctx, idToken)
+}
 
-	return userInfo, nil
+// ValidateIDToken validates an ID token from any configured identity provider,
+// selecting the provider by the token's issuer
+func (am *AuthMiddleware) ValidateIDToken(ctx context.Context, idToken string) (*models.GoogleUserInfo, error) {
+	return am.providers.Verify(ctx, idToken)
 }
 
 // RequireAuth is a convenience function that returns the JWT middleware
@@ -182,6 +136,21 @@ func GetUserFromContext(c echo.Context) (userID, email, name string) {
 	return userID, email, name
 }
 
+// GetUserEmailVerifiedFromContext reports whether the identity provider
+// verified the caller's email
+func GetUserEmailVerifiedFromContext(c echo.Context) bool {
+	verified, _ := c.Get("user_email_verified").(bool)
+	return verified
+}
+
+// GetUserGroupsFromContext returns the groups embedded in the caller's JWT
+func GetUserGroupsFromContext(c echo.Context) []string {
+	if groups, ok := c.Get("user_groups").([]string); ok {
+		return groups
+	}
+	return nil
+}
+
 // SkipAuth returns a middleware that skips authentication for specific paths
 func SkipAuth(paths ...string) echo.MiddlewareFunc {
 	return echojwt.WithConfig(echojwt.Config{
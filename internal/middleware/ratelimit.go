@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+)
+
+// Rate limit response headers
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// rateLimitSweepInterval is how often idle buckets are discarded
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter applies token-bucket limits per caller, route group and
+// read/write class
+type RateLimiter struct {
+	mu        sync.Mutex
+	limits    *config.RateLimitConfig
+	buckets   map[rateLimitKey]*rate.Limiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// rateLimitKey identifies one token bucket
+type rateLimitKey struct {
+	caller string
+	group  string
+	write  bool
+}
+
+// NewRateLimiter creates a rate limiter enforcing limits
+func NewRateLimiter(limits *config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		limits:  limits,
+		buckets: make(map[rateLimitKey]*rate.Limiter),
+		now:     time.Now,
+	}
+}
+
+// Middleware rejects requests beyond the caller's limit with 429 Too Many
+// Requests. Callers are identified by JWT subject, so it runs after
+// authentication; UnauthenticatedMiddleware limits the requests that fail it.
+// Every response carries RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset; rejected ones also carry Retry-After.
+func (rl *RateLimiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := rl.key(c, rateLimitCaller(c))
+			allowed, tokens, limit := rl.take(key)
+			if err := setRateLimitHeaders(c, key, allowed, tokens, limit); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// UnauthenticatedMiddleware limits requests that fail authentication per
+// client IP, so it runs before authentication. Each failed request takes a
+// token from the bucket of its IP; once that is empty, every request from
+// the IP is rejected with 429 Too Many Requests until it refills.
+func (rl *RateLimiter) UnauthenticatedMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := rl.key(c, "ip:"+c.RealIP())
+			if tokens, limit := rl.peek(key); tokens < 1 {
+				return setRateLimitHeaders(c, key, false, tokens, limit)
+			}
+
+			err := next(c)
+			if userID, _, _ := GetUserFromContext(c); userID == "" && gcperrors.Is(err, codes.Unauthenticated) {
+				allowed, tokens, limit := rl.take(key)
+				_ = setRateLimitHeaders(c, key, allowed, tokens, limit)
+			}
+			return err
+		}
+	}
+}
+
+// key returns the bucket of caller for the request's route group and class
+func (rl *RateLimiter) key(c echo.Context, caller string) rateLimitKey {
+	return rateLimitKey{
+		caller: caller,
+		group:  routeGroup(c.Path()),
+		write:  isWrite(c.Request().Method),
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit headers of a response and returns
+// the error for a request that was not allowed
+func setRateLimitHeaders(c echo.Context, key rateLimitKey, allowed bool, tokens float64, limit config.TokenBucket) error {
+	header := c.Response().Header()
+	header.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Burst))
+	header.Set(HeaderRateLimitRemaining, strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+	header.Set(HeaderRateLimitReset, strconv.Itoa(secondsUntil(float64(limit.Burst)-tokens, limit.PerSecond)))
+
+	if allowed {
+		return nil
+	}
+	retryAfter := secondsUntil(1-tokens, limit.PerSecond)
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	return gcperrors.New(codes.ResourceExhausted, "too many %s requests to %s; retry after %ds", requestClass(key.write), key.group, retryAfter)
+}
+
+// SetLimits replaces the limits enforced, e.g. after a configuration reload.
+// Buckets whose limits changed restart full.
+func (rl *RateLimiter) SetLimits(limits *config.RateLimitConfig) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limits = limits
+}
+
+// take consumes a token from the bucket for key and returns whether one was
+// available, the tokens left and the bucket's limit
+func (rl *RateLimiter) take(key rateLimitKey) (bool, float64, config.TokenBucket) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	limiter, limit := rl.bucket(key, now)
+	allowed := limiter.AllowN(now, 1)
+	return allowed, limiter.TokensAt(now), limit
+}
+
+// peek returns the tokens left in the bucket for key without consuming one,
+// and the bucket's limit
+func (rl *RateLimiter) peek(key rateLimitKey) (float64, config.TokenBucket) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	limiter, limit := rl.bucket(key, now)
+	return limiter.TokensAt(now), limit
+}
+
+// bucket returns the bucket for key, creating it full if it does not exist
+// or its limits changed. rl.mu must be held.
+func (rl *RateLimiter) bucket(key rateLimitKey, now time.Time) (*rate.Limiter, config.TokenBucket) {
+	limit := rl.limits.For(key.group, key.write)
+	rl.sweep(now)
+
+	limiter, ok := rl.buckets[key]
+	if !ok || limiter.Limit() != rate.Limit(limit.PerSecond) || limiter.Burst() != limit.Burst {
+		limiter = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.Burst)
+		rl.buckets[key] = limiter
+	}
+	return limiter, limit
+}
+
+// sweep drops buckets that have refilled completely; a new bucket behaves
+// identically
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+
+	for key, limiter := range rl.buckets {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// rateLimitCaller identifies the caller by JWT subject, falling back to the
+// client IP
+func rateLimitCaller(c echo.Context) string {
+	if userID, _, _ := GetUserFromContext(c); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.RealIP()
+}
+
+// routeGroup returns the first path segment under /api/v1 of the matched
+// route, e.g. "buckets" for /api/v1/buckets/:name
+func routeGroup(route string) string {
+	route = strings.TrimPrefix(strings.TrimPrefix(route, "/api/v1"), "/")
+	group, _, _ := strings.Cut(route, "/")
+	return group
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func requestClass(write bool) string {
+	if write {
+		return "write"
+	}
+	return "read"
+}
+
+// secondsUntil returns how many whole seconds it takes to refill tokens
+func secondsUntil(tokens, perSecond float64) int {
+	if tokens <= 0 {
+		return 0
+	}
+	return int(math.Ceil(tokens / perSecond))
+}
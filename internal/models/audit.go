@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit outcomes
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry records a single mutating API call
+type AuditEntry struct {
+	Timestamp    time.Time       `json:"timestamp" example:"2025-09-20T10:00:00Z"`
+	RequestID    string          `json:"request_id" example:"3f8a2c1e-5b7d-4e9f-a1b2-c3d4e5f6a7b8"`
+	UserID       string          `json:"user_id" example:"123456789"`
+	Email        string          `json:"email" example:"jane@example.com"`
+	Method       string          `json:"method" example:"DELETE"`
+	Path         string          `json:"path" example:"/api/v1/buckets/my-data-bucket"`
+	ResourceType string          `json:"resource_type" example:"buckets"`
+	Resource     string          `json:"resource" example:"my-data-bucket"`
+	Body         json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+	Status       int             `json:"status" example:"200"`
+	Outcome      string          `json:"outcome" example:"success"` // "success" or "failure"
+	Error        string          `json:"error,omitempty" example:""`
+	LatencyMS    int64           `json:"latency_ms" example:"412"`
+}
@@ -2,7 +2,8 @@ package models
 
 import "github.com/golang-jwt/jwt/v5"
 
-// GoogleUserInfo represents user information from Google OAuth
+// GoogleUserInfo represents user information from Google OAuth or another
+// configured OIDC identity provider
 type GoogleUserInfo struct {
 	Sub           string `json:"sub"`
 	Email         string `json:"email"`
@@ -12,6 +13,8 @@ type GoogleUserInfo struct {
 	FamilyName    string `json:"family_name"`
 	Picture       string `json:"picture"`
 	Locale        string `json:"locale"`
+	HostedDomain  string `json:"hd,omitempty"`
+	Provider      string `json:"provider,omitempty"`
 }
 
 // LoginRequest represents a login request with Google ID token
@@ -25,6 +28,7 @@ type LoginResponse struct {
 	TokenType   string         `json:"token_type"`
 	ExpiresIn   int            `json:"expires_in"`
 	UserInfo    GoogleUserInfo `json:"user_info"`
+	Groups      []string       `json:"groups,omitempty"`
 }
 
 // OAuthTokenResponse represents the OAuth2 token exchange response from Google
@@ -37,10 +41,14 @@ type OAuthTokenResponse struct {
 
 // JWTClaims represents the JWT claims structure
 type JWTClaims struct {
-	UserID    string `json:"user_id"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	Picture   string `json:"picture,omitempty"`
-	GoogleSub string `json:"google_sub,omitempty"`
+	UserID        string   `json:"user_id"`
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified,omitempty"` // nil in tokens issued before the claim existed
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture,omitempty"`
+	GoogleSub     string   `json:"google_sub,omitempty"`
+	Provider      string   `json:"idp,omitempty"`
+	HostedDomain  string   `json:"hd,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -86,6 +86,20 @@ type ObjectResponse struct {
 	SelfLink     string            `json:"self_link"`
 }
 
+// ObjectListRequest represents a request to list one page of a bucket's objects
+type ObjectListRequest struct {
+	Bucket    string `json:"bucket" example:"my-data-bucket-123"`
+	Prefix    string `json:"prefix,omitempty" example:"reports/"`
+	PageSize  int    `json:"page_size" example:"100"`
+	PageToken string `json:"page_token" example:""`
+}
+
+// ObjectListResponse represents one page of a bucket's objects
+type ObjectListResponse struct {
+	Objects       []*ObjectResponse `json:"objects"`
+	NextPageToken string            `json:"next_page_token,omitempty" example:"abc123"`
+}
+
 // LifecyclePolicy represents a bucket lifecycle policy
 type LifecyclePolicy struct {
 	Rules []LifecycleRule `json:"rules"`
@@ -11,10 +11,21 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message"`
 	Code    int    `json:"code"`
+	// PolicyDecisions lists the policies that matched a request denied by
+	// policy
+	PolicyDecisions []PolicyDecision `json:"policy_decisions,omitempty"`
+	// LabelViolations lists the labels of a request rejected by its label
+	// schema
+	LabelViolations []LabelViolation `json:"label_violations,omitempty"`
 }
 
 // SuccessResponse represents a generic success response
 type SuccessResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	// PolicyDecisions lists the warn and mutate policies that matched the
+	// request
+	PolicyDecisions []PolicyDecision `json:"policy_decisions,omitempty"`
+	// LabelWarnings lists deprecated labels set by the request
+	LabelWarnings []LabelViolation `json:"label_warnings,omitempty"`
 }
@@ -0,0 +1,38 @@
+package models
+
+// DryRunResponse describes what a mutating request would do. It is returned
+// instead of performing the request when ?dry_run=true is set.
+type DryRunResponse struct {
+	Operation string `json:"operation" example:"CreateBucket"`
+	Resource  string `json:"resource" example:"buckets/my-data-bucket"`
+	// Request is the call that would be made to GCP
+	Request GCPRequest `json:"request"`
+	// Diff lists the fields that would change, comparing the resource's
+	// current state with its state afterwards
+	Diff []FieldChange `json:"diff"`
+	// Checks lists the live checks run against GCP
+	Checks []DryRunCheck `json:"checks"`
+}
+
+// GCPRequest is a GCP REST API call
+type GCPRequest struct {
+	Method string      `json:"method" example:"POST"`
+	URL    string      `json:"url" example:"https://storage.googleapis.com/storage/v1/b?project=my-project"`
+	Body   interface{} `json:"body,omitempty" swaggertype:"object"`
+}
+
+// FieldChange is a field whose value would change; a missing current value
+// means the field would be added, a missing proposed value that it would be
+// removed
+type FieldChange struct {
+	Field    string      `json:"field" example:"labels.environment"`
+	Current  interface{} `json:"current,omitempty" swaggertype:"string" example:"staging"`
+	Proposed interface{} `json:"proposed,omitempty" swaggertype:"string" example:"production"`
+}
+
+// DryRunCheck is the result of a check made during a dry run
+type DryRunCheck struct {
+	Name    string `json:"name" example:"name_available"`
+	Status  string `json:"status" example:"passed"` // "passed" or "skipped"
+	Message string `json:"message,omitempty" example:"Bucket name my-data-bucket is available"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// LabelSchema lists the rules for the labels of one resource type
+type LabelSchema struct {
+	ResourceType string         `json:"resource_type" example:"bucket"` // "bucket" or "project"
+	Keys         []LabelKeyRule `json:"keys"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	UpdatedBy    string         `json:"updated_by,omitempty" example:"admin@example.com"`
+}
+
+// LabelSchemaRequest represents a request to create or replace a label schema
+type LabelSchemaRequest struct {
+	Keys []LabelKeyRule `json:"keys" validate:"required,dive"`
+}
+
+// LabelKeyRule constrains the values of one label key
+type LabelKeyRule struct {
+	Key         string `json:"key" validate:"required,label_key" example:"environment"`
+	Description string `json:"description,omitempty" example:"Deployment environment"`
+	Required    bool   `json:"required,omitempty" example:"true"`
+	// AllowedValues, if not empty, lists the values the label may have
+	AllowedValues []string `json:"allowed_values,omitempty" validate:"omitempty,dive,label_value" example:"dev,staging,prod"`
+	// Pattern is a regular expression that values must match in full
+	Pattern string `json:"pattern,omitempty" example:"^[a-z]+-[0-9]{4}$"`
+	// Default is added to new resources that do not set the label
+	Default    string `json:"default,omitempty" validate:"omitempty,label_value" example:"dev"`
+	Deprecated bool   `json:"deprecated,omitempty" example:"false"`
+	// ReplacedBy names the key to use instead of a deprecated one
+	ReplacedBy string `json:"replaced_by,omitempty" validate:"omitempty,label_key" example:"env"`
+}
+
+// LabelViolation is a label that does not follow its resource type's schema
+type LabelViolation struct {
+	Key     string `json:"key" example:"environment"`
+	Value   string `json:"value,omitempty" example:"qa"`
+	Reason  string `json:"reason" example:"not_allowed"` // "missing", "not_allowed", "pattern_mismatch" or "deprecated"
+	Message string `json:"message" example:"label environment must be one of dev, staging, prod"`
+}
+
+// LabelComplianceReport lists the existing resources of a type whose labels
+// do not follow its schema
+type LabelComplianceReport struct {
+	ResourceType string                 `json:"resource_type" example:"bucket"`
+	Checked      int                    `json:"checked" example:"42"`
+	NonCompliant []NonCompliantResource `json:"non_compliant"`
+}
+
+// NonCompliantResource is a resource whose labels do not follow the schema
+type NonCompliantResource struct {
+	Name       string            `json:"name" example:"my-data-bucket"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Violations []LabelViolation  `json:"violations"`
+}
@@ -1,9 +1,12 @@
 // Package models contains all data models for the GCP Automation API
 //
 // This package is organized by domain:
+// - audit.go: Audit log models
 // - auth.go: Authentication and authorization models
 // - bucket.go: Google Cloud Storage bucket models
 // - common.go: Common models and response types
 // - folder.go: Google Cloud folder models
+// - labels.go: Label schema models
+// - policy.go: Policy decision models
 // - project.go: Google Cloud project models
 package models
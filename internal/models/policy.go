@@ -0,0 +1,10 @@
+package models
+
+// PolicyDecision is the outcome of a policy that matched a request
+type PolicyDecision struct {
+	Policy  string `json:"policy" example:"buckets-in-us"`
+	Effect  string `json:"effect" example:"deny"` // "deny", "warn" or "mutate"
+	Message string `json:"message,omitempty" example:"Buckets must be created in a US location"`
+	// Changes lists the fields a mutate policy set on the request
+	Changes []FieldChange `json:"changes,omitempty"`
+}
@@ -0,0 +1,534 @@
+// Package policy evaluates guardrail policies, written as CEL expressions in
+// YAML files, against create requests and the caller making them. A policy
+// can deny a request, let it through with a warning, or mutate it, e.g. to
+// inject default labels.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// Resource types policies apply to
+const (
+	ResourceBucket  = "bucket"
+	ResourceProject = "project"
+	ResourceFolder  = "folder"
+)
+
+// Effects of a matching policy
+const (
+	EffectDeny   = "deny"
+	EffectWarn   = "warn"
+	EffectMutate = "mutate"
+)
+
+// Policy is a rule as written in a policy file
+type Policy struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Resources lists the resource types the policy applies to; empty means
+	// all of them
+	Resources []string `yaml:"resources"`
+	Effect    string   `yaml:"effect"`
+	// Condition is a CEL expression that must evaluate to true for the
+	// policy to match; it may only be omitted by mutate policies
+	Condition string `yaml:"condition"`
+	Message   string `yaml:"message"`
+	// Set maps request fields (e.g. labels.cost_center) to the CEL
+	// expressions giving their new values, for mutate policies
+	Set map[string]string `yaml:"set"`
+}
+
+// policyFile is the on-disk layout of a policy file
+type policyFile struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// Caller is the authenticated caller of a request
+type Caller struct {
+	UserID string
+	Email  string
+	Name   string
+	Groups []string
+}
+
+// DeniedError is returned for requests denied by policy. It is classified
+// as FAILED_PRECONDITION, like requests GCP organization policies reject.
+type DeniedError struct {
+	// Decisions lists every policy that matched the request, including
+	// those that did not deny it
+	Decisions []models.PolicyDecision
+}
+
+// Error lists the messages of the denying policies
+func (e *DeniedError) Error() string {
+	var messages []string
+	for _, decision := range e.Decisions {
+		if decision.Effect == EffectDeny {
+			messages = append(messages, fmt.Sprintf("%s: %s", decision.Policy, decision.Message))
+		}
+	}
+	return "request denied by policy: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the error's classification
+func (e *DeniedError) Unwrap() error {
+	return gcperrors.New(codes.FailedPrecondition, "request denied by policy")
+}
+
+// rule is a policy with its expressions compiled
+type rule struct {
+	Policy
+	condition cel.Program
+	set       []assignment
+}
+
+// assignment sets a request field to the value of an expression
+type assignment struct {
+	field string
+	value cel.Program
+}
+
+// Engine evaluates the policies loaded from a set of files. A nil Engine
+// has no policies.
+type Engine struct {
+	rules atomic.Pointer[[]*rule]
+}
+
+// Load reads the policy files and directories at paths; directories
+// contribute their .yaml and .yml files in name order. Policies are
+// evaluated in the order they are loaded.
+func Load(paths []string) (*Engine, error) {
+	e := &Engine{}
+	if err := e.Reload(paths); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload replaces the engine's policies with those at paths. The current
+// policies are kept if any of the new ones is invalid.
+func (e *Engine) Reload(paths []string) error {
+	files, err := expand(paths)
+	if err != nil {
+		return err
+	}
+
+	env, err := newEnv()
+	if err != nil {
+		return err
+	}
+	rules := []*rule{}
+	seen := make(map[string]bool)
+	for _, path := range files {
+		// #nosec G304 - path is supplied by the operator via POLICY_FILES
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read policy file: %w", err)
+		}
+		var file policyFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to parse policy file %s: %w", path, err)
+		}
+		for i, p := range file.Policies {
+			if p.Name == "" {
+				return fmt.Errorf("policy file %s: policy %d: name is required", path, i)
+			}
+			if seen[p.Name] {
+				return fmt.Errorf("policy file %s: duplicate policy %q", path, p.Name)
+			}
+			seen[p.Name] = true
+			r, err := compile(env, p)
+			if err != nil {
+				return fmt.Errorf("policy file %s: policy %q: %w", path, p.Name, err)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	e.rules.Store(&rules)
+	return nil
+}
+
+// Len returns the number of policies loaded
+func (e *Engine) Len() int {
+	if e == nil || e.rules.Load() == nil {
+		return 0
+	}
+	return len(*e.rules.Load())
+}
+
+// expand replaces the directories among paths with the policy files in them
+func expand(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy file: %w", err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy directory: %w", err)
+		}
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// newEnv declares the variables policy expressions can use: the request's
+// fields, named as in its JSON form; the caller; and the resource type. The
+// CEL string extensions (split, lowerAscii, ...) are available too.
+func newEnv() (*cel.Env, error) {
+	env, err := cel.NewEnv(
+		ext.Strings(),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("caller", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("resource", cel.StringType),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create policy environment: %w", err)
+	}
+	return env, nil
+}
+
+// compile validates p and compiles its expressions
+func compile(env *cel.Env, p Policy) (*rule, error) {
+	for _, resource := range p.Resources {
+		switch resource {
+		case ResourceBucket, ResourceProject, ResourceFolder:
+		default:
+			return nil, fmt.Errorf("invalid resource %q: must be %q, %q or %q", resource, ResourceBucket, ResourceProject, ResourceFolder)
+		}
+	}
+
+	r := &rule{Policy: p}
+	switch p.Effect {
+	case EffectDeny, EffectWarn:
+		if p.Condition == "" {
+			return nil, fmt.Errorf("condition is required")
+		}
+		if len(p.Set) > 0 {
+			return nil, fmt.Errorf("set is only allowed with effect %q", EffectMutate)
+		}
+	case EffectMutate:
+		if len(p.Set) == 0 {
+			return nil, fmt.Errorf("set is required with effect %q", EffectMutate)
+		}
+	default:
+		return nil, fmt.Errorf("invalid effect %q: must be %q, %q or %q", p.Effect, EffectDeny, EffectWarn, EffectMutate)
+	}
+
+	if p.Condition != "" {
+		ast, issues := env.Compile(p.Condition)
+		if issues.Err() != nil {
+			return nil, fmt.Errorf("invalid condition: %w", issues.Err())
+		}
+		if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+			return nil, fmt.Errorf("invalid condition: must be a boolean, not %s", t)
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %w", err)
+		}
+		r.condition = program
+	}
+
+	fields := make([]string, 0, len(p.Set))
+	for field := range p.Set {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		ast, issues := env.Compile(p.Set[field])
+		if issues.Err() != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", field, issues.Err())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", field, err)
+		}
+		r.set = append(r.set, assignment{field: field, value: program})
+	}
+	return r, nil
+}
+
+// Evaluate applies the policies for resource to req, a pointer to the
+// request, made by caller. Mutate policies are applied first, in order, and
+// their changes are written to req; deny and warn policies then see the
+// mutated request. It returns the decisions of the matching policies, and a
+// *DeniedError if any of them denies the request. A policy that fails to
+// evaluate fails the request with INTERNAL, so a broken guardrail is never
+// skipped.
+func (e *Engine) Evaluate(resource string, req interface{}, caller Caller) ([]models.PolicyDecision, error) {
+	if e.Len() == 0 {
+		return nil, nil
+	}
+
+	request, err := toValue(reflect.ValueOf(req))
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]interface{}{
+		"request":  request,
+		"caller":   callerValue(caller),
+		"resource": resource,
+	}
+
+	var decisions []models.PolicyDecision
+	mutated := false
+	for _, phase := range []bool{true, false} {
+		for _, r := range *e.rules.Load() {
+			if (r.Effect == EffectMutate) != phase || !r.appliesTo(resource) {
+				continue
+			}
+			matched, err := r.matches(vars)
+			if err != nil {
+				return decisions, err
+			}
+			if !matched {
+				continue
+			}
+
+			decision := models.PolicyDecision{Policy: r.Name, Effect: r.Effect, Message: r.Message}
+			if r.Effect == EffectMutate {
+				changes, err := r.apply(vars)
+				if err != nil {
+					return decisions, err
+				}
+				if len(changes) == 0 {
+					continue
+				}
+				decision.Changes = changes
+				mutated = true
+			}
+			metrics.RecordPolicyDecision(r.Name, r.Effect)
+			decisions = append(decisions, decision)
+		}
+	}
+
+	if mutated {
+		if err := fromValue(vars["request"], req); err != nil {
+			return decisions, err
+		}
+	}
+	for _, decision := range decisions {
+		if decision.Effect == EffectDeny {
+			return decisions, &DeniedError{Decisions: decisions}
+		}
+	}
+	return decisions, nil
+}
+
+// appliesTo reports whether the policy applies to resource
+func (r *rule) appliesTo(resource string) bool {
+	if len(r.Resources) == 0 {
+		return true
+	}
+	for _, candidate := range r.Resources {
+		if candidate == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// matches evaluates the policy's condition; a policy without one always
+// matches
+func (r *rule) matches(vars map[string]interface{}) (bool, error) {
+	if r.condition == nil {
+		return true, nil
+	}
+	out, _, err := r.condition.Eval(vars)
+	if err != nil {
+		return false, r.evalError(err)
+	}
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, r.evalError(fmt.Errorf("condition returned %s, not a boolean", out.Type().TypeName()))
+	}
+	return matched, nil
+}
+
+// apply evaluates the policy's assignments and sets the fields of the
+// request in vars, returning the fields that changed
+func (r *rule) apply(vars map[string]interface{}) ([]models.FieldChange, error) {
+	request := vars["request"].(map[string]interface{})
+	var changes []models.FieldChange
+	for _, a := range r.set {
+		out, _, err := a.value.Eval(vars)
+		if err != nil {
+			return nil, r.evalError(fmt.Errorf("%s: %w", a.field, err))
+		}
+		native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+		if err != nil {
+			return nil, r.evalError(fmt.Errorf("%s: %w", a.field, err))
+		}
+		value := native.(*structpb.Value).AsInterface()
+
+		current, err := set(request, a.field, value)
+		if err != nil {
+			return nil, r.evalError(err)
+		}
+		if !reflect.DeepEqual(current, value) {
+			changes = append(changes, models.FieldChange{Field: a.field, Current: current, Proposed: value})
+		}
+	}
+	return changes, nil
+}
+
+// evalError reports a policy that failed to evaluate
+func (r *rule) evalError(err error) error {
+	return gcperrors.New(codes.Internal, "policy %q failed to evaluate: %w", r.Name, err)
+}
+
+// set sets the field at path (e.g. labels.env) in request to value,
+// creating intermediate objects as needed, and returns its previous value
+func set(request map[string]interface{}, path string, value interface{}) (interface{}, error) {
+	keys := strings.Split(path, ".")
+	object := request
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := object[key].(map[string]interface{})
+		if !ok {
+			if object[key] != nil {
+				return nil, fmt.Errorf("cannot set %s: %s is not an object", path, key)
+			}
+			next = map[string]interface{}{}
+			object[key] = next
+		}
+		object = next
+	}
+	key := keys[len(keys)-1]
+	current := object[key]
+	object[key] = value
+	return current, nil
+}
+
+// callerValue returns the caller as seen by policy expressions
+func callerValue(caller Caller) map[string]interface{} {
+	domain := ""
+	if at := strings.LastIndex(caller.Email, "@"); at >= 0 {
+		domain = strings.ToLower(caller.Email[at+1:])
+	}
+	groups := make([]interface{}, len(caller.Groups))
+	for i, group := range caller.Groups {
+		groups[i] = group
+	}
+	return map[string]interface{}{
+		"user_id": caller.UserID,
+		"email":   caller.Email,
+		"name":    caller.Name,
+		"domain":  domain,
+		"groups":  groups,
+	}
+}
+
+// toValue converts v to maps, lists and scalars keyed by JSON field names.
+// Unlike its JSON form, fields that are empty are kept (nil maps become
+// empty ones), so that expressions can test them without has().
+func toValue(v reflect.Value) (interface{}, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return toValue(v.Elem())
+	case reflect.Struct:
+		object := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			value, err := toValue(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+		}
+		return object, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported request field of type %s", v.Type())
+		}
+		object := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := toValue(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			object[iter.Key().String()] = value
+		}
+		return object, nil
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			value, err := toValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return nil, fmt.Errorf("unsupported request field of type %s", v.Type())
+}
+
+// fromValue writes the mutated request back to req
+func fromValue(request interface{}, req interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode mutated request: %w", err)
+	}
+	if err := json.Unmarshal(data, req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return gcperrors.New(codes.Internal, "policy set %s to a %s, not a %s", typeErr.Field, typeErr.Value, typeErr.Type)
+		}
+		return fmt.Errorf("failed to decode mutated request: %w", err)
+	}
+	return nil
+}
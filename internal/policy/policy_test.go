@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+const testPolicies = `
+policies:
+  - name: default-storage-class
+    resources: [bucket]
+    effect: mutate
+    condition: request.storage_class == ""
+    message: Buckets default to STANDARD storage
+    set:
+      storage_class: '"STANDARD"'
+  - name: owner-label
+    resources: [bucket, project]
+    effect: mutate
+    condition: "!('owner' in request.labels)"
+    set:
+      labels.owner: caller.email.split("@")[0]
+  - name: buckets-in-us
+    resources: [bucket]
+    effect: deny
+    condition: "!request.location.startsWith('us')"
+    message: Buckets must be created in a US location
+  - name: no-archive-for-contractors
+    resources: [bucket]
+    effect: deny
+    condition: request.storage_class == "ARCHIVE" && caller.domain != "example.com"
+    message: Only employees may create archive buckets
+  - name: versioning
+    resources: [bucket]
+    effect: warn
+    condition: "!request.versioning"
+    message: Buckets should enable versioning
+`
+
+func loadTestEngine(t *testing.T, content string) *Engine {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	engine, err := Load([]string{path})
+	require.NoError(t, err)
+	return engine
+}
+
+func TestEvaluateMutatesAndWarns(t *testing.T) {
+	engine := loadTestEngine(t, testPolicies)
+	caller := Caller{Email: "jane@example.com"}
+
+	req := &models.BucketRequest{Name: "my-bucket", Location: "us-central1"}
+	decisions, err := engine.Evaluate(ResourceBucket, req, caller)
+	require.NoError(t, err)
+
+	assert.Equal(t, "STANDARD", req.StorageClass)
+	assert.Equal(t, map[string]string{"owner": "jane"}, req.Labels)
+	assert.Equal(t, []models.PolicyDecision{
+		{
+			Policy:  "default-storage-class",
+			Effect:  EffectMutate,
+			Message: "Buckets default to STANDARD storage",
+			Changes: []models.FieldChange{{Field: "storage_class", Current: "", Proposed: "STANDARD"}},
+		},
+		{
+			Policy:  "owner-label",
+			Effect:  EffectMutate,
+			Changes: []models.FieldChange{{Field: "labels.owner", Proposed: "jane"}},
+		},
+		{Policy: "versioning", Effect: EffectWarn, Message: "Buckets should enable versioning"},
+	}, decisions)
+
+	// Policies for other resources do not apply, and mutations that change
+	// nothing are not reported
+	folder := &models.FolderRequest{DisplayName: "Team", ParentID: "123", ParentType: "organization"}
+	decisions, err = engine.Evaluate(ResourceFolder, folder, caller)
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+
+	project := &models.ProjectRequest{ProjectID: "my-project", Labels: map[string]string{"owner": "ops"}}
+	decisions, err = engine.Evaluate(ResourceProject, project, caller)
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+	assert.Equal(t, map[string]string{"owner": "ops"}, project.Labels)
+}
+
+func TestEvaluateDenies(t *testing.T) {
+	engine := loadTestEngine(t, testPolicies)
+
+	req := &models.BucketRequest{Name: "my-bucket", Location: "europe-west1", StorageClass: "ARCHIVE", Versioning: true}
+	decisions, err := engine.Evaluate(ResourceBucket, req, Caller{Email: "bob@contractor.io"})
+
+	var denied *DeniedError
+	require.True(t, errors.As(err, &denied))
+	assert.Equal(t, decisions, denied.Decisions)
+	assert.Equal(t, codes.FailedPrecondition, gcperrors.Code(err))
+	assert.EqualError(t, err, "request denied by policy: buckets-in-us: Buckets must be created in a US location; "+
+		"no-archive-for-contractors: Only employees may create archive buckets")
+	require.Len(t, decisions, 3)
+	assert.Equal(t, "owner-label", decisions[0].Policy)
+}
+
+func TestEvaluateFailsClosed(t *testing.T) {
+	engine := loadTestEngine(t, `
+policies:
+  - name: needs-env
+    effect: deny
+    condition: request.labels.env == "prod"
+`)
+
+	_, err := engine.Evaluate(ResourceBucket, &models.BucketRequest{Name: "my-bucket"}, Caller{})
+	assert.Equal(t, codes.Internal, gcperrors.Code(err))
+	assert.Contains(t, err.Error(), `policy "needs-env" failed to evaluate`)
+}
+
+func TestEvaluateWithoutPolicies(t *testing.T) {
+	var engine *Engine
+	req := &models.BucketRequest{Name: "my-bucket"}
+	decisions, err := engine.Evaluate(ResourceBucket, req, Caller{})
+	require.NoError(t, err)
+	assert.Empty(t, decisions)
+	assert.Nil(t, req.Labels)
+}
+
+func TestLoadRejectsInvalidPolicies(t *testing.T) {
+	tests := map[string]string{
+		"name is required":              "policies:\n  - effect: deny\n    condition: 'true'\n",
+		`invalid effect "block"`:        "policies:\n  - name: p\n    effect: block\n    condition: 'true'\n",
+		"condition is required":         "policies:\n  - name: p\n    effect: warn\n",
+		"set is required":               "policies:\n  - name: p\n    effect: mutate\n",
+		"must be a boolean, not string": "policies:\n  - name: p\n    effect: deny\n    condition: request.name + 'x'\n",
+		"invalid condition":             "policies:\n  - name: p\n    effect: deny\n    condition: request.name ==\n",
+		`invalid resource "vm"`:         "policies:\n  - name: p\n    resources: [vm]\n    effect: deny\n    condition: 'true'\n",
+		`duplicate policy "p"`:          "policies:\n  - name: p\n    effect: deny\n    condition: 'true'\n  - name: p\n    effect: deny\n    condition: 'false'\n",
+	}
+	for want, content := range tests {
+		path := filepath.Join(t.TempDir(), "policies.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := Load([]string{path})
+		require.Error(t, err, want)
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestReloadKeepsPoliciesOnError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(testPolicies), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a policy"), 0o600))
+
+	engine, err := Load([]string{dir})
+	require.NoError(t, err)
+	assert.Equal(t, 5, engine.Len())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"), []byte("policies:\n  - name: p\n    effect: nope\n"), 0o600))
+	assert.Error(t, engine.Reload([]string{dir}))
+	assert.Equal(t, 5, engine.Len())
+}
@@ -0,0 +1,332 @@
+// Package profiles manages named CLI profiles (contexts) and the credentials
+// stored for each of them, in the style of a kubeconfig file.
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+)
+
+// DefaultProfileName is the profile used when no profiles file exists yet
+const DefaultProfileName = "default"
+
+// ErrProfileNotFound is returned when a named profile does not exist
+var ErrProfileNotFound = errors.New("profile not found")
+
+// ErrNoCredentials is returned when a profile has no stored credentials
+var ErrNoCredentials = errors.New("no credentials stored for profile")
+
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,62}$`)
+
+// Profile is a named deployment of the API and the OAuth client used to log in to it
+type Profile struct {
+	Name     string `yaml:"name"`
+	Server   string `yaml:"server,omitempty"`
+	Provider string `yaml:"provider,omitempty"`
+	ClientID string `yaml:"client-id,omitempty"`
+	// ClientSecret belongs to ClientID; the profiles file is only readable
+	// by its owner
+	ClientSecret string `yaml:"client-secret,omitempty"`
+}
+
+// File is the on-disk profiles file
+type File struct {
+	CurrentContext string    `yaml:"current-context"`
+	Profiles       []Profile `yaml:"profiles"`
+}
+
+// StoredCredentials represents the stored authentication data of a profile
+type StoredCredentials struct {
+	AccessToken  string                `json:"access_token"`
+	TokenType    string                `json:"token_type"`
+	ExpiresAt    time.Time             `json:"expires_at"`
+	UserInfo     models.GoogleUserInfo `json:"user_info"`
+	Groups       []string              `json:"groups,omitempty"`
+	RefreshToken string                `json:"refresh_token,omitempty"`
+}
+
+// Expired reports whether the access token has expired
+func (c *StoredCredentials) Expired() bool {
+	return time.Now().After(c.ExpiresAt)
+}
+
+// ValidateName checks that a profile name is safe to use in file names
+func ValidateName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// Get returns the named profile
+func (f *File) Get(name string) (*Profile, error) {
+	for i := range f.Profiles {
+		if f.Profiles[i].Name == name {
+			return &f.Profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+}
+
+// Set adds a profile or replaces the one with the same name
+func (f *File) Set(p Profile) error {
+	if err := ValidateName(p.Name); err != nil {
+		return err
+	}
+	for i := range f.Profiles {
+		if f.Profiles[i].Name == p.Name {
+			f.Profiles[i] = p
+			return nil
+		}
+	}
+	f.Profiles = append(f.Profiles, p)
+	sort.Slice(f.Profiles, func(i, j int) bool { return f.Profiles[i].Name < f.Profiles[j].Name })
+	return nil
+}
+
+// Resolve returns the profile to use: name if given, otherwise the current context
+func (f *File) Resolve(name string) (*Profile, error) {
+	if name == "" {
+		name = f.CurrentContext
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no current profile set. Run 'auth-cli use-context <name>' or pass --profile")
+	}
+	return f.Get(name)
+}
+
+// Delete removes a profile, clearing the current context if it pointed at it
+func (f *File) Delete(name string) error {
+	for i := range f.Profiles {
+		if f.Profiles[i].Name == name {
+			f.Profiles = append(f.Profiles[:i], f.Profiles[i+1:]...)
+			if f.CurrentContext == name {
+				f.CurrentContext = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+}
+
+// Store reads and writes the profiles file under the CLI's credentials
+// directory and keeps per-profile credentials in a SecretStore
+type Store struct {
+	dir             string
+	profilesFile    string
+	credentialsFile string
+	secrets         SecretStore
+}
+
+// NewStore creates a store rooted at the configured credentials directory
+// in the user's home directory, using the configured secret store backend
+func NewStore(cfg *config.Config) (*Store, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory
+		homeDir = "."
+	}
+	dir := filepath.Join(homeDir, cfg.CredentialsDir)
+
+	secrets, err := NewSecretStore(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreAt(dir, cfg.ProfilesFile, cfg.CredentialsFile, secrets), nil
+}
+
+// NewStoreAt creates a store rooted at dir
+func NewStoreAt(dir, profilesFile, credentialsFile string, secrets SecretStore) *Store {
+	return &Store{dir: dir, profilesFile: profilesFile, credentialsFile: credentialsFile, secrets: secrets}
+}
+
+// Backend returns the name of the secret store holding credentials
+func (s *Store) Backend() string {
+	return s.secrets.Name()
+}
+
+// Dir returns the directory holding the profiles file and credentials
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Load reads the profiles file. When it does not exist yet, a file with a
+// single "default" profile is returned so existing single-profile setups
+// keep working.
+func (s *Store) Load() (*File, error) {
+	path, err := s.path(s.profilesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G304 - path is validated by s.path to stay within the store directory
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &File{
+			CurrentContext: DefaultProfileName,
+			Profiles:       []Profile{{Name: DefaultProfileName}},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles file %s: %w", path, err)
+	}
+	for _, p := range f.Profiles {
+		if err := ValidateName(p.Name); err != nil {
+			return nil, fmt.Errorf("profiles file %s: %w", path, err)
+		}
+	}
+
+	return &f, nil
+}
+
+// Save writes the profiles file
+func (s *Store) Save(f *File) error {
+	path, err := s.path(s.profilesFile)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadCredentials reads the credentials stored for a profile. Plaintext
+// credentials files written by earlier versions are moved into the secret
+// store and removed on first use.
+func (s *Store) LoadCredentials(profile string) (*StoredCredentials, error) {
+	if err := ValidateName(profile); err != nil {
+		return nil, err
+	}
+
+	data, err := s.secrets.Get(profile)
+	if errors.Is(err, ErrSecretNotFound) {
+		return s.migratePlaintext(profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds StoredCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse stored credentials: %w", err)
+	}
+	return &creds, nil
+}
+
+// SaveCredentials writes the credentials for a profile
+func (s *Store) SaveCredentials(profile string, creds *StoredCredentials) error {
+	if err := ValidateName(profile); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	return s.secrets.Set(profile, data)
+}
+
+// DeleteCredentials removes the credentials stored for a profile
+func (s *Store) DeleteCredentials(profile string) error {
+	if err := ValidateName(profile); err != nil {
+		return err
+	}
+	if err := s.secrets.Delete(profile); err != nil {
+		return err
+	}
+
+	path, err := s.plaintextPath(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// migratePlaintext moves a plaintext credentials file into the secret store
+func (s *Store) migratePlaintext(profile string) (*StoredCredentials, error) {
+	path, err := s.plaintextPath(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G304 - path is validated by plaintextPath to stay within the store directory
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNoCredentials, profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds StoredCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", path, err)
+	}
+
+	if err := s.SaveCredentials(profile, &creds); err != nil {
+		return nil, fmt.Errorf("failed to migrate credentials to %s: %w", s.Backend(), err)
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("credentials migrated but plaintext file %s could not be removed: %w", path, err)
+	}
+
+	return &creds, nil
+}
+
+// plaintextPath returns the plaintext credentials file used by earlier
+// versions: the original credentials file for the default profile and
+// profiles/<name>.json for the others
+func (s *Store) plaintextPath(profile string) (string, error) {
+	if profile == DefaultProfileName {
+		return s.path(s.credentialsFile)
+	}
+	return s.path(filepath.Join("profiles", profile+".json"))
+}
+
+// path resolves name inside the store directory, rejecting path traversal
+func (s *Store) path(name string) (string, error) {
+	absDir, err := filepath.Abs(s.dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve credentials directory: %w", err)
+	}
+	absPath, err := filepath.Abs(filepath.Join(s.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute relative path: %w", err)
+	}
+	if strings.HasPrefix(rel, ".."+string(os.PathSeparator)) || rel == ".." || filepath.IsAbs(rel) {
+		return "", fmt.Errorf("path is outside allowed credentials directory")
+	}
+
+	return absPath, nil
+}
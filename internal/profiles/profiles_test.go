@@ -0,0 +1,120 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWithoutProfilesFileReturnsDefault(t *testing.T) {
+	store := newTestStore(t.TempDir())
+
+	file, err := store.Load()
+	require.NoError(t, err)
+
+	profile, err := file.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfileName, profile.Name)
+}
+
+func TestProfilesRoundTrip(t *testing.T) {
+	store := newTestStore(t.TempDir())
+
+	file, err := store.Load()
+	require.NoError(t, err)
+	require.NoError(t, file.Set(Profile{Name: "prod", Server: "https://api.example.com", ClientID: "prod-client", ClientSecret: "prod-secret"}))
+	require.NoError(t, file.Set(Profile{Name: "dev", Server: "http://localhost:8080"}))
+	file.CurrentContext = "prod"
+	require.NoError(t, store.Save(file))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "dev", "prod"}, profileNames(loaded))
+
+	current, err := loaded.Resolve("")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-client", current.ClientID)
+	assert.Equal(t, "prod-secret", current.ClientSecret)
+
+	override, err := loaded.Resolve("dev")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080", override.Server)
+
+	require.NoError(t, loaded.Delete("prod"))
+	assert.Empty(t, loaded.CurrentContext)
+	_, err = loaded.Resolve("")
+	assert.Error(t, err)
+
+	_, err = loaded.Get("staging")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+}
+
+func TestCredentialsArePerProfile(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(dir)
+
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, store.SaveCredentials("default", &StoredCredentials{AccessToken: "default-token", ExpiresAt: expires}))
+	require.NoError(t, store.SaveCredentials("prod", &StoredCredentials{AccessToken: "prod-token", ExpiresAt: expires}))
+
+	// Nothing is written in plaintext
+	_, err := os.Stat(filepath.Join(dir, "credentials.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	prod, err := store.LoadCredentials("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-token", prod.AccessToken)
+	assert.False(t, prod.Expired())
+
+	require.NoError(t, store.DeleteCredentials("prod"))
+	_, err = store.LoadCredentials("prod")
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	def, err := store.LoadCredentials("default")
+	require.NoError(t, err)
+	assert.Equal(t, "default-token", def.AccessToken)
+}
+
+func TestPlaintextCredentialsAreMigrated(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore(dir)
+
+	legacy := filepath.Join(dir, "profiles", "dev.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(legacy), 0700))
+	require.NoError(t, os.WriteFile(legacy, []byte(`{"access_token":"legacy-token","token_type":"Bearer"}`), 0600))
+
+	creds, err := store.LoadCredentials("dev")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-token", creds.AccessToken)
+
+	_, err = os.Stat(legacy)
+	assert.True(t, os.IsNotExist(err), "plaintext file should be removed")
+
+	// Subsequent loads come from the secret store
+	creds, err = store.LoadCredentials("dev")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-token", creds.AccessToken)
+}
+
+func TestValidateNameRejectsTraversal(t *testing.T) {
+	for _, name := range []string{"", "../prod", "a/b", ".hidden"} {
+		assert.Error(t, ValidateName(name), name)
+	}
+	assert.NoError(t, ValidateName("prod-us_1.2"))
+}
+
+func profileNames(f *File) []string {
+	names := make([]string, 0, len(f.Profiles))
+	for _, p := range f.Profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func newTestStore(dir string) *Store {
+	return NewStoreAt(dir, "config.yaml", "credentials.json", NewEncryptedFileStore(filepath.Join(dir, "secrets"), ""))
+}
@@ -0,0 +1,290 @@
+package profiles
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/argon2"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// Credential store backends selectable with CREDENTIALS_BACKEND
+const (
+	BackendAuto    = "auto"
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+)
+
+// keyringService is the service name credentials are stored under in the OS keyring
+const keyringService = "gcp-automation-api"
+
+// ErrSecretNotFound is returned by a SecretStore when no secret exists for a key
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretStore persists opaque secrets, such as serialized credentials, by key
+type SecretStore interface {
+	Name() string
+	Get(key string) ([]byte, error)
+	Set(key string, data []byte) error
+	Delete(key string) error
+}
+
+// NewSecretStore selects the secret store backend from configuration. In
+// auto mode the OS keyring is used when it is reachable (Secret Service on
+// Linux, Keychain on macOS, Credential Manager on Windows) and the encrypted
+// file store otherwise.
+func NewSecretStore(cfg *config.Config, dir string) (SecretStore, error) {
+	switch cfg.CredentialsBackend {
+	case "", BackendAuto:
+		if KeyringAvailable() {
+			return NewKeyringStore(), nil
+		}
+		return NewEncryptedFileStore(filepath.Join(dir, "secrets"), cfg.CredentialsPassphrase), nil
+	case BackendKeyring:
+		if !KeyringAvailable() {
+			return nil, fmt.Errorf("CREDENTIALS_BACKEND=keyring but no OS keyring is available")
+		}
+		return NewKeyringStore(), nil
+	case BackendFile:
+		return NewEncryptedFileStore(filepath.Join(dir, "secrets"), cfg.CredentialsPassphrase), nil
+	default:
+		return nil, fmt.Errorf("unknown CREDENTIALS_BACKEND %q (expected %q, %q or %q)",
+			cfg.CredentialsBackend, BackendAuto, BackendKeyring, BackendFile)
+	}
+}
+
+// KeyringAvailable reports whether the OS keyring can be reached
+func KeyringAvailable() bool {
+	_, err := keyring.Get(keyringService, "__probe__")
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// KeyringStore stores secrets in the OS keyring
+type KeyringStore struct{}
+
+// NewKeyringStore creates a keyring-backed secret store
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{}
+}
+
+// Name returns the backend name
+func (s *KeyringStore) Name() string {
+	return BackendKeyring
+}
+
+// Get reads a secret from the keyring
+func (s *KeyringStore) Get(key string) ([]byte, error) {
+	secret, err := keyring.Get(keyringService, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from keyring: %w", err)
+	}
+	return []byte(secret), nil
+}
+
+// Set writes a secret to the keyring
+func (s *KeyringStore) Set(key string, data []byte) error {
+	if err := keyring.Set(keyringService, key, string(data)); err != nil {
+		return fmt.Errorf("failed to write to keyring: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a secret from the keyring
+func (s *KeyringStore) Delete(key string) error {
+	if err := keyring.Delete(keyringService, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete from keyring: %w", err)
+	}
+	return nil
+}
+
+// Key sources recorded in encrypted files
+const (
+	keySourcePassphrase = "passphrase"
+	keySourceMachine    = "machine"
+)
+
+// Argon2id parameters used to derive file encryption keys
+const (
+	argonTime    = 1
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	argonKeyLen  = 32
+)
+
+// encryptedFile is the on-disk envelope of an encrypted secret
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	KeySource  string `json:"key_source"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileStore stores each secret in its own AES-256-GCM encrypted
+// file. The key is derived with Argon2id from a passphrase when one is
+// configured, or otherwise from the machine ID and user name. The machine
+// key keeps copied files unreadable on other machines but does not protect
+// against other processes running as the same user.
+type EncryptedFileStore struct {
+	dir        string
+	passphrase string
+}
+
+// NewEncryptedFileStore creates an encrypted file store in dir
+func NewEncryptedFileStore(dir, passphrase string) *EncryptedFileStore {
+	return &EncryptedFileStore{dir: dir, passphrase: passphrase}
+}
+
+// Name returns the backend name
+func (s *EncryptedFileStore) Name() string {
+	if s.passphrase != "" {
+		return BackendFile + " (passphrase)"
+	}
+	return BackendFile + " (machine key)"
+}
+
+// Get decrypts the secret stored for key
+func (s *EncryptedFileStore) Get(key string) ([]byte, error) {
+	// #nosec G304 - key is a validated profile name
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrSecretNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope encryptedFile
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted credentials: %w", err)
+	}
+	if envelope.KeySource == keySourcePassphrase && s.passphrase == "" {
+		return nil, fmt.Errorf("credentials are encrypted with a passphrase; set CREDENTIALS_PASSPHRASE")
+	}
+
+	secret, err := s.secret(envelope.KeySource)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(secret, envelope.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials (wrong passphrase or machine?)")
+	}
+	return plaintext, nil
+}
+
+// Set encrypts and writes the secret for key
+func (s *EncryptedFileStore) Set(key string, data []byte) error {
+	keySource := keySourceMachine
+	if s.passphrase != "" {
+		keySource = keySourcePassphrase
+	}
+	secret, err := s.secret(keySource)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(secret, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	envelope, err := json.MarshalIndent(encryptedFile{
+		Version:    1,
+		KDF:        "argon2id",
+		KeySource:  keySource,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, data, []byte(key)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(key), envelope, 0600)
+}
+
+// Delete removes the secret stored for key
+func (s *EncryptedFileStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *EncryptedFileStore) path(key string) string {
+	return filepath.Join(s.dir, key+".enc")
+}
+
+// secret returns the input keying material for a key source
+func (s *EncryptedFileStore) secret(keySource string) ([]byte, error) {
+	if keySource == keySourcePassphrase {
+		return []byte(s.passphrase), nil
+	}
+	return machineSecret()
+}
+
+// newGCM derives an AES-256 key from secret and salt and returns its GCM cipher
+func newGCM(secret, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(secret, salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// machineSecret identifies the current machine and user
+func machineSecret() ([]byte, error) {
+	var machineID string
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			machineID = strings.TrimSpace(string(data))
+			break
+		}
+	}
+	if machineID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine machine identity: %w", err)
+		}
+		machineID = hostname
+	}
+
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Uid + ":" + u.Username
+	}
+
+	return []byte("gcp-automation-api:" + machineID + ":" + username), nil
+}
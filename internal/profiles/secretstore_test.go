@@ -0,0 +1,83 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := t.TempDir()
+	secret := []byte(`{"access_token":"secret-token"}`)
+
+	tests := []struct {
+		name       string
+		passphrase string
+	}{
+		{name: "Machine key", passphrase: ""},
+		{name: "Passphrase", passphrase: "correct horse battery staple"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewEncryptedFileStore(dir, tt.passphrase)
+			require.NoError(t, store.Set("prod", secret))
+
+			raw, err := os.ReadFile(filepath.Join(dir, "prod.enc"))
+			require.NoError(t, err)
+			assert.NotContains(t, string(raw), "secret-token")
+
+			got, err := store.Get("prod")
+			require.NoError(t, err)
+			assert.Equal(t, secret, got)
+
+			require.NoError(t, store.Delete("prod"))
+			_, err = store.Get("prod")
+			assert.ErrorIs(t, err, ErrSecretNotFound)
+		})
+	}
+}
+
+func TestEncryptedFileStoreRejectsWrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, NewEncryptedFileStore(dir, "right").Set("prod", []byte("data")))
+
+	_, err := NewEncryptedFileStore(dir, "wrong").Get("prod")
+	assert.Error(t, err)
+
+	_, err = NewEncryptedFileStore(dir, "").Get("prod")
+	assert.ErrorContains(t, err, "CREDENTIALS_PASSPHRASE")
+}
+
+func TestEncryptedFileStoreBindsSecretToKey(t *testing.T) {
+	dir := t.TempDir()
+	store := NewEncryptedFileStore(dir, "pass")
+	require.NoError(t, store.Set("dev", []byte("dev-data")))
+
+	// A file copied to another profile's name must not decrypt
+	data, err := os.ReadFile(filepath.Join(dir, "dev.enc"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prod.enc"), data, 0600))
+
+	_, err = store.Get("prod")
+	assert.Error(t, err)
+}
+
+func TestKeyringStore(t *testing.T) {
+	keyring.MockInit()
+
+	store := NewKeyringStore()
+	require.NoError(t, store.Set("prod", []byte("data")))
+
+	got, err := store.Get("prod")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), got)
+
+	require.NoError(t, store.Delete("prod"))
+	_, err = store.Get("prod")
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+}
@@ -0,0 +1,156 @@
+// Package retry retries GCP calls that fail transiently (rate limiting,
+// unavailable backends, reads racing a create) with exponential backoff and
+// jitter. Retries are bounded by a budget shared by all the calls of one
+// operation, and calls that are not idempotent are only retried when the
+// failed attempt cannot have taken effect.
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
+)
+
+// Policy controls how failed calls are retried
+type Policy struct {
+	// MaxRetries is how many times the calls of one operation may be retried
+	// in total, unless Budgets has an entry for the operation; 0 disables
+	// retries
+	MaxRetries int
+	Budgets    map[string]int
+	// InitialBackoff is the longest wait before the first retry; it doubles
+	// with each retry up to MaxBackoff, and the actual wait is a random
+	// duration up to that bound
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultPolicy returns the policy used when none is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries:     3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// NewPolicy returns the policy configured by the GCP_RETRY_* settings
+func NewPolicy(cfg *config.Config) Policy {
+	return Policy{
+		MaxRetries:     cfg.GCPRetryMax,
+		Budgets:        cfg.GCPRetryBudgets,
+		InitialBackoff: time.Duration(cfg.GCPRetryInitialBackoffMS) * time.Millisecond,
+		MaxBackoff:     time.Duration(cfg.GCPRetryMaxBackoffMS) * time.Millisecond,
+	}
+}
+
+// Start returns the retry budget of one run of operation (e.g.
+// "CreateBucket")
+func (p Policy) Start(operation string) *Budget {
+	remaining, ok := p.Budgets[operation]
+	if !ok {
+		remaining = p.MaxRetries
+	}
+	return &Budget{policy: p, remaining: remaining}
+}
+
+// Budget tracks the retries left to the calls of one operation. It is not
+// safe for concurrent use.
+type Budget struct {
+	policy    Policy
+	remaining int
+	retries   int
+}
+
+// Call describes a GCP API call
+type Call struct {
+	// Service and Method label the call in metrics, as in
+	// metrics.ObserveGCPCall
+	Service string
+	Method  string
+	// Idempotent calls can be repeated safely, e.g. reads and deletes.
+	// Others, e.g. creates, are only retried when GCP rejected the attempt
+	// before acting on it.
+	Idempotent bool
+	// RetryNotFound retries NOT_FOUND errors, for reads of a resource that
+	// was just created and may not be visible yet
+	RetryNotFound bool
+	// Delete marks a delete: a retry failing with NOT_FOUND succeeds, as an
+	// earlier attempt whose response was lost may have deleted the resource
+	Delete bool
+}
+
+// Do runs fn, retrying it while it fails with a retryable error and the
+// budget allows. Each attempt is observed in metrics; each retry is counted,
+// logged and delayed by a jittered backoff. It returns the error of the last
+// attempt, or the context's error if ctx ends while waiting.
+func (b *Budget) Do(ctx context.Context, call Call, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		done := metrics.ObserveGCPCall(call.Service, call.Method)
+		err := fn(ctx)
+		done(err)
+		if call.Delete && attempt > 0 && gcperrors.Is(err, codes.NotFound) {
+			return nil
+		}
+		if err == nil || b.remaining <= 0 || ctx.Err() != nil || !Retryable(err, call) {
+			return err
+		}
+
+		b.remaining--
+		b.retries++
+		wait := b.backoff()
+		metrics.RecordGCPRetry(call.Service, call.Method, err)
+		logging.FromContext(ctx).Warn("Retrying GCP call",
+			"service", call.Service,
+			"method", call.Method,
+			"retry", b.retries,
+			"backoff", wait.String(),
+			"error", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// backoff returns a random wait up to the current exponential bound
+func (b *Budget) backoff() time.Duration {
+	bound := b.policy.InitialBackoff
+	for i := 1; i < b.retries && bound < b.policy.MaxBackoff; i++ {
+		bound *= 2
+	}
+	if bound > b.policy.MaxBackoff {
+		bound = b.policy.MaxBackoff
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return rand.N(bound) + 1
+}
+
+// Retryable reports whether call should be retried after failing with err
+func Retryable(err error, call Call) bool {
+	switch gcperrors.Code(err) {
+	case codes.ResourceExhausted:
+		// Rate limited requests are rejected before they are processed
+		return true
+	case codes.Unavailable, codes.Internal, codes.Aborted, codes.DeadlineExceeded:
+		// The attempt may have taken effect before failing
+		return call.Idempotent
+	case codes.NotFound:
+		return call.RetryNotFound
+	}
+	return false
+}
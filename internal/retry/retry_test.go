@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnavailable = &googleapi.Error{Code: http.StatusServiceUnavailable}
+	errRateLimited = &googleapi.Error{Code: http.StatusTooManyRequests}
+)
+
+func testPolicy() Policy {
+	return Policy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+}
+
+// failing returns a function that fails with errs in turn and then succeeds,
+// and a pointer to its number of calls
+func failing(errs ...error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	}, &calls
+}
+
+func TestDoRetriesTransientErrors(t *testing.T) {
+	fn, calls := failing(errUnavailable, errRateLimited)
+	err := testPolicy().Start("GetBucket").Do(context.Background(),
+		Call{Service: "storage", Method: "buckets.get", Idempotent: true}, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestDoOnlyRetriesRejectedNonIdempotentCalls(t *testing.T) {
+	create := Call{Service: "storage", Method: "buckets.insert"}
+
+	fn, calls := failing(errUnavailable)
+	assert.Equal(t, errUnavailable, testPolicy().Start("CreateBucket").Do(context.Background(), create, fn))
+	assert.Equal(t, 1, *calls, "the bucket may have been created")
+
+	fn, calls = failing(errRateLimited)
+	assert.NoError(t, testPolicy().Start("CreateBucket").Do(context.Background(), create, fn))
+	assert.Equal(t, 2, *calls)
+}
+
+func TestDoRetriesConnectionResetsOfIdempotentCalls(t *testing.T) {
+	reset := &url.Error{Op: "Get", URL: "https://storage.googleapis.com/storage/v1/b/logs", Err: syscall.ECONNRESET}
+
+	fn, calls := failing(reset, io.ErrUnexpectedEOF)
+	assert.NoError(t, testPolicy().Start("GetBucket").Do(context.Background(),
+		Call{Service: "storage", Method: "buckets.get", Idempotent: true}, fn))
+	assert.Equal(t, 3, *calls)
+
+	fn, calls = failing(reset)
+	assert.Equal(t, reset, testPolicy().Start("CreateBucket").Do(context.Background(),
+		Call{Service: "storage", Method: "buckets.insert"}, fn))
+	assert.Equal(t, 1, *calls, "the bucket may have been created")
+}
+
+func TestDoRetriesNotFoundOnlyWhenAsked(t *testing.T) {
+	get := Call{Service: "storage", Method: "buckets.get", Idempotent: true}
+
+	fn, calls := failing(storage.ErrBucketNotExist)
+	assert.ErrorIs(t, testPolicy().Start("GetBucket").Do(context.Background(), get, fn), storage.ErrBucketNotExist)
+	assert.Equal(t, 1, *calls)
+
+	get.RetryNotFound = true
+	fn, calls = failing(storage.ErrBucketNotExist, storage.ErrBucketNotExist)
+	assert.NoError(t, testPolicy().Start("CreateBucket").Do(context.Background(), get, fn))
+	assert.Equal(t, 3, *calls)
+}
+
+func TestDoTreatsNotFoundOnARetriedDeleteAsSuccess(t *testing.T) {
+	del := Call{Service: "storage", Method: "objects.delete", Idempotent: true, Delete: true}
+
+	fn, calls := failing(storage.ErrObjectNotExist)
+	assert.ErrorIs(t, testPolicy().Start("DeleteObject").Do(context.Background(), del, fn), storage.ErrObjectNotExist,
+		"the first attempt found nothing to delete")
+	assert.Equal(t, 1, *calls)
+
+	fn, calls = failing(errUnavailable, storage.ErrObjectNotExist)
+	assert.NoError(t, testPolicy().Start("DeleteObject").Do(context.Background(), del, fn),
+		"the first attempt may have deleted the object")
+	assert.Equal(t, 2, *calls)
+}
+
+func TestBudgetIsSharedByAnOperation(t *testing.T) {
+	policy := testPolicy()
+	policy.Budgets = map[string]int{"CreateBucket": 2}
+	budget := policy.Start("CreateBucket")
+	get := Call{Service: "storage", Method: "buckets.get", Idempotent: true}
+
+	fn, calls := failing(errUnavailable)
+	assert.NoError(t, budget.Do(context.Background(), get, fn))
+	assert.Equal(t, 2, *calls)
+
+	fn, calls = failing(errUnavailable, errUnavailable)
+	assert.Equal(t, errUnavailable, budget.Do(context.Background(), get, fn))
+	assert.Equal(t, 2, *calls, "only one retry was left")
+
+	policy.MaxRetries = 0
+	fn, calls = failing(errUnavailable)
+	assert.Equal(t, errUnavailable, policy.Start("GetBucket").Do(context.Background(), get, fn))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestDoStopsWhenContextEnds(t *testing.T) {
+	policy := Policy{MaxRetries: 3, InitialBackoff: time.Minute, MaxBackoff: time.Minute}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	fn, calls := failing(errUnavailable)
+	start := time.Now()
+	err := policy.Start("GetBucket").Do(ctx, Call{Service: "storage", Method: "buckets.get", Idempotent: true}, fn)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestRetryable(t *testing.T) {
+	read := Call{Idempotent: true}
+	assert.True(t, Retryable(status.Error(codes.Unavailable, "try again"), read))
+	assert.True(t, Retryable(&googleapi.Error{Code: http.StatusBadGateway}, read))
+	assert.False(t, Retryable(&googleapi.Error{Code: http.StatusForbidden}, read))
+	assert.False(t, Retryable(errors.New("boom"), read))
+	assert.False(t, Retryable(status.Error(codes.Aborted, "conflict"), Call{}))
+}
@@ -2,26 +2,37 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"google.golang.org/api/idtoken"
 
 	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/identity"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 )
 
+// ErrLoginRequired is returned when a token cannot be refreshed and the user
+// has to log in again
+var ErrLoginRequired = errors.New("token cannot be refreshed; please log in again")
+
 // AuthService handles authentication operations
 type AuthService struct {
-	config *config.Config
+	config    *config.Config
+	providers *identity.Registry
+	policy    *identity.LoginPolicy
 }
 
 // NewAuthService creates a new authentication service instance
 func NewAuthService(cfg *config.Config) *AuthService {
 	return &AuthService{
-		config: cfg,
+		config:    cfg,
+		providers: identity.NewRegistry(cfg),
+		policy:    identity.NewLoginPolicy(cfg),
 	}
 }
 
@@ -37,13 +48,42 @@ func (as *AuthService) LoginWithGoogle(ctx context.Context, googleIDToken string
 		return nil, fmt.Errorf("failed to validate Google ID token: %w", err)
 	}
 
+	return as.issueLoginResponse(ctx, userInfo)
+}
+
+// LoginWithIDToken authenticates a user with an ID token from any configured
+// identity provider and returns a JWT. The provider is selected by the
+// token's issuer.
+func (as *AuthService) LoginWithIDToken(ctx context.Context, rawIDToken string) (*models.LoginResponse, error) {
+	userInfo, err := as.providers.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate ID token: %w", err)
+	}
+
+	return as.issueLoginResponse(ctx, userInfo)
+}
+
+// issueLoginResponse applies the login policy and generates a JWT for an
+// authenticated user
+func (as *AuthService) issueLoginResponse(ctx context.Context, userInfo *models.GoogleUserInfo) (*models.LoginResponse, error) {
+	groups, err := as.policy.Evaluate(ctx, userInfo)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "Login rejected", "email", userInfo.Email, "error", err)
+		var policyErr *identity.PolicyError
+		if errors.As(err, &policyErr) {
+			metrics.RecordAuthFailure(policyErr.Reason)
+		}
+		return nil, err
+	}
+
 	// Generate JWT token for the user
-	jwtToken, err := as.generateJWT(userInfo)
+	jwtToken, err := as.generateJWT(userInfo, groups)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate JWT token: %w", err)
 	}
 
-	log.Printf("User %s (%s) authenticated successfully", userInfo.Name, userInfo.Email)
+	logging.FromContext(ctx).InfoContext(ctx, "User authenticated",
+		"name", userInfo.Name, "email", userInfo.Email, "provider", providerName(userInfo))
 
 	// Prepare response
 	response := &models.LoginResponse{
@@ -51,6 +91,7 @@ func (as *AuthService) LoginWithGoogle(ctx context.Context, googleIDToken string
 		TokenType:   "Bearer",
 		ExpiresIn:   as.config.JWTExpirationHours * 3600, // Convert hours to seconds
 		UserInfo:    *userInfo,
+		Groups:      groups,
 	}
 
 	return response, nil
@@ -83,8 +124,9 @@ func (as *AuthService) ValidateJWT(tokenString string) (*models.JWTClaims, error
 	return nil, fmt.Errorf("invalid token claims")
 }
 
-// GenerateTestJWT generates a JWT token for testing purposes (development only)
-func (as *AuthService) GenerateTestJWT(userID, email, name string) (string, error) {
+// GenerateTestJWT generates a JWT token for testing purposes (development
+// only), optionally carrying groups
+func (as *AuthService) GenerateTestJWT(userID, email, name string, groups ...string) (string, error) {
 	if as.config.IsProduction() {
 		return "", fmt.Errorf("test JWT generation is not allowed in production")
 	}
@@ -98,97 +140,83 @@ func (as *AuthService) GenerateTestJWT(userID, email, name string) (string, erro
 		Locale:        "en",
 	}
 
-	return as.generateJWT(userInfo)
+	return as.generateJWT(userInfo, groups)
 }
 
-// RefreshJWT generates a new JWT token using existing valid claims
+// RefreshJWT generates a new JWT token using existing valid claims. The login
+// policy is re-evaluated so that revoked users and changed group memberships
+// take effect on refresh.
 func (as *AuthService) RefreshJWT(claims *models.JWTClaims) (string, error) {
+	// Tokens issued before the email_verified claim existed do not say
+	// whether the email may be trusted, which only the provider can tell
+	if claims.EmailVerified == nil {
+		return "", fmt.Errorf("%w: the token does not record whether the email is verified", ErrLoginRequired)
+	}
+
 	// Create new user info from existing claims
 	userInfo := &models.GoogleUserInfo{
-		Sub:           claims.GoogleSub,
 		Email:         claims.Email,
 		Name:          claims.Name,
-		EmailVerified: true,
+		EmailVerified: *claims.EmailVerified,
 		Picture:       claims.Picture,
+		HostedDomain:  claims.HostedDomain,
+		Provider:      claims.Provider,
 	}
+	// Tokens issued before user IDs were namespaced hold the bare subject
+	userInfo.Sub = strings.TrimPrefix(claims.UserID, providerName(userInfo)+":")
 
-	return as.generateJWT(userInfo)
-}
-
-// validateGoogleIDToken validates a Google ID token and extracts user information
-func (as *AuthService) validateGoogleIDToken(ctx context.Context, idToken string) (*models.GoogleUserInfo, error) {
-	// Validate the Google ID token
-	payload, err := idtoken.Validate(ctx, idToken, as.config.GoogleClientID)
+	groups, err := as.policy.Evaluate(context.Background(), userInfo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate Google ID token: %w", err)
-	}
-
-	// Helper function to safely extract string from claims
-	getString := func(claims map[string]interface{}, key string) string {
-		if val, ok := claims[key]; ok {
-			if str, ok := val.(string); ok {
-				return str
-			}
-		}
-		return ""
-	}
-
-	// Helper function to safely extract bool from claims
-	getBool := func(claims map[string]interface{}, key string) bool {
-		if val, ok := claims[key]; ok {
-			if b, ok := val.(bool); ok {
-				return b
-			}
-		}
-		return false
+		return "", err
 	}
 
-	// Extract user information from payload
-	userInfo := &models.GoogleUserInfo{
-		Sub:           payload.Subject,
-		Email:         getString(payload.Claims, "email"),
-		EmailVerified: getBool(payload.Claims, "email_verified"),
-		Name:          getString(payload.Claims, "name"),
-		GivenName:     getString(payload.Claims, "given_name"),
-		FamilyName:    getString(payload.Claims, "family_name"),
-		Picture:       getString(payload.Claims, "picture"),
-		Locale:        getString(payload.Claims, "locale"),
-	}
-
-	// Verify required fields
-	if userInfo.Email == "" {
-		return nil, fmt.Errorf("email not found in Google ID token")
-	}
+	return as.generateJWT(userInfo, groups)
+}
 
-	if !userInfo.EmailVerified {
-		return nil, fmt.Errorf("Google account email not verified")
+// validateGoogleIDToken validates a Google ID token and extracts user information
+func (as *AuthService) validateGoogleIDToken(ctx context.Context, idToken string) (*models.GoogleUserInfo, error) {
+	if p, ok := as.providers.Get(identity.GoogleProviderName); ok {
+		return p.Verify(ctx, idToken)
 	}
-
-	return userInfo, nil
+	return identity.NewGoogleProvider(as.config.GoogleClientID, as.config.GoogleAudiences...).Verify(ctx, idToken)
 }
 
-// generateJWT generates a new JWT token with user information
-func (as *AuthService) generateJWT(userInfo *models.GoogleUserInfo) (string, error) {
+// generateJWT generates a new JWT token with user information and groups
+func (as *AuthService) generateJWT(userInfo *models.GoogleUserInfo, groups []string) (string, error) {
 	// Set token expiration
 	expirationTime := time.Now().Add(time.Duration(as.config.JWTExpirationHours) * time.Hour)
 
+	// Subjects are only unique within their identity provider, so the user
+	// ID that keys rate limits, audit records and idempotent responses is
+	// namespaced by provider
+	userID := providerName(userInfo) + ":" + userInfo.Sub
+	emailVerified := userInfo.EmailVerified
+
 	// Create claims
 	claims := &models.JWTClaims{
-		UserID:    userInfo.Sub,
-		Email:     userInfo.Email,
-		Name:      userInfo.Name,
-		Picture:   userInfo.Picture,
-		GoogleSub: userInfo.Sub,
+		UserID:        userID,
+		Email:         userInfo.Email,
+		EmailVerified: &emailVerified,
+		Name:          userInfo.Name,
+		Picture:       userInfo.Picture,
+		Provider:      userInfo.Provider,
+		HostedDomain:  userInfo.HostedDomain,
+		Groups:        groups,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "gcp-automation-api",
-			Subject:   userInfo.Sub,
+			Subject:   userID,
 			Audience:  []string{"gcp-automation-api"},
 		},
 	}
 
+	// Only Google subjects are recorded as google_sub
+	if providerName(userInfo) == identity.GoogleProviderName {
+		claims.GoogleSub = userInfo.Sub
+	}
+
 	// Create token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
@@ -209,6 +237,8 @@ func (as *AuthService) GetUserContext(claims *models.JWTClaims) map[string]inter
 		"name":       claims.Name,
 		"picture":    claims.Picture,
 		"google_sub": claims.GoogleSub,
+		"provider":   claims.Provider,
+		"groups":     claims.Groups,
 	}
 }
 
@@ -217,6 +247,25 @@ func (as *AuthService) IsTokenExpired(claims *models.JWTClaims) bool {
 	return time.Now().After(claims.ExpiresAt.Time)
 }
 
+// LoginPolicy returns the login authorization policy
+func (as *AuthService) LoginPolicy() *identity.LoginPolicy {
+	return as.policy
+}
+
+// IdentityProviders returns the registry of configured identity providers
+func (as *AuthService) IdentityProviders() *identity.Registry {
+	return as.providers
+}
+
+// providerName returns the identity provider of a user, treating tokens
+// issued before multi-provider support as Google
+func providerName(userInfo *models.GoogleUserInfo) string {
+	if userInfo.Provider == "" {
+		return identity.GoogleProviderName
+	}
+	return userInfo.Provider
+}
+
 // GetConfig returns the configuration for external access
 func (as *AuthService) GetConfig() *config.Config {
 	return as.config
@@ -9,10 +9,16 @@ import (
 	"cloud.google.com/go/logging/logadmin"
 	run "cloud.google.com/go/run/apiv2"
 	"cloud.google.com/go/run/apiv2/runpb"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
 
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/retry"
+	"github.com/stuartshay/gcp-automation-api/internal/tracing"
 	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
 )
 
@@ -22,6 +28,7 @@ type CloudRunService struct {
 	runClient      *run.ServicesClient
 	loggingClient  *logging.Client
 	logAdminClient *logadmin.Client
+	retry          retry.Policy
 }
 
 // CloudRunServiceInterface defines the interface for Cloud Run operations
@@ -46,6 +53,9 @@ func NewCloudRunService(ctx context.Context, projectID string, opts ...option.Cl
 		return nil, fmt.Errorf("failed to create Cloud Run client: %w", err)
 	}
 
+	// Calls are retried by the service's retry policy instead
+	runClient.CallOptions.GetService = nil
+
 	// Create logging client
 	loggingClient, err := logging.NewClient(ctx, projectID, opts...)
 	if err != nil {
@@ -66,18 +76,28 @@ func NewCloudRunService(ctx context.Context, projectID string, opts ...option.Cl
 		runClient:      runClient,
 		loggingClient:  loggingClient,
 		logAdminClient: logAdminClient,
+		retry:          retry.DefaultPolicy(),
 	}, nil
 }
 
+// SetRetryPolicy sets how the service retries failed GCP calls
+func (s *CloudRunService) SetRetryPolicy(policy retry.Policy) {
+	s.retry = policy
+}
+
 // ConfigureLogging configures logging for a Cloud Run service
-func (s *CloudRunService) ConfigureLogging(ctx context.Context, req *models.CloudRunLoggingConfigRequest) (*models.CloudRunLoggingConfigResponse, error) {
+func (s *CloudRunService) ConfigureLogging(ctx context.Context, req *models.CloudRunLoggingConfigRequest) (_ *models.CloudRunLoggingConfigResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CloudRunService.ConfigureLogging",
+		attribute.String("cloudrun.service", req.ServiceName), attribute.String("cloudrun.region", req.Region))
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if err := s.validateLoggingConfigRequest(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "validation failed: %w", err)
 	}
 
 	// Get service information to ensure it exists
-	_, err := s.GetServiceInfo(ctx, req.ServiceName, req.Region)
+	_, err = s.GetServiceInfo(ctx, req.ServiceName, req.Region)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service info: %w", err)
 	}
@@ -120,13 +140,17 @@ func (s *CloudRunService) ConfigureLogging(ctx context.Context, req *models.Clou
 }
 
 // GetLoggingConfig retrieves the current logging configuration for a service
-func (s *CloudRunService) GetLoggingConfig(ctx context.Context, serviceName, region string) (*models.CloudRunLoggingConfigResponse, error) {
+func (s *CloudRunService) GetLoggingConfig(ctx context.Context, serviceName, region string) (_ *models.CloudRunLoggingConfigResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CloudRunService.GetLoggingConfig",
+		attribute.String("cloudrun.service", serviceName), attribute.String("cloudrun.region", region))
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		return nil, fmt.Errorf("invalid service name: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err)
 	}
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		return nil, fmt.Errorf("invalid region: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err)
 	}
 
 	// Get service information
@@ -153,13 +177,17 @@ func (s *CloudRunService) GetLoggingConfig(ctx context.Context, serviceName, reg
 }
 
 // UpdateLoggingConfig updates the logging configuration for a service
-func (s *CloudRunService) UpdateLoggingConfig(ctx context.Context, serviceName, region string, req *models.CloudRunLoggingConfigUpdateRequest) (*models.CloudRunLoggingConfigResponse, error) {
+func (s *CloudRunService) UpdateLoggingConfig(ctx context.Context, serviceName, region string, req *models.CloudRunLoggingConfigUpdateRequest) (_ *models.CloudRunLoggingConfigResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CloudRunService.UpdateLoggingConfig",
+		attribute.String("cloudrun.service", serviceName), attribute.String("cloudrun.region", region))
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		return nil, fmt.Errorf("invalid service name: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err)
 	}
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		return nil, fmt.Errorf("invalid region: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err)
 	}
 
 	// Get current configuration
@@ -198,60 +226,69 @@ func (s *CloudRunService) UpdateLoggingConfig(ctx context.Context, serviceName,
 }
 
 // GetLogs retrieves logs for a Cloud Run service
-func (s *CloudRunService) GetLogs(ctx context.Context, req *models.CloudRunLogsRequest) (*models.CloudRunLogsResponse, error) {
+func (s *CloudRunService) GetLogs(ctx context.Context, req *models.CloudRunLogsRequest) (_ *models.CloudRunLogsResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CloudRunService.GetLogs",
+		attribute.String("cloudrun.service", req.ServiceName), attribute.String("cloudrun.region", req.Region))
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if err := gcp.ValidateCloudRunServiceName(req.ServiceName); err != nil {
-		return nil, fmt.Errorf("invalid service name: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err)
 	}
 	if err := gcp.ValidateCloudRunRegion(req.Region); err != nil {
-		return nil, fmt.Errorf("invalid region: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err)
 	}
 
 	// Build log filter
 	filter := s.buildLogFilter(req)
 
-	// Query logs
-	entries := []models.LogEntry{}
-	it := s.logAdminClient.Entries(ctx, logadmin.Filter(filter))
-
-	count := 0
+	// Query one page of logs, resuming from the caller's page token
 	pageSize := req.PageSize
 	if pageSize <= 0 || pageSize > 1000 {
 		pageSize = 100 // Default page size
 	}
 
-	for count < pageSize {
-		entry, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve logs: %w", err)
-		}
+	var page []*logging.Entry
+	var nextPageToken string
+	err = s.retry.Start("GetLogs").Do(ctx, retry.Call{Service: metrics.ServiceLogging, Method: "entries.list", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			page = nil
+			it := s.logAdminClient.Entries(ctx, logadmin.Filter(filter))
+			nextPageToken, err = iterator.NewPager(it, pageSize, req.PageToken).NextPage(&page)
+			return err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve logs: %w", err)
+	}
 
-		logEntry := s.convertLogEntry(entry)
-		entries = append(entries, logEntry)
-		count++
+	entries := make([]models.LogEntry, 0, len(page))
+	for _, entry := range page {
+		entries = append(entries, s.convertLogEntry(entry))
 	}
 
 	response := &models.CloudRunLogsResponse{
-		ServiceName: req.ServiceName,
-		Region:      req.Region,
-		Logs:        entries,
-		TotalCount:  len(entries),
+		ServiceName:   req.ServiceName,
+		Region:        req.Region,
+		Logs:          entries,
+		NextPageToken: nextPageToken,
+		TotalCount:    len(entries),
 	}
 
 	return response, nil
 }
 
 // GetServiceInfo retrieves information about a Cloud Run service
-func (s *CloudRunService) GetServiceInfo(ctx context.Context, serviceName, region string) (*models.CloudRunServiceInfo, error) {
+func (s *CloudRunService) GetServiceInfo(ctx context.Context, serviceName, region string) (_ *models.CloudRunServiceInfo, err error) {
+	ctx, span := tracing.Start(ctx, "CloudRunService.GetServiceInfo",
+		attribute.String("cloudrun.service", serviceName), attribute.String("cloudrun.region", region))
+	defer func() { tracing.End(span, err) }()
+
 	// Validate input
 	if err := gcp.ValidateCloudRunServiceName(serviceName); err != nil {
-		return nil, fmt.Errorf("invalid service name: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid service name: %w", err)
 	}
 	if err := gcp.ValidateCloudRunRegion(region); err != nil {
-		return nil, fmt.Errorf("invalid region: %w", err)
+		return nil, gcperrors.New(codes.InvalidArgument, "invalid region: %w", err)
 	}
 
 	// Build service name
@@ -262,7 +299,12 @@ func (s *CloudRunService) GetServiceInfo(ctx context.Context, serviceName, regio
 		Name: name,
 	}
 
-	service, err := s.runClient.GetService(ctx, getReq)
+	var service *runpb.Service
+	err = s.retry.Start("GetServiceInfo").Do(ctx, retry.Call{Service: metrics.ServiceRun, Method: "services.get", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			service, err = s.runClient.GetService(ctx, getReq)
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
@@ -281,6 +323,20 @@ func (s *CloudRunService) GetServiceInfo(ctx context.Context, serviceName, regio
 	return serviceInfo, nil
 }
 
+// PingLogging checks that the Cloud Logging API is available by listing at
+// most one log in the project
+func (s *CloudRunService) PingLogging(ctx context.Context) error {
+	done := metrics.ObserveGCPCall(metrics.ServiceLogging, "logs.list")
+	it := s.logAdminClient.Logs(ctx)
+	it.PageInfo().MaxSize = 1
+	_, err := it.Next()
+	if err == iterator.Done {
+		err = nil
+	}
+	done(err)
+	return err
+}
+
 // Close closes all clients
 func (s *CloudRunService) Close() error {
 	var errs []error
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/iterator"
+	storagev1 "google.golang.org/api/storage/v1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/dryrun"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/tracing"
+)
+
+// Base URLs of the REST APIs reported in dry runs
+const (
+	resourceManagerURL = "https://cloudresourcemanager.googleapis.com"
+	storageURL         = "https://storage.googleapis.com/storage/v1"
+)
+
+// Planned lifecycle state of deleted projects and folders
+const stateDeleteRequested = "DELETE_REQUESTED"
+
+// PlanCreateProject checks that a project could be created and describes
+// the request that would create it
+func (s *GCPService) PlanCreateProject(ctx context.Context, req *models.ProjectRequest) (_ *models.DryRunResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "CreateProject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.PlanCreateProject", attribute.String("gcp.project_id", req.ProjectID))
+	defer func() { tracing.End(span, err) }()
+
+	project := &cloudresourcemanager.Project{
+		ProjectId: req.ProjectID,
+		Name:      req.DisplayName,
+		Labels:    req.Labels,
+	}
+	if req.ParentID != "" && req.ParentType != "" {
+		if req.ParentType != "organization" && req.ParentType != "folder" {
+			return nil, gcperrors.New(codes.InvalidArgument, "invalid parent type: %s", req.ParentType)
+		}
+		project.Parent = &cloudresourcemanager.ResourceId{Type: req.ParentType, Id: req.ParentID}
+	}
+
+	// Project IDs are global; a project the caller cannot see is reported
+	// as forbidden rather than found
+	_, err = s.GetProject(ctx, req.ProjectID)
+	check := models.DryRunCheck{Name: "id_available", Status: dryrun.CheckPassed}
+	switch {
+	case err == nil:
+		return nil, gcperrors.New(codes.AlreadyExists, "project %s already exists", req.ProjectID)
+	case gcperrors.Is(err, codes.NotFound):
+		check.Message = fmt.Sprintf("Project ID %s is available", req.ProjectID)
+	case gcperrors.Is(err, codes.PermissionDenied):
+		check.Status = dryrun.CheckSkipped
+		check.Message = fmt.Sprintf("Project %s is not visible to the caller; the ID may be taken", req.ProjectID)
+	default:
+		return nil, err
+	}
+
+	diff, err := dryrun.Diff(nil, req)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DryRunResponse{
+		Operation: "CreateProject",
+		Resource:  "projects/" + req.ProjectID,
+		Request:   models.GCPRequest{Method: "POST", URL: resourceManagerURL + "/v1/projects", Body: project},
+		Diff:      diff,
+		Checks:    []models.DryRunCheck{check},
+	}, nil
+}
+
+// PlanDeleteProject checks that a project exists and describes the request
+// that would delete it
+func (s *GCPService) PlanDeleteProject(ctx context.Context, projectID string) (_ *models.DryRunResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteProject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.PlanDeleteProject", attribute.String("gcp.project_id", projectID))
+	defer func() { tracing.End(span, err) }()
+
+	current, err := s.GetProject(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return planDeletion("DeleteProject", "projects/"+projectID,
+		models.GCPRequest{Method: "DELETE", URL: resourceManagerURL + "/v1/projects/" + url.PathEscape(projectID)},
+		current, func(deleted *models.ProjectResponse) { deleted.State = stateDeleteRequested },
+		models.DryRunCheck{Name: "exists", Status: dryrun.CheckPassed, Message: fmt.Sprintf("Project %s is %s", projectID, current.State)})
+}
+
+// PlanCreateFolder describes the request that would create a folder
+// (placeholder implementation, like CreateFolder)
+func (s *GCPService) PlanCreateFolder(_ context.Context, req *models.FolderRequest) (*models.DryRunResponse, error) {
+	diff, err := dryrun.Diff(nil, req)
+	if err != nil {
+		return nil, err
+	}
+	parent := fmt.Sprintf("%ss/%s", req.ParentType, req.ParentID)
+	return &models.DryRunResponse{
+		Operation: "CreateFolder",
+		Resource:  "folders",
+		Request: models.GCPRequest{
+			Method: "POST",
+			URL:    resourceManagerURL + "/v2/folders?parent=" + url.QueryEscape(parent),
+			Body:   map[string]string{"displayName": req.DisplayName},
+		},
+		Diff:   diff,
+		Checks: []models.DryRunCheck{},
+	}, nil
+}
+
+// PlanDeleteFolder describes the request that would delete a folder
+// (placeholder implementation, like DeleteFolder)
+func (s *GCPService) PlanDeleteFolder(ctx context.Context, folderID string) (*models.DryRunResponse, error) {
+	current, err := s.GetFolder(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	return planDeletion("DeleteFolder", "folders/"+folderID,
+		models.GCPRequest{Method: "DELETE", URL: resourceManagerURL + "/v2/folders/" + url.PathEscape(folderID)},
+		current, func(deleted *models.FolderResponse) { deleted.State = stateDeleteRequested })
+}
+
+// PlanCreateBucket checks that a bucket could be created, including that
+// its name is available and its location exists, and describes the request
+// that would create it
+func (s *GCPService) PlanCreateBucket(ctx context.Context, req *models.BucketRequest) (_ *models.DryRunResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "CreateBucket")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.PlanCreateBucket", attribute.String("gcp.bucket", req.Name))
+	defer func() { tracing.End(span, err) }()
+
+	// Bucket names are global; buckets in other projects are reported as
+	// forbidden
+	_, err = s.GetBucket(ctx, req.Name)
+	switch {
+	case err == nil, gcperrors.Is(err, codes.PermissionDenied):
+		return nil, gcperrors.New(codes.AlreadyExists, "bucket name %s is already taken", req.Name)
+	case !gcperrors.Is(err, codes.NotFound):
+		return nil, err
+	}
+	checks := []models.DryRunCheck{{
+		Name:    "name_available",
+		Status:  dryrun.CheckPassed,
+		Message: fmt.Sprintf("Bucket name %s is available", req.Name),
+	}}
+
+	location, err := s.checkBucketLocation(ctx, req.Location)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, location)
+
+	diff, err := dryrun.Diff(nil, req)
+	if err != nil {
+		return nil, err
+	}
+	return &models.DryRunResponse{
+		Operation: "CreateBucket",
+		Resource:  "buckets/" + req.Name,
+		Request: models.GCPRequest{
+			Method: "POST",
+			URL:    storageURL + "/b?project=" + url.QueryEscape(s.config.GCPProjectID),
+			Body:   bucketResource(req),
+		},
+		Diff:   diff,
+		Checks: checks,
+	}, nil
+}
+
+// checkBucketLocation checks a regional location against the regions GCP
+// currently offers. Multi- and dual-region locations (e.g. US, NAM4) have
+// no region list to check against and were validated with the request.
+func (s *GCPService) checkBucketLocation(ctx context.Context, location string) (models.DryRunCheck, error) {
+	check := models.DryRunCheck{Name: "location", Status: dryrun.CheckSkipped}
+	region := strings.ToLower(location)
+	if !strings.Contains(region, "-") {
+		check.Message = fmt.Sprintf("%s is a multi- or dual-region location", location)
+		return check, nil
+	}
+
+	regions, _, err := s.locations.GetAvailableLocations(ctx)
+	if err != nil {
+		check.Message = fmt.Sprintf("Available regions could not be listed: %v", err)
+		return check, nil
+	}
+	if !slices.Contains(regions, region) {
+		return check, gcperrors.New(codes.InvalidArgument, "location %s is not an available region", location)
+	}
+	check.Status = dryrun.CheckPassed
+	check.Message = fmt.Sprintf("Region %s is available", region)
+	return check, nil
+}
+
+// bucketResource returns the body of the buckets.insert request creating
+// the bucket described by req, as CreateBucket sends it
+func bucketResource(req *models.BucketRequest) *storagev1.Bucket {
+	bucket := &storagev1.Bucket{
+		Name:         req.Name,
+		Location:     req.Location,
+		StorageClass: req.StorageClass,
+		Labels:       req.Labels,
+	}
+	if req.Versioning {
+		bucket.Versioning = &storagev1.BucketVersioning{Enabled: true}
+	}
+	if req.KMSKeyName != "" {
+		bucket.Encryption = &storagev1.BucketEncryption{DefaultKmsKeyName: req.KMSKeyName}
+	}
+	if req.RetentionPolicy != nil {
+		bucket.RetentionPolicy = &storagev1.BucketRetentionPolicy{
+			RetentionPeriod: req.RetentionPolicy.RetentionPeriodSeconds,
+			IsLocked:        req.RetentionPolicy.IsLocked,
+		}
+	}
+	if req.UniformBucketLevelAccess || req.PublicAccessPrevention != "" {
+		bucket.IamConfiguration = &storagev1.BucketIamConfiguration{PublicAccessPrevention: req.PublicAccessPrevention}
+		if req.UniformBucketLevelAccess {
+			bucket.IamConfiguration.UniformBucketLevelAccess = &storagev1.BucketIamConfigurationUniformBucketLevelAccess{Enabled: true}
+		}
+	}
+	return bucket
+}
+
+// PlanDeleteBucket checks that a bucket exists and is empty, as GCS only
+// deletes empty buckets, and describes the request that would delete it
+func (s *GCPService) PlanDeleteBucket(ctx context.Context, bucketName string) (_ *models.DryRunResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteBucket")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.PlanDeleteBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { tracing.End(span, err) }()
+
+	current, err := s.GetBucket(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	it := s.storageClient.Bucket(bucketName).Objects(ctx, &storage.Query{Versions: true})
+	it.PageInfo().MaxSize = 1
+	_, err = it.Next()
+	switch {
+	case err == nil:
+		return nil, gcperrors.New(codes.FailedPrecondition, "bucket %s is not empty", bucketName)
+	case err != iterator.Done:
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	return planDeletion("DeleteBucket", "buckets/"+bucketName,
+		models.GCPRequest{Method: "DELETE", URL: storageURL + "/b/" + url.PathEscape(bucketName)},
+		current, nil,
+		models.DryRunCheck{Name: "exists", Status: dryrun.CheckPassed, Message: fmt.Sprintf("Bucket %s exists", bucketName)},
+		models.DryRunCheck{Name: "empty", Status: dryrun.CheckPassed, Message: fmt.Sprintf("Bucket %s has no objects", bucketName)})
+}
+
+// PlanDeleteObject checks that an object exists and describes the request
+// that would delete it
+func (s *GCPService) PlanDeleteObject(ctx context.Context, bucketName, objectName string) (_ *models.DryRunResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteObject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.PlanDeleteObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { tracing.End(span, err) }()
+
+	current, err := s.GetObject(ctx, bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	return planDeletion("DeleteObject", "buckets/"+bucketName+"/objects/"+objectName,
+		models.GCPRequest{
+			Method: "DELETE",
+			URL:    storageURL + "/b/" + url.PathEscape(bucketName) + "/o/" + url.PathEscape(objectName),
+		},
+		current, nil,
+		models.DryRunCheck{Name: "exists", Status: dryrun.CheckPassed,
+			Message: fmt.Sprintf("Object %s exists at generation %d", objectName, current.Generation)})
+}
+
+// planDeletion describes the deletion of a resource in its current state.
+// Resources that are marked for deletion rather than removed are changed by
+// markDeleted; others are diffed against no state at all.
+func planDeletion[T any](operation, resource string, request models.GCPRequest, current *T, markDeleted func(*T), checks ...models.DryRunCheck) (*models.DryRunResponse, error) {
+	var proposed *T
+	if markDeleted != nil {
+		deleted := *current
+		markDeleted(&deleted)
+		proposed = &deleted
+	}
+	diff, err := dryrun.Diff(current, proposed)
+	if err != nil {
+		return nil, err
+	}
+	if checks == nil {
+		checks = []models.DryRunCheck{}
+	}
+	return &models.DryRunResponse{
+		Operation: operation,
+		Resource:  resource,
+		Request:   request,
+		Diff:      diff,
+		Checks:    checks,
+	}, nil
+}
@@ -0,0 +1,13 @@
+package services
+
+import (
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+)
+
+// IsAlreadyExists reports whether err is a GCP "already exists" (409) error
+// from either a REST or a gRPC API
+func IsAlreadyExists(err error) bool {
+	return gcperrors.Is(err, codes.AlreadyExists)
+}
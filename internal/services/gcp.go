@@ -7,9 +7,17 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/metrics"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/retry"
+	"github.com/stuartshay/gcp-automation-api/internal/tracing"
+	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
 )
 
 // GCPService handles all GCP operations
@@ -17,7 +25,8 @@ type GCPService struct {
 	config          *config.Config
 	resourceManager *cloudresourcemanager.Service
 	storageClient   *storage.Client
-	ctx             context.Context
+	retry           retry.Policy
+	locations       *gcp.LocationValidator
 }
 
 // NewGCPService creates a new GCP service instance
@@ -29,6 +38,17 @@ func NewGCPService(cfg *config.Config) (*GCPService, error) {
 		opts = append(opts, option.WithCredentialsFile(cfg.GCPCredentials))
 	}
 
+	return newGCPServiceWithOptions(ctx, cfg, opts...)
+}
+
+// newGCPServiceWithOptions creates a GCP service whose clients use the given
+// client options, e.g. an impersonated token source
+func newGCPServiceWithOptions(ctx context.Context, cfg *config.Config, opts ...option.ClientOption) (*GCPService, error) {
+	// Buckets are created and listed in the configured project
+	if cfg.GCPProjectID == "" {
+		return nil, fmt.Errorf("GCP_PROJECT_ID is required")
+	}
+
 	// Initialize Resource Manager client
 	resourceManager, err := cloudresourcemanager.NewService(ctx, opts...)
 	if err != nil {
@@ -40,17 +60,33 @@ func NewGCPService(cfg *config.Config) (*GCPService, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage client: %w", err)
 	}
+	// Calls are retried by the service's retry policy instead
+	storageClient.SetRetry(storage.WithPolicy(storage.RetryNever))
 
 	return &GCPService{
 		config:          cfg,
 		resourceManager: resourceManager,
 		storageClient:   storageClient,
-		ctx:             ctx,
+		retry:           retry.NewPolicy(cfg),
+		locations:       gcp.NewLocationValidator(cfg.GCPProjectID, opts...),
 	}, nil
 }
 
+// withTimeout bounds an operation by its configured timeout
+func (s *GCPService) withTimeout(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	if timeout := s.config.GCPOperationTimeout(operation); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // CreateProject creates a new GCP project
-func (s *GCPService) CreateProject(req *models.ProjectRequest) (*models.ProjectResponse, error) {
+func (s *GCPService) CreateProject(ctx context.Context, req *models.ProjectRequest) (_ *models.ProjectResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "CreateProject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.CreateProject", attribute.String("gcp.project_id", req.ProjectID))
+	defer func() { tracing.End(span, err) }()
+
 	project := &cloudresourcemanager.Project{
 		ProjectId: req.ProjectID,
 		Name:      req.DisplayName,
@@ -71,21 +107,37 @@ func (s *GCPService) CreateProject(req *models.ProjectRequest) (*models.ProjectR
 				Id:   req.ParentID,
 			}
 		default:
-			return nil, fmt.Errorf("invalid parent type: %s", req.ParentType)
+			return nil, gcperrors.New(codes.InvalidArgument, "invalid parent type: %s", req.ParentType)
 		}
 	}
 
+	budget := s.retry.Start("CreateProject")
+
 	// Create the project
-	op, err := s.resourceManager.Projects.Create(project).Do()
+	var op *cloudresourcemanager.Operation
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceResourceManager, Method: "projects.create"},
+		func(ctx context.Context) (err error) {
+			op, err = s.resourceManager.Projects.Create(project).Context(ctx).Do()
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
 
 	// Wait for operation to complete (simplified - in production, use polling)
-	time.Sleep(2 * time.Second)
+	select {
+	case <-time.After(2 * time.Second):
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for project creation: %w", ctx.Err())
+	}
 
-	// Get the created project
-	createdProject, err := s.resourceManager.Projects.Get(req.ProjectID).Do()
+	// Get the created project, which may not be visible yet
+	var createdProject *cloudresourcemanager.Project
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceResourceManager, Method: "projects.get", Idempotent: true, RetryNotFound: true},
+		func(ctx context.Context) (err error) {
+			createdProject, err = s.resourceManager.Projects.Get(req.ProjectID).Context(ctx).Do()
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get created project: %w", err)
 	}
@@ -112,12 +164,54 @@ func (s *GCPService) CreateProject(req *models.ProjectRequest) (*models.ProjectR
 }
 
 // GetProject retrieves a GCP project
-func (s *GCPService) GetProject(projectID string) (*models.ProjectResponse, error) {
-	project, err := s.resourceManager.Projects.Get(projectID).Do()
+func (s *GCPService) GetProject(ctx context.Context, projectID string) (_ *models.ProjectResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "GetProject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.GetProject", attribute.String("gcp.project_id", projectID))
+	defer func() { tracing.End(span, err) }()
+
+	var project *cloudresourcemanager.Project
+	err = s.retry.Start("GetProject").Do(ctx, retry.Call{Service: metrics.ServiceResourceManager, Method: "projects.get", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			project, err = s.resourceManager.Projects.Get(projectID).Context(ctx).Do()
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
 
+	return projectResponse(project), nil
+}
+
+// ListProjects lists the active projects the caller can see
+func (s *GCPService) ListProjects(ctx context.Context) (_ []*models.ProjectResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "ListProjects")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.ListProjects")
+	defer func() { tracing.End(span, err) }()
+
+	var projects []*models.ProjectResponse
+	err = s.retry.Start("ListProjects").Do(ctx, retry.Call{Service: metrics.ServiceResourceManager, Method: "projects.list", Idempotent: true},
+		func(ctx context.Context) error {
+			// A retry lists the projects again from the start
+			projects = []*models.ProjectResponse{}
+			return s.resourceManager.Projects.List().Filter("lifecycleState:ACTIVE").Pages(ctx,
+				func(page *cloudresourcemanager.ListProjectsResponse) error {
+					for _, project := range page.Projects {
+						projects = append(projects, projectResponse(project))
+					}
+					return nil
+				})
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+// projectResponse converts a Resource Manager project
+func projectResponse(project *cloudresourcemanager.Project) *models.ProjectResponse {
 	response := &models.ProjectResponse{
 		ProjectID:     project.ProjectId,
 		DisplayName:   project.Name,
@@ -133,12 +227,37 @@ func (s *GCPService) GetProject(projectID string) (*models.ProjectResponse, erro
 		response.ParentType = project.Parent.Type
 	}
 
-	return response, nil
+	return response
 }
 
 // DeleteProject deletes a GCP project
-func (s *GCPService) DeleteProject(projectID string) error {
-	_, err := s.resourceManager.Projects.Delete(projectID).Do()
+func (s *GCPService) DeleteProject(ctx context.Context, projectID string) (err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteProject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.DeleteProject", attribute.String("gcp.project_id", projectID))
+	defer func() { tracing.End(span, err) }()
+
+	// Deleting only marks the project DELETE_REQUESTED, and is rejected
+	// once it is no longer ACTIVE. A retry therefore checks whether an
+	// earlier attempt whose response was lost already marked it.
+	attempt := 0
+	err = s.retry.Start("DeleteProject").Do(ctx, retry.Call{Service: metrics.ServiceResourceManager, Method: "projects.delete", Idempotent: true, Delete: true},
+		func(ctx context.Context) error {
+			attempt++
+			if attempt > 1 {
+				done := metrics.ObserveGCPCall(metrics.ServiceResourceManager, "projects.get")
+				project, err := s.resourceManager.Projects.Get(projectID).Context(ctx).Do()
+				done(err)
+				if err != nil {
+					return err
+				}
+				if project.LifecycleState == "DELETE_REQUESTED" {
+					return nil
+				}
+			}
+			_, err := s.resourceManager.Projects.Delete(projectID).Context(ctx).Do()
+			return err
+		})
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
@@ -146,7 +265,7 @@ func (s *GCPService) DeleteProject(projectID string) error {
 }
 
 // CreateFolder creates a new GCP folder (placeholder implementation)
-func (s *GCPService) CreateFolder(req *models.FolderRequest) (*models.FolderResponse, error) {
+func (s *GCPService) CreateFolder(_ context.Context, req *models.FolderRequest) (*models.FolderResponse, error) {
 	// This is a placeholder implementation
 	// In a real implementation, you would use the Cloud Resource Manager API
 	// to create folders, which requires additional permissions and setup
@@ -165,7 +284,7 @@ func (s *GCPService) CreateFolder(req *models.FolderRequest) (*models.FolderResp
 }
 
 // GetFolder retrieves a GCP folder (placeholder implementation)
-func (s *GCPService) GetFolder(folderID string) (*models.FolderResponse, error) {
+func (s *GCPService) GetFolder(_ context.Context, folderID string) (*models.FolderResponse, error) {
 	// Placeholder implementation
 	response := &models.FolderResponse{
 		Name:        fmt.Sprintf("folders/%s", folderID),
@@ -179,13 +298,18 @@ func (s *GCPService) GetFolder(folderID string) (*models.FolderResponse, error)
 }
 
 // DeleteFolder deletes a GCP folder (placeholder implementation)
-func (s *GCPService) DeleteFolder(folderID string) error {
+func (s *GCPService) DeleteFolder(_ context.Context, folderID string) error {
 	// Placeholder implementation
 	return nil
 }
 
 // CreateBucket creates a new GCS bucket
-func (s *GCPService) CreateBucket(req *models.BucketRequest) (*models.BucketResponse, error) {
+func (s *GCPService) CreateBucket(ctx context.Context, req *models.BucketRequest) (_ *models.BucketResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "CreateBucket")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.CreateBucket", attribute.String("gcp.bucket", req.Name))
+	defer func() { tracing.End(span, err) }()
+
 	bucket := s.storageClient.Bucket(req.Name)
 
 	// Set basic bucket attributes
@@ -238,13 +362,25 @@ func (s *GCPService) CreateBucket(req *models.BucketRequest) (*models.BucketResp
 		}
 	}
 
+	budget := s.retry.Start("CreateBucket")
+
 	// Create the bucket
-	if err := bucket.Create(s.ctx, s.config.GCPProjectID, attrs); err != nil {
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "buckets.insert"},
+		func(ctx context.Context) error {
+			return bucket.Create(ctx, s.config.GCPProjectID, attrs)
+		})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create bucket: %w", err)
 	}
 
-	// Get bucket attributes to return complete information
-	bucketAttrs, err := bucket.Attrs(s.ctx)
+	// Get bucket attributes to return complete information; the new bucket
+	// may not be visible yet
+	var bucketAttrs *storage.BucketAttrs
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "buckets.get", Idempotent: true, RetryNotFound: true},
+		func(ctx context.Context) (err error) {
+			bucketAttrs, err = bucket.Attrs(ctx)
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bucket attributes: %w", err)
 	}
@@ -268,14 +404,60 @@ func (s *GCPService) CreateBucket(req *models.BucketRequest) (*models.BucketResp
 }
 
 // GetBucket retrieves a GCS bucket
-func (s *GCPService) GetBucket(bucketName string) (*models.BucketResponse, error) {
+func (s *GCPService) GetBucket(ctx context.Context, bucketName string) (_ *models.BucketResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "GetBucket")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.GetBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { tracing.End(span, err) }()
+
 	bucket := s.storageClient.Bucket(bucketName)
 
-	attrs, err := bucket.Attrs(s.ctx)
+	var attrs *storage.BucketAttrs
+	err = s.retry.Start("GetBucket").Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "buckets.get", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			attrs, err = bucket.Attrs(ctx)
+			return err
+		})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bucket: %w", err)
 	}
 
+	return s.bucketResponse(attrs), nil
+}
+
+// ListBuckets lists the buckets in the configured project
+func (s *GCPService) ListBuckets(ctx context.Context) (_ []*models.BucketResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "ListBuckets")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.ListBuckets", attribute.String("gcp.project_id", s.config.GCPProjectID))
+	defer func() { tracing.End(span, err) }()
+
+	var buckets []*models.BucketResponse
+	err = s.retry.Start("ListBuckets").Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "buckets.list", Idempotent: true},
+		func(ctx context.Context) error {
+			// A retry lists the buckets again from the start
+			buckets = []*models.BucketResponse{}
+			it := s.storageClient.Buckets(ctx, s.config.GCPProjectID)
+			for {
+				attrs, err := it.Next()
+				if err == iterator.Done {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				buckets = append(buckets, s.bucketResponse(attrs))
+			}
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// bucketResponse converts the attributes of a GCS bucket
+func (s *GCPService) bucketResponse(attrs *storage.BucketAttrs) *models.BucketResponse {
 	response := &models.BucketResponse{
 		Name:         attrs.Name,
 		Location:     attrs.Location,
@@ -290,20 +472,133 @@ func (s *GCPService) GetBucket(bucketName string) (*models.BucketResponse, error
 	// Add Phase 1 Advanced Options to response
 	s.mapAdvancedOptionsToResponse(attrs, response)
 
-	return response, nil
+	return response
 }
 
 // DeleteBucket deletes a GCS bucket
-func (s *GCPService) DeleteBucket(bucketName string) error {
+func (s *GCPService) DeleteBucket(ctx context.Context, bucketName string) (err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteBucket")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.DeleteBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { tracing.End(span, err) }()
+
 	bucket := s.storageClient.Bucket(bucketName)
 
-	if err := bucket.Delete(s.ctx); err != nil {
+	err = s.retry.Start("DeleteBucket").Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "buckets.delete", Idempotent: true, Delete: true},
+		bucket.Delete)
+	if err != nil {
 		return fmt.Errorf("failed to delete bucket: %w", err)
 	}
 
 	return nil
 }
 
+// ListObjects lists one page of the objects in a GCS bucket, optionally
+// filtered by prefix
+func (s *GCPService) ListObjects(ctx context.Context, req *models.ObjectListRequest) (_ *models.ObjectListResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "ListObjects")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.ListObjects", attribute.String("gcp.bucket", req.Bucket))
+	defer func() { tracing.End(span, err) }()
+
+	pageSize := req.PageSize
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 100 // Default page size
+	}
+
+	var page []*storage.ObjectAttrs
+	var nextPageToken string
+	err = s.retry.Start("ListObjects").Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "objects.list", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			// A retry fetches the same page again, resuming from the caller's page token
+			page = nil
+			it := s.storageClient.Bucket(req.Bucket).Objects(ctx, &storage.Query{Prefix: req.Prefix})
+			nextPageToken, err = iterator.NewPager(it, pageSize, req.PageToken).NextPage(&page)
+			return err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	objects := make([]*models.ObjectResponse, 0, len(page))
+	for _, attrs := range page {
+		objects = append(objects, objectResponse(attrs))
+	}
+
+	return &models.ObjectListResponse{Objects: objects, NextPageToken: nextPageToken}, nil
+}
+
+// GetObject retrieves the metadata of a GCS object
+func (s *GCPService) GetObject(ctx context.Context, bucketName, objectName string) (_ *models.ObjectResponse, err error) {
+	ctx, cancel := s.withTimeout(ctx, "GetObject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.GetObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { tracing.End(span, err) }()
+
+	var attrs *storage.ObjectAttrs
+	err = s.retry.Start("GetObject").Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "objects.get", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			attrs, err = s.storageClient.Bucket(bucketName).Object(objectName).Attrs(ctx)
+			return err
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", err)
+	}
+
+	return objectResponse(attrs), nil
+}
+
+// DeleteObject deletes a GCS object
+func (s *GCPService) DeleteObject(ctx context.Context, bucketName, objectName string) (err error) {
+	ctx, cancel := s.withTimeout(ctx, "DeleteObject")
+	defer cancel()
+	ctx, span := tracing.Start(ctx, "GCPService.DeleteObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { tracing.End(span, err) }()
+
+	object := s.storageClient.Bucket(bucketName).Object(objectName)
+	budget := s.retry.Start("DeleteObject")
+
+	var attrs *storage.ObjectAttrs
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "objects.get", Idempotent: true},
+		func(ctx context.Context) (err error) {
+			attrs, err = object.Attrs(ctx)
+			return err
+		})
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	// Deleting only the generation that was read makes retries safe: a retry
+	// cannot delete a newer generation uploaded after an earlier attempt
+	err = budget.Do(ctx, retry.Call{Service: metrics.ServiceStorage, Method: "objects.delete", Idempotent: true, Delete: true},
+		object.If(storage.Conditions{GenerationMatch: attrs.Generation}).Delete)
+	if err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+// objectResponse maps GCS object attributes to our response model
+func objectResponse(attrs *storage.ObjectAttrs) *models.ObjectResponse {
+	return &models.ObjectResponse{
+		Name:         attrs.Name,
+		Bucket:       attrs.Bucket,
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		MD5Hash:      fmt.Sprintf("%x", attrs.MD5),
+		CRC32C:       fmt.Sprintf("%08x", attrs.CRC32C),
+		CreateTime:   attrs.Created,
+		UpdateTime:   attrs.Updated,
+		Generation:   attrs.Generation,
+		StorageClass: attrs.StorageClass,
+		Metadata:     attrs.Metadata,
+		SelfLink:     attrs.MediaLink,
+	}
+}
+
 // mapAdvancedOptionsToResponse maps GCP bucket attributes advanced options to our response model
 func (s *GCPService) mapAdvancedOptionsToResponse(attrs *storage.BucketAttrs, response *models.BucketResponse) {
 	// KMS Encryption
@@ -333,6 +628,29 @@ func (s *GCPService) mapAdvancedOptionsToResponse(attrs *storage.BucketAttrs, re
 	}
 }
 
+// PingResourceManager checks that the Resource Manager API is reachable with
+// the service's credentials by listing at most one project
+func (s *GCPService) PingResourceManager(ctx context.Context) error {
+	done := metrics.ObserveGCPCall(metrics.ServiceResourceManager, "projects.list")
+	_, err := s.resourceManager.Projects.List().PageSize(1).Context(ctx).Do()
+	done(err)
+	return err
+}
+
+// PingStorage checks that the storage client can authenticate by listing at
+// most one bucket in the configured project
+func (s *GCPService) PingStorage(ctx context.Context) error {
+	done := metrics.ObserveGCPCall(metrics.ServiceStorage, "buckets.list")
+	it := s.storageClient.Buckets(ctx, s.config.GCPProjectID)
+	it.PageInfo().MaxSize = 1
+	_, err := it.Next()
+	if err == iterator.Done {
+		err = nil
+	}
+	done(err)
+	return err
+}
+
 // Close closes all GCP clients
 func (s *GCPService) Close() error {
 	if s.storageClient != nil {
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/logging"
+)
+
+// cloudPlatformScope is requested for impersonated access tokens
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// ErrNoImpersonationTarget is returned when no service account is mapped for a caller
+var ErrNoImpersonationTarget = errors.New("no GCP service account is mapped for the caller")
+
+// CallerIdentity identifies the API caller a GCP operation is performed for
+type CallerIdentity struct {
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// GCPServiceResolver returns the GCP service that should perform operations
+// on behalf of a caller
+type GCPServiceResolver interface {
+	ServiceFor(ctx context.Context, caller CallerIdentity) (GCPServiceInterface, error)
+	Close() error
+}
+
+// ImpersonatingGCPServiceResolver builds GCP clients that impersonate a
+// per-user or per-group service account using the IAM Credentials
+// generateAccessToken API. The server's own credentials must hold
+// roles/iam.serviceAccountTokenCreator on every target service account.
+//
+// Clients are cached per service account; their token sources cache the
+// short-lived access tokens and refresh them before expiry.
+type ImpersonatingGCPServiceResolver struct {
+	config   *config.Config
+	mapping  *config.ImpersonationConfig
+	baseOpts []option.ClientOption
+
+	mu       sync.Mutex
+	services map[string]*GCPService
+}
+
+// NewImpersonatingGCPServiceResolver creates a resolver from configuration
+func NewImpersonatingGCPServiceResolver(cfg *config.Config) *ImpersonatingGCPServiceResolver {
+	var opts []option.ClientOption
+	if cfg.GCPCredentials != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.GCPCredentials))
+	}
+
+	mapping := cfg.GCPImpersonation
+	if mapping == nil {
+		mapping = &config.ImpersonationConfig{}
+	}
+
+	return &ImpersonatingGCPServiceResolver{
+		config:   cfg,
+		mapping:  mapping,
+		baseOpts: opts,
+		services: make(map[string]*GCPService),
+	}
+}
+
+// ServiceAccountFor returns the service account to impersonate for a caller.
+// A user mapping takes precedence over group mappings, which are checked in
+// file order, followed by the default service account. User mappings only
+// apply to verified emails, since an unverified email could be any address.
+func (r *ImpersonatingGCPServiceResolver) ServiceAccountFor(caller CallerIdentity) (string, error) {
+	if sa, ok := r.mapping.Users[strings.ToLower(caller.Email)]; ok && caller.EmailVerified {
+		return sa, nil
+	}
+
+	for _, g := range r.mapping.Groups {
+		for _, group := range caller.Groups {
+			if strings.EqualFold(g.Group, group) {
+				return g.ServiceAccount, nil
+			}
+		}
+	}
+
+	if r.mapping.DefaultServiceAccount != "" {
+		return r.mapping.DefaultServiceAccount, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoImpersonationTarget, caller.Email)
+}
+
+// ServiceFor returns a GCP service impersonating the caller's service account
+func (r *ImpersonatingGCPServiceResolver) ServiceFor(ctx context.Context, caller CallerIdentity) (GCPServiceInterface, error) {
+	sa, err := r.ServiceAccountFor(caller)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if svc, ok := r.services[sa]; ok {
+		return svc, nil
+	}
+
+	// Clients outlive the request, so they are built with a background context
+	ts, err := impersonate.CredentialsTokenSource(context.Background(), impersonate.CredentialsConfig{
+		TargetPrincipal: sa,
+		Scopes:          []string{cloudPlatformScope},
+	}, r.baseOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", sa, err)
+	}
+
+	svc, err := newGCPServiceWithOptions(context.Background(), r.config, option.WithTokenSource(ts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCP clients for %s: %w", sa, err)
+	}
+
+	logging.FromContext(ctx).InfoContext(ctx, "Created impersonated GCP clients", "service_account", sa)
+	r.services[sa] = svc
+	return svc, nil
+}
+
+// Close closes every cached GCP service
+func (r *ImpersonatingGCPServiceResolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs []error
+	for sa, svc := range r.services {
+		if err := svc.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close clients for %s: %w", sa, err))
+		}
+	}
+	r.services = make(map[string]*GCPService)
+
+	return errors.Join(errs...)
+}
@@ -1,25 +1,46 @@
 package services
 
 import (
+	"context"
+
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 )
 
-// GCPServiceInterface defines the interface for GCP operations
+// GCPServiceInterface defines the interface for GCP operations. Operations
+// run in ctx, typically the request's, so that client disconnects, server
+// shutdown and trace context reach the GCP APIs.
 type GCPServiceInterface interface {
 	// Project operations
-	CreateProject(req *models.ProjectRequest) (*models.ProjectResponse, error)
-	GetProject(projectID string) (*models.ProjectResponse, error)
-	DeleteProject(projectID string) error
+	CreateProject(ctx context.Context, req *models.ProjectRequest) (*models.ProjectResponse, error)
+	GetProject(ctx context.Context, projectID string) (*models.ProjectResponse, error)
+	DeleteProject(ctx context.Context, projectID string) error
+	ListProjects(ctx context.Context) ([]*models.ProjectResponse, error)
 
 	// Folder operations
-	CreateFolder(req *models.FolderRequest) (*models.FolderResponse, error)
-	GetFolder(folderID string) (*models.FolderResponse, error)
-	DeleteFolder(folderID string) error
+	CreateFolder(ctx context.Context, req *models.FolderRequest) (*models.FolderResponse, error)
+	GetFolder(ctx context.Context, folderID string) (*models.FolderResponse, error)
+	DeleteFolder(ctx context.Context, folderID string) error
 
 	// Bucket operations
-	CreateBucket(req *models.BucketRequest) (*models.BucketResponse, error)
-	GetBucket(bucketName string) (*models.BucketResponse, error)
-	DeleteBucket(bucketName string) error
+	CreateBucket(ctx context.Context, req *models.BucketRequest) (*models.BucketResponse, error)
+	GetBucket(ctx context.Context, bucketName string) (*models.BucketResponse, error)
+	DeleteBucket(ctx context.Context, bucketName string) error
+	ListBuckets(ctx context.Context) ([]*models.BucketResponse, error)
+
+	// Object operations
+	ListObjects(ctx context.Context, req *models.ObjectListRequest) (*models.ObjectListResponse, error)
+	GetObject(ctx context.Context, bucketName, objectName string) (*models.ObjectResponse, error)
+	DeleteObject(ctx context.Context, bucketName, objectName string) error
+
+	// Dry runs check that an operation would succeed and describe the GCP
+	// request it would make, without changing anything
+	PlanCreateProject(ctx context.Context, req *models.ProjectRequest) (*models.DryRunResponse, error)
+	PlanDeleteProject(ctx context.Context, projectID string) (*models.DryRunResponse, error)
+	PlanCreateFolder(ctx context.Context, req *models.FolderRequest) (*models.DryRunResponse, error)
+	PlanDeleteFolder(ctx context.Context, folderID string) (*models.DryRunResponse, error)
+	PlanCreateBucket(ctx context.Context, req *models.BucketRequest) (*models.DryRunResponse, error)
+	PlanDeleteBucket(ctx context.Context, bucketName string) (*models.DryRunResponse, error)
+	PlanDeleteObject(ctx context.Context, bucketName, objectName string) (*models.DryRunResponse, error)
 
 	// Cleanup
 	Close() error
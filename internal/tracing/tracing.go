@@ -0,0 +1,148 @@
+// Package tracing configures OpenTelemetry tracing: the global tracer
+// provider with an OTLP exporter, W3C trace context propagation, and helpers
+// for spans around service operations.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+)
+
+// HeaderTraceID is the response header carrying the request's trace ID
+const HeaderTraceID = "X-Trace-ID"
+
+// instrumentationName names the tracer used by the server's own spans
+const instrumentationName = "github.com/stuartshay/gcp-automation-api"
+
+// Setup installs the W3C trace context propagator and, when tracing is
+// enabled, a tracer provider exporting spans over OTLP. The propagator is
+// installed either way so that incoming trace context still reaches GCP
+// calls. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, cfg *config.Config, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.TracingEnabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.TracingServiceName),
+		semconv.ServiceVersion(version),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the OTLP exporter for the configured protocol. The
+// endpoint may be host:port or a URL.
+func newExporter(ctx context.Context, cfg *config.Config) (sdktrace.SpanExporter, error) {
+	isURL := strings.Contains(cfg.TracingEndpoint, "://")
+
+	switch cfg.TracingProtocol {
+	case "http/protobuf":
+		var opts []otlptracehttp.Option
+		if isURL {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingEndpoint))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.TracingEndpoint))
+		}
+		if cfg.TracingInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		var opts []otlptracegrpc.Option
+		if isURL {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.TracingEndpoint))
+		} else {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.TracingEndpoint))
+		}
+		if cfg.TracingInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	}
+}
+
+// Middleware starts a server span for each request, continuing any W3C trace
+// context sent by the caller, and returns the trace ID in the X-Trace-ID
+// header. Health checks and metrics scrapes are not traced.
+func Middleware(serviceName string) echo.MiddlewareFunc {
+	traced := otelecho.Middleware(serviceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		switch c.Path() {
+		case "/health", "/livez", "/readyz", "/metrics":
+			return true
+		}
+		return false
+	}))
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return traced(func(c echo.Context) error {
+			if traceID := TraceID(c.Request().Context()); traceID != "" {
+				c.Response().Header().Set(HeaderTraceID, traceID)
+			}
+			return next(c)
+		})
+	}
+}
+
+// Start starts an internal span for a service operation
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it. Use it with a named error
+// result:
+//
+//	ctx, span := tracing.Start(ctx, "GCPService.GetBucket")
+//	defer func() { tracing.End(span, err) }()
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or "" if there is none
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AuditQuery filters audit log entries. Empty fields match everything.
+type AuditQuery struct {
+	// User matches the caller's user ID or email
+	User         string
+	ResourceType string
+	Resource     string
+	Method       string
+	Outcome      string
+	RequestID    string
+	Since        time.Time
+	Until        time.Time
+	// Limit caps the number of entries (server default 100, max 1000)
+	Limit int
+}
+
+// ListAuditEntries returns recorded mutating API calls, newest first. The
+// API does not paginate the audit log: at most q.Limit entries are
+// returned, and older ones are reached by setting q.Until.
+func (c *Client) ListAuditEntries(ctx context.Context, q *AuditQuery) ([]AuditEntry, error) {
+	query := url.Values{}
+	if q != nil {
+		for name, value := range map[string]string{
+			"user":          q.User,
+			"resource_type": q.ResourceType,
+			"resource":      q.Resource,
+			"method":        q.Method,
+			"outcome":       q.Outcome,
+			"request_id":    q.RequestID,
+		} {
+			if value != "" {
+				query.Set(name, value)
+			}
+		}
+		if !q.Since.IsZero() {
+			query.Set("since", q.Since.Format(time.RFC3339))
+		}
+		if !q.Until.IsZero() {
+			query.Set("until", q.Until.Format(time.RFC3339))
+		}
+		if q.Limit > 0 {
+			query.Set("limit", strconv.Itoa(q.Limit))
+		}
+	}
+
+	var entries []AuditEntry
+	if err := c.do(ctx, http.MethodGet, "/audit", query, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
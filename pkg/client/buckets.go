@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateBucket creates a Cloud Storage bucket
+func (c *Client) CreateBucket(ctx context.Context, req *BucketRequest) (*BucketResponse, error) {
+	var bucket BucketResponse
+	if err := c.do(ctx, http.MethodPost, "/buckets", nil, req, &bucket); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// GetBucket retrieves a bucket by name
+func (c *Client) GetBucket(ctx context.Context, bucketName string) (*BucketResponse, error) {
+	var bucket BucketResponse
+	if err := c.do(ctx, http.MethodGet, "/buckets/"+url.PathEscape(bucketName), nil, nil, &bucket); err != nil {
+		return nil, err
+	}
+	return &bucket, nil
+}
+
+// DeleteBucket deletes an empty bucket
+func (c *Client) DeleteBucket(ctx context.Context, bucketName string) error {
+	return c.do(ctx, http.MethodDelete, "/buckets/"+url.PathEscape(bucketName), nil, nil, nil)
+}
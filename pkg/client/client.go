@@ -0,0 +1,331 @@
+// Package client is a typed Go client for the GCP Automation API.
+//
+// It wraps every /api/v1 endpoint using the API's own request and response
+// types, authenticates with a bearer TokenSource, retries rate-limited and
+// transient server errors with exponential backoff, and decodes error
+// responses into *Error values.
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retry settings
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// RetryPolicy controls how failed requests are retried. Requests that were
+// rate limited (429) are always safe to retry; other 5xx responses and
+// network errors are only retried for idempotent methods.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+}
+
+// Client calls the GCP Automation API
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	tokens     TokenSource
+	retry      RetryPolicy
+	userAgent  string
+	// idempotencyKeys sends a generated Idempotency-Key with every create
+	idempotencyKeys bool
+
+	// sleep waits between retries; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithTokenSource sets the source of bearer tokens sent with every request
+func WithTokenSource(tokens TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithToken authenticates every request with a fixed bearer token
+func WithToken(token string) Option {
+	return WithTokenSource(StaticToken(token))
+}
+
+// WithHTTPClient sets the HTTP client used to send requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithIdempotencyKeys sends a generated Idempotency-Key header with every
+// create request, reusing it on retries. The server then replays the
+// original response instead of creating the resource twice, so creates are
+// retried like idempotent methods. Only use it against servers with
+// idempotency keys enabled.
+func WithIdempotencyKeys() Option {
+	return func(c *Client) {
+		c.idempotencyKeys = true
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a client for the API server at baseURL, e.g. https://api.example.com
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid API server URL %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		retry: RetryPolicy{
+			MaxRetries:     DefaultMaxRetries,
+			InitialBackoff: DefaultInitialBackoff,
+			MaxBackoff:     DefaultMaxBackoff,
+		},
+		userAgent: "gcp-automation-api-go-client",
+		sleep:     sleepContext,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// do sends a request to path (already escaped, relative to /api/v1), retrying
+// per the retry policy, and decodes the "data" field of the success response
+// into out if out is not nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u, err := url.Parse(c.baseURL.String() + "/api/v1" + path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = query.Encode()
+
+	var payload []byte
+	if body != nil {
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	var idempotencyKey string
+	if c.idempotencyKeys && method == http.MethodPost {
+		if idempotencyKey, err = newIdempotencyKey(); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		data, err := c.send(ctx, method, u.String(), payload, idempotencyKey)
+		if err == nil {
+			return decodeData(data, out)
+		}
+
+		if attempt >= c.retry.MaxRetries || !retryable(method, idempotencyKey != "", err) || ctx.Err() != nil {
+			return err
+		}
+		if err := c.sleep(ctx, c.backoff(attempt, err)); err != nil {
+			return err
+		}
+	}
+}
+
+// send performs a single HTTP request and returns the response body, or an
+// *Error for error responses
+func (c *Client) send(ctx context.Context, method, rawURL string, payload []byte, idempotencyKey string) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &transportError{err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, newError(resp, data)
+	}
+	return data, nil
+}
+
+// decodeData unmarshals the "data" field of a success response into out
+func decodeData(data []byte, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+
+	envelope := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(envelope.Data) == 0 {
+		return fmt.Errorf("response contained no data")
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// transportError is a request that failed before a response was received
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return "request failed: " + e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// retryable reports whether a failed request may be sent again. Requests
+// carrying an Idempotency-Key are safe to resend whatever their method.
+func retryable(method string, hasIdempotencyKey bool, err error) bool {
+	safe := hasIdempotencyKey || idempotent(method)
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		return apiErr.StatusCode >= 500 && safe
+	}
+
+	var transportErr *transportError
+	return errors.As(err, &transportErr) && safe
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// newIdempotencyKey returns a random key identifying one logical request
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := cryptorand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// backoff returns the delay before retry number attempt+1, honoring a
+// Retry-After from the server when present
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if c.retry.MaxBackoff > 0 && apiErr.RetryAfter > c.retry.MaxBackoff {
+			return c.retry.MaxBackoff
+		}
+		return apiErr.RetryAfter
+	}
+
+	delay := c.retry.InitialBackoff << attempt
+	if c.retry.MaxBackoff > 0 && (delay > c.retry.MaxBackoff || delay <= 0) {
+		delay = c.retry.MaxBackoff
+	}
+	// Full jitter keeps many clients from retrying in lockstep
+	if delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay)) + 1) // #nosec G404 -- jitter does not need crypto randomness
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pathEscape escapes each segment of a slash-separated path such as an object name
+func pathEscape(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
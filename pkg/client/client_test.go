@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/iterator"
+)
+
+// newTestClient returns a client for server that records retry delays instead of sleeping
+func newTestClient(t *testing.T, server *httptest.Server, delays *[]time.Duration) *Client {
+	t.Helper()
+	c, err := New(server.URL, WithToken("test-token"))
+	require.NoError(t, err)
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		if delays != nil {
+			*delays = append(*delays, d)
+		}
+		return nil
+	}
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestGetObjectEscapesNameAndSendsToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/api/v1/buckets/data/objects/reports/q1%20final.csv", r.URL.EscapedPath())
+		writeJSON(w, http.StatusOK, SuccessResponse{Data: ObjectResponse{Name: "reports/q1 final.csv", Size: 7}})
+	}))
+	defer server.Close()
+
+	object, err := newTestClient(t, server, nil).GetObject(context.Background(), "data", "reports/q1 final.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "reports/q1 final.csv", object.Name)
+	assert.Equal(t, int64(7), object.Size)
+}
+
+func TestErrorResponsesAreTyped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse{
+			Error:   "Project not found",
+			Message: "project missing does not exist",
+			Code:    http.StatusNotFound,
+		})
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server, nil).GetProject(context.Background(), "missing")
+	require.Error(t, err)
+	assert.True(t, IsNotFound(err))
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Project not found", apiErr.Type)
+	assert.Equal(t, "project missing does not exist", apiErr.Message)
+}
+
+func TestErrorsCarryPolicyDecisionsAndLabelViolations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusForbidden, ErrorResponse{
+			Error:           "PERMISSION_DENIED",
+			Message:         "request denied by policy",
+			Code:            http.StatusForbidden,
+			PolicyDecisions: []PolicyDecision{{Policy: "buckets-in-us", Effect: "deny", Message: "Buckets must be created in a US location"}},
+			LabelViolations: []LabelViolation{{Key: "env", Value: "qa", Reason: "not_allowed"}},
+		})
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(t, server, nil).CreateBucket(context.Background(), &BucketRequest{Name: "my-bucket", Location: "EU"})
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Len(t, apiErr.PolicyDecisions, 1)
+	assert.Equal(t, "buckets-in-us", apiErr.PolicyDecisions[0].Policy)
+	require.Len(t, apiErr.LabelViolations, 1)
+	assert.Equal(t, "not_allowed", apiErr.LabelViolations[0].Reason)
+}
+
+func TestRetriesRateLimitedRequestsWithRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			writeJSON(w, http.StatusTooManyRequests, ErrorResponse{Error: "Rate limit exceeded", Code: http.StatusTooManyRequests})
+			return
+		}
+		writeJSON(w, http.StatusCreated, SuccessResponse{Data: BucketResponse{Name: "my-bucket"}})
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	bucket, err := newTestClient(t, server, &delays).CreateBucket(context.Background(), &BucketRequest{Name: "my-bucket"})
+	require.NoError(t, err)
+	assert.Equal(t, "my-bucket", bucket.Name)
+	assert.Equal(t, int32(2), calls)
+	assert.Equal(t, []time.Duration{2 * time.Second}, delays)
+}
+
+func TestServerErrorsRetriedOnlyForIdempotentMethods(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "Unavailable", Code: http.StatusServiceUnavailable})
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	c := newTestClient(t, server, &delays)
+
+	err := c.DeleteBucket(context.Background(), "my-bucket")
+	assert.Equal(t, http.StatusServiceUnavailable, StatusCode(err))
+	assert.Equal(t, int32(DefaultMaxRetries+1), calls)
+	require.Len(t, delays, DefaultMaxRetries)
+	for i, d := range delays {
+		assert.LessOrEqual(t, d, DefaultInitialBackoff<<i)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	_, err = c.CreateBucket(context.Background(), &BucketRequest{Name: "my-bucket"})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestCreatesWithIdempotencyKeysAreRetried(t *testing.T) {
+	var calls int32
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{Error: "Unavailable", Code: http.StatusServiceUnavailable})
+			return
+		}
+		writeJSON(w, http.StatusCreated, SuccessResponse{Data: ProjectResponse{ProjectID: "my-project"}})
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server, nil)
+	WithIdempotencyKeys()(c)
+
+	project, err := c.CreateProject(context.Background(), &ProjectRequest{ProjectID: "my-project"})
+	require.NoError(t, err)
+	assert.Equal(t, "my-project", project.ProjectID)
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+}
+
+func TestCloudRunLogsIteratorFollowsPageTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/cloudrun/logs/my-service/us-central1", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("pageSize"))
+
+		resp := CloudRunLogsResponse{ServiceName: "my-service", Region: "us-central1"}
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			resp.Logs = []LogEntry{{Message: "one"}, {Message: "two"}}
+			resp.NextPageToken = "page-2"
+		case "page-2":
+			resp.Logs = []LogEntry{{Message: "three"}}
+		default:
+			t.Errorf("unexpected page token %q", r.URL.Query().Get("pageToken"))
+		}
+		writeJSON(w, http.StatusOK, SuccessResponse{Data: resp})
+	}))
+	defer server.Close()
+
+	it := newTestClient(t, server, nil).CloudRunLogs(context.Background(), &CloudRunLogsRequest{
+		ServiceName: "my-service",
+		Region:      "us-central1",
+		PageSize:    2,
+	})
+
+	var messages []string
+	for {
+		entry, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		require.NoError(t, err)
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, messages)
+}
+
+func TestObjectIteratorFollowsPageTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/buckets/data/objects", r.URL.Path)
+		assert.Equal(t, "reports/", r.URL.Query().Get("prefix"))
+		assert.Equal(t, "2", r.URL.Query().Get("page_size"))
+
+		var resp ObjectListResponse
+		switch r.URL.Query().Get("page_token") {
+		case "":
+			resp.Objects = []*ObjectResponse{{Name: "reports/a.csv"}, {Name: "reports/b.csv"}}
+			resp.NextPageToken = "page-2"
+		case "page-2":
+			resp.Objects = []*ObjectResponse{{Name: "reports/c.csv"}}
+		default:
+			t.Errorf("unexpected page token %q", r.URL.Query().Get("page_token"))
+		}
+		writeJSON(w, http.StatusOK, SuccessResponse{Data: resp})
+	}))
+	defer server.Close()
+
+	it := newTestClient(t, server, nil).Objects(context.Background(), &ObjectListRequest{
+		Bucket:   "data",
+		Prefix:   "reports/",
+		PageSize: 2,
+	})
+
+	var names []string
+	for {
+		object, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, object.Name)
+	}
+	assert.Equal(t, []string{"reports/a.csv", "reports/b.csv", "reports/c.csv"}, names)
+}
+
+func TestLabelSchemaEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/label-schemas":
+			writeJSON(w, http.StatusOK, SuccessResponse{Data: []LabelSchema{{ResourceType: "bucket"}}})
+		case "GET /api/v1/label-schemas/bucket":
+			writeJSON(w, http.StatusOK, SuccessResponse{Data: LabelSchema{ResourceType: "bucket", Keys: []LabelKeyRule{{Key: "env"}}}})
+		case "PUT /api/v1/label-schemas/bucket":
+			var req LabelSchemaRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			writeJSON(w, http.StatusOK, SuccessResponse{Data: LabelSchema{ResourceType: "bucket", Keys: req.Keys}})
+		case "DELETE /api/v1/label-schemas/bucket":
+			writeJSON(w, http.StatusOK, SuccessResponse{Message: "Label schema deleted successfully"})
+		case "GET /api/v1/label-schemas/bucket/compliance":
+			writeJSON(w, http.StatusOK, SuccessResponse{Data: LabelComplianceReport{
+				ResourceType: "bucket",
+				Checked:      2,
+				NonCompliant: []NonCompliantResource{{Name: "old-bucket", Violations: []LabelViolation{{Key: "env", Reason: "missing"}}}},
+			}})
+		case "GET /api/v1/label-schemas/folder":
+			writeJSON(w, http.StatusNotFound, ErrorResponse{Error: "NOT_FOUND", Message: "no label schema for folder", Code: http.StatusNotFound})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestClient(t, server, nil)
+	ctx := context.Background()
+
+	schemas, err := c.ListLabelSchemas(ctx)
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.Equal(t, "bucket", schemas[0].ResourceType)
+
+	schema, err := c.GetLabelSchema(ctx, "bucket")
+	require.NoError(t, err)
+	assert.Equal(t, "env", schema.Keys[0].Key)
+
+	schema, err = c.PutLabelSchema(ctx, "bucket", &LabelSchemaRequest{Keys: []LabelKeyRule{{Key: "team", Required: true}}})
+	require.NoError(t, err)
+	assert.Equal(t, []LabelKeyRule{{Key: "team", Required: true}}, schema.Keys)
+
+	require.NoError(t, c.DeleteLabelSchema(ctx, "bucket"))
+
+	report, err := c.LabelCompliance(ctx, "bucket")
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	require.Len(t, report.NonCompliant, 1)
+	assert.Equal(t, "old-bucket", report.NonCompliant[0].Name)
+
+	_, err = c.GetLabelSchema(ctx, "folder")
+	assert.True(t, IsNotFound(err))
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/iterator"
+)
+
+// GetCloudRunLogs retrieves one page of logs for a Cloud Run service.
+// ServiceName and Region are required; set PageToken to the previous
+// response's NextPageToken to continue.
+func (c *Client) GetCloudRunLogs(ctx context.Context, req *CloudRunLogsRequest) (*CloudRunLogsResponse, error) {
+	query := url.Values{}
+	if !req.StartTime.IsZero() {
+		query.Set("startTime", req.StartTime.Format(time.RFC3339))
+	}
+	if !req.EndTime.IsZero() {
+		query.Set("endTime", req.EndTime.Format(time.RFC3339))
+	}
+	if req.Filter != "" {
+		query.Set("filter", req.Filter)
+	}
+	if req.PageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(req.PageSize))
+	}
+	if req.PageToken != "" {
+		query.Set("pageToken", req.PageToken)
+	}
+
+	path := "/cloudrun/logs/" + url.PathEscape(req.ServiceName) + "/" + url.PathEscape(req.Region)
+	var logs CloudRunLogsResponse
+	if err := c.do(ctx, http.MethodGet, path, query, nil, &logs); err != nil {
+		return nil, err
+	}
+	return &logs, nil
+}
+
+// CloudRunLogs returns an iterator over all log entries matching req,
+// fetching further pages as needed. req.PageSize sets the page size.
+func (c *Client) CloudRunLogs(ctx context.Context, req *CloudRunLogsRequest) *LogIterator {
+	pageReq := *req
+	return &LogIterator{ctx: ctx, client: c, req: &pageReq}
+}
+
+// LogIterator iterates over Cloud Run log entries
+type LogIterator struct {
+	ctx    context.Context
+	client *Client
+	req    *CloudRunLogsRequest
+	page   []LogEntry
+	last   bool
+}
+
+// Next returns the next log entry. It returns iterator.Done when there are
+// no more entries.
+func (it *LogIterator) Next() (*LogEntry, error) {
+	for len(it.page) == 0 {
+		if it.last {
+			return nil, iterator.Done
+		}
+
+		resp, err := it.client.GetCloudRunLogs(it.ctx, it.req)
+		if err != nil {
+			return nil, err
+		}
+		it.page = resp.Logs
+		it.req.PageToken = resp.NextPageToken
+		it.last = resp.NextPageToken == ""
+	}
+
+	entry := it.page[0]
+	it.page = it.page[1:]
+	return &entry, nil
+}
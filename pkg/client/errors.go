@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Error is an error response returned by the API
+type Error struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Type is the short error description from the response's "error" field
+	Type string
+	// Message is the detailed error message
+	Message string
+	// RetryAfter is the delay requested by the server, if any
+	RetryAfter time.Duration
+	// PolicyDecisions lists the policies that denied the request
+	PolicyDecisions []PolicyDecision
+	// LabelViolations lists the labels that broke the resource type's label
+	// schema
+	LabelViolations []LabelViolation
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s (HTTP %d)", e.Type, e.Message, e.StatusCode)
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Type, e.StatusCode)
+}
+
+// newError decodes an ErrorResponse body, falling back to the raw body for
+// responses that did not come from the API itself (e.g. a proxy)
+func newError(resp *http.Response, body []byte) *Error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var decoded ErrorResponse
+	if err := json.Unmarshal(body, &decoded); err == nil && decoded.Error != "" {
+		apiErr.Type = decoded.Error
+		apiErr.Message = decoded.Message
+		apiErr.PolicyDecisions = decoded.PolicyDecisions
+		apiErr.LabelViolations = decoded.LabelViolations
+		return apiErr
+	}
+
+	apiErr.Type = http.StatusText(resp.StatusCode)
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+// StatusCode returns the HTTP status of an API error, or 0 if err is not one
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is a 404 from the API
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is a 409 from the API
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsUnauthorized reports whether err is a 401 from the API
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether err is a 403 from the API
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+// IsRateLimited reports whether err is a 429 from the API
+func IsRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
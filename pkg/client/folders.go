@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CreateFolder creates a folder
+func (c *Client) CreateFolder(ctx context.Context, req *FolderRequest) (*FolderResponse, error) {
+	var folder FolderResponse
+	if err := c.do(ctx, http.MethodPost, "/folders", nil, req, &folder); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// GetFolder retrieves a folder by ID. The ID may also be given as a
+// "folders/ID" resource name.
+func (c *Client) GetFolder(ctx context.Context, folderID string) (*FolderResponse, error) {
+	var folder FolderResponse
+	if err := c.do(ctx, http.MethodGet, folderPath(folderID), nil, nil, &folder); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// DeleteFolder deletes a folder by ID or "folders/ID" resource name
+func (c *Client) DeleteFolder(ctx context.Context, folderID string) error {
+	return c.do(ctx, http.MethodDelete, folderPath(folderID), nil, nil, nil)
+}
+
+func folderPath(folderID string) string {
+	return "/folders/" + url.PathEscape(strings.TrimPrefix(folderID, "folders/"))
+}
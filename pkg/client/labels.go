@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListLabelSchemas returns the label schemas of all resource types
+func (c *Client) ListLabelSchemas(ctx context.Context) ([]LabelSchema, error) {
+	var schemas []LabelSchema
+	if err := c.do(ctx, http.MethodGet, "/label-schemas", nil, nil, &schemas); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// GetLabelSchema retrieves the label schema of a resource type ("bucket" or
+// "project")
+func (c *Client) GetLabelSchema(ctx context.Context, resourceType string) (*LabelSchema, error) {
+	var schema LabelSchema
+	if err := c.do(ctx, http.MethodGet, labelSchemaPath(resourceType), nil, nil, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// PutLabelSchema creates or replaces the label schema of a resource type.
+// The caller must belong to a label schema admin group.
+func (c *Client) PutLabelSchema(ctx context.Context, resourceType string, req *LabelSchemaRequest) (*LabelSchema, error) {
+	var schema LabelSchema
+	if err := c.do(ctx, http.MethodPut, labelSchemaPath(resourceType), nil, req, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// DeleteLabelSchema removes the label schema of a resource type. The caller
+// must belong to a label schema admin group.
+func (c *Client) DeleteLabelSchema(ctx context.Context, resourceType string) error {
+	return c.do(ctx, http.MethodDelete, labelSchemaPath(resourceType), nil, nil, nil)
+}
+
+// LabelCompliance reports the existing resources of a type whose labels do
+// not follow its schema
+func (c *Client) LabelCompliance(ctx context.Context, resourceType string) (*LabelComplianceReport, error) {
+	var report LabelComplianceReport
+	if err := c.do(ctx, http.MethodGet, labelSchemaPath(resourceType)+"/compliance", nil, nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// labelSchemaPath returns the API path for a resource type's label schema
+func labelSchemaPath(resourceType string) string {
+	return "/label-schemas/" + url.PathEscape(resourceType)
+}
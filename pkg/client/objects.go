@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/api/iterator"
+)
+
+// ListObjects lists one page of the objects in a bucket. req.Bucket is
+// required; set PageToken to the previous response's NextPageToken to
+// continue.
+func (c *Client) ListObjects(ctx context.Context, req *ObjectListRequest) (*ObjectListResponse, error) {
+	query := url.Values{}
+	if req.Prefix != "" {
+		query.Set("prefix", req.Prefix)
+	}
+	if req.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(req.PageSize))
+	}
+	if req.PageToken != "" {
+		query.Set("page_token", req.PageToken)
+	}
+
+	var objects ObjectListResponse
+	if err := c.do(ctx, http.MethodGet, objectsPath(req.Bucket, ""), query, nil, &objects); err != nil {
+		return nil, err
+	}
+	return &objects, nil
+}
+
+// Objects returns an iterator over all objects matching req, fetching
+// further pages as needed. req.PageSize sets the page size.
+func (c *Client) Objects(ctx context.Context, req *ObjectListRequest) *ObjectIterator {
+	pageReq := *req
+	return &ObjectIterator{ctx: ctx, client: c, req: &pageReq}
+}
+
+// ObjectIterator iterates over the objects in a bucket
+type ObjectIterator struct {
+	ctx    context.Context
+	client *Client
+	req    *ObjectListRequest
+	page   []*ObjectResponse
+	last   bool
+}
+
+// Next returns the next object. It returns iterator.Done when there are no
+// more objects.
+func (it *ObjectIterator) Next() (*ObjectResponse, error) {
+	for len(it.page) == 0 {
+		if it.last {
+			return nil, iterator.Done
+		}
+
+		resp, err := it.client.ListObjects(it.ctx, it.req)
+		if err != nil {
+			return nil, err
+		}
+		it.page = resp.Objects
+		it.req.PageToken = resp.NextPageToken
+		it.last = resp.NextPageToken == ""
+	}
+
+	object := it.page[0]
+	it.page = it.page[1:]
+	return object, nil
+}
+
+// GetObject retrieves an object's metadata
+func (c *Client) GetObject(ctx context.Context, bucketName, objectName string) (*ObjectResponse, error) {
+	var object ObjectResponse
+	if err := c.do(ctx, http.MethodGet, objectsPath(bucketName, objectName), nil, nil, &object); err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// DeleteObject deletes an object
+func (c *Client) DeleteObject(ctx context.Context, bucketName, objectName string) error {
+	return c.do(ctx, http.MethodDelete, objectsPath(bucketName, objectName), nil, nil, nil)
+}
+
+// objectsPath returns the API path for a bucket's objects, or for one object
+func objectsPath(bucketName, objectName string) string {
+	path := "/buckets/" + url.PathEscape(bucketName) + "/objects"
+	if objectName != "" {
+		path += "/" + pathEscape(objectName)
+	}
+	return path
+}
@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CreateProject creates a project
+func (c *Client) CreateProject(ctx context.Context, req *ProjectRequest) (*ProjectResponse, error) {
+	var project ProjectResponse
+	if err := c.do(ctx, http.MethodPost, "/projects", nil, req, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetProject retrieves a project by ID
+func (c *Client) GetProject(ctx context.Context, projectID string) (*ProjectResponse, error) {
+	var project ProjectResponse
+	if err := c.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(projectID), nil, nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// DeleteProject deletes a project by ID
+func (c *Client) DeleteProject(ctx context.Context, projectID string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+url.PathEscape(projectID), nil, nil, nil)
+}
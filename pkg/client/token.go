@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenSource supplies the bearer token sent with each request
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticToken returns a TokenSource that always returns token
+func StaticToken(token string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// EnvToken returns a TokenSource that reads the token from an environment
+// variable on each request, e.g. GCP_AUTOMATION_TOKEN as set by
+// `auth-cli token -o env`
+func EnvToken(name string) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		token := os.Getenv(name)
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return token, nil
+	})
+}
+
+// OAuth2Token adapts an oauth2.TokenSource, such as one that refreshes
+// tokens automatically, to a TokenSource
+func OAuth2Token(ts oauth2.TokenSource) TokenSource {
+	return TokenSourceFunc(func(context.Context) (string, error) {
+		token, err := ts.Token()
+		if err != nil {
+			return "", err
+		}
+		return token.AccessToken, nil
+	})
+}
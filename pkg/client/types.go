@@ -0,0 +1,42 @@
+package client
+
+import "github.com/stuartshay/gcp-automation-api/internal/models"
+
+// Request and response types of the API, re-exported so that code outside
+// this module can use them without importing internal packages.
+type (
+	ErrorResponse   = models.ErrorResponse
+	SuccessResponse = models.SuccessResponse
+
+	ProjectRequest  = models.ProjectRequest
+	ProjectResponse = models.ProjectResponse
+
+	FolderRequest  = models.FolderRequest
+	FolderResponse = models.FolderResponse
+
+	BucketRequest   = models.BucketRequest
+	BucketResponse  = models.BucketResponse
+	RetentionPolicy = models.RetentionPolicy
+	ObjectResponse  = models.ObjectResponse
+
+	ObjectListRequest  = models.ObjectListRequest
+	ObjectListResponse = models.ObjectListResponse
+
+	CloudRunLogsRequest  = models.CloudRunLogsRequest
+	CloudRunLogsResponse = models.CloudRunLogsResponse
+	LogEntry             = models.LogEntry
+	LogResource          = models.LogResource
+	HTTPRequest          = models.HTTPRequest
+
+	AuditEntry = models.AuditEntry
+
+	PolicyDecision = models.PolicyDecision
+	FieldChange    = models.FieldChange
+
+	LabelSchema           = models.LabelSchema
+	LabelSchemaRequest    = models.LabelSchemaRequest
+	LabelKeyRule          = models.LabelKeyRule
+	LabelViolation        = models.LabelViolation
+	LabelComplianceReport = models.LabelComplianceReport
+	NonCompliantResource  = models.NonCompliantResource
+)
@@ -8,7 +8,9 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/retry"
 	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
@@ -18,6 +20,7 @@ type GCPStorageClient struct {
 	client    *storage.Client
 	projectID string
 	ctx       context.Context
+	retry     retry.Policy
 }
 
 // NewGCPStorageClient creates a new GCP Storage Client
@@ -26,16 +29,66 @@ func NewGCPStorageClient(ctx context.Context, projectID string, opts ...option.C
 	if err != nil {
 		return nil, fmt.Errorf("failed to create storage client: %w", err)
 	}
+	// Calls are retried by the client's retry policy instead
+	client.SetRetry(storage.WithPolicy(storage.RetryNever))
 
 	return &GCPStorageClient{
 		client:    client,
 		projectID: projectID,
 		ctx:       ctx,
+		retry:     DefaultRetryPolicy().policy(),
 	}, nil
 }
 
+// storageService labels the client's calls in metrics, as the server does
+const storageService = "storage"
+
+// RetryPolicy controls how the client retries failed calls
+type RetryPolicy struct {
+	// MaxRetries is how many times the calls of one method may be retried
+	// in total, unless Budgets has an entry for the method; 0 disables
+	// retries
+	MaxRetries int
+	// Budgets overrides MaxRetries by method name, e.g. "CreateBucket"
+	Budgets map[string]int
+	// InitialBackoff is the longest wait before the first retry; it doubles
+	// with each retry up to MaxBackoff, and the actual wait is a random
+	// duration up to that bound
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy returns the policy a new client uses
+func DefaultRetryPolicy() RetryPolicy {
+	p := retry.DefaultPolicy()
+	return RetryPolicy{MaxRetries: p.MaxRetries, InitialBackoff: p.InitialBackoff, MaxBackoff: p.MaxBackoff}
+}
+
+// policy converts p to the policy of the retry implementation
+func (p RetryPolicy) policy() retry.Policy {
+	return retry.Policy{
+		MaxRetries:     p.MaxRetries,
+		Budgets:        p.Budgets,
+		InitialBackoff: p.InitialBackoff,
+		MaxBackoff:     p.MaxBackoff,
+	}
+}
+
+// SetRetryPolicy sets how the client retries failed calls
+func (c *GCPStorageClient) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy.policy()
+}
+
+// call returns the description of a Cloud Storage API call
+func call(method string, idempotent bool) retry.Call {
+	return retry.Call{Service: storageService, Method: method, Idempotent: idempotent}
+}
+
 // CreateBucket creates a new GCS bucket
-func (c *GCPStorageClient) CreateBucket(ctx context.Context, req *models.BucketRequest) (*models.BucketResponse, error) {
+func (c *GCPStorageClient) CreateBucket(ctx context.Context, req *models.BucketRequest) (_ *models.BucketResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.CreateBucket", attribute.String("gcp.bucket", req.Name))
+	defer func() { endSpan(span, err) }()
+
 	// Validate request
 	if err := gcp.ValidateBucketName(req.Name); err != nil {
 		return nil, gcp.WrapError("creating bucket", req.Name, err)
@@ -101,13 +154,25 @@ func (c *GCPStorageClient) CreateBucket(ctx context.Context, req *models.BucketR
 		}
 	}
 
+	budget := c.retry.Start("CreateBucket")
+
 	// Create the bucket
-	if err := bucket.Create(ctx, c.projectID, attrs); err != nil {
+	err = budget.Do(ctx, call("buckets.insert", false), func(ctx context.Context) error {
+		return bucket.Create(ctx, c.projectID, attrs)
+	})
+	if err != nil {
 		return nil, gcp.WrapError("creating bucket", req.Name, err)
 	}
 
-	// Get bucket attributes to return complete information
-	bucketAttrs, err := bucket.Attrs(ctx)
+	// Get bucket attributes to return complete information; the new bucket
+	// may not be visible yet
+	var bucketAttrs *storage.BucketAttrs
+	getCall := call("buckets.get", true)
+	getCall.RetryNotFound = true
+	err = budget.Do(ctx, getCall, func(ctx context.Context) (err error) {
+		bucketAttrs, err = bucket.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, gcp.WrapError("getting bucket attributes after creation", req.Name, err)
 	}
@@ -116,14 +181,21 @@ func (c *GCPStorageClient) CreateBucket(ctx context.Context, req *models.BucketR
 }
 
 // GetBucket retrieves a GCS bucket
-func (c *GCPStorageClient) GetBucket(ctx context.Context, bucketName string) (*models.BucketResponse, error) {
+func (c *GCPStorageClient) GetBucket(ctx context.Context, bucketName string) (_ *models.BucketResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.GetBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	if err := gcp.ValidateBucketName(bucketName); err != nil {
 		return nil, gcp.WrapError("getting bucket", bucketName, err)
 	}
 
 	bucket := c.client.Bucket(bucketName)
 
-	attrs, err := bucket.Attrs(ctx)
+	var attrs *storage.BucketAttrs
+	err = c.retry.Start("GetBucket").Do(ctx, call("buckets.get", true), func(ctx context.Context) (err error) {
+		attrs, err = bucket.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, gcp.WrapError("getting bucket", bucketName, err)
 	}
@@ -132,14 +204,19 @@ func (c *GCPStorageClient) GetBucket(ctx context.Context, bucketName string) (*m
 }
 
 // DeleteBucket deletes a GCS bucket
-func (c *GCPStorageClient) DeleteBucket(ctx context.Context, bucketName string) error {
+func (c *GCPStorageClient) DeleteBucket(ctx context.Context, bucketName string) (err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.DeleteBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	if err := gcp.ValidateBucketName(bucketName); err != nil {
 		return gcp.WrapError("deleting bucket", bucketName, err)
 	}
 
 	bucket := c.client.Bucket(bucketName)
 
-	if err := bucket.Delete(ctx); err != nil {
+	deleteCall := call("buckets.delete", true)
+	deleteCall.Delete = true
+	if err := c.retry.Start("DeleteBucket").Do(ctx, deleteCall, bucket.Delete); err != nil {
 		return gcp.WrapError("deleting bucket", bucketName, err)
 	}
 
@@ -147,33 +224,48 @@ func (c *GCPStorageClient) DeleteBucket(ctx context.Context, bucketName string)
 }
 
 // ListBuckets lists all buckets in the project
-func (c *GCPStorageClient) ListBuckets(ctx context.Context, projectID string) ([]*models.BucketResponse, error) {
+func (c *GCPStorageClient) ListBuckets(ctx context.Context, projectID string) (_ []*models.BucketResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.ListBuckets", attribute.String("gcp.project_id", projectID))
+	defer func() { endSpan(span, err) }()
+
 	if projectID == "" {
 		projectID = c.projectID
 	}
 
 	var buckets []*models.BucketResponse
-	it := c.client.Buckets(ctx, projectID)
-
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
-			break
+	err = c.retry.Start("ListBuckets").Do(ctx, call("buckets.list", true), func(ctx context.Context) error {
+		// A retry lists the buckets again from the start
+		buckets = nil
+		it := c.client.Buckets(ctx, projectID)
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			buckets = append(buckets, c.mapBucketAttrsToResponse(attrs))
 		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to list buckets: %w", err)
-		}
-
-		buckets = append(buckets, c.mapBucketAttrsToResponse(attrs))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
 	}
 
 	return buckets, nil
 }
 
 // BucketExists checks if a bucket exists
-func (c *GCPStorageClient) BucketExists(ctx context.Context, bucketName string) (bool, error) {
+func (c *GCPStorageClient) BucketExists(ctx context.Context, bucketName string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.BucketExists", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
-	_, err := bucket.Attrs(ctx)
+	err = c.retry.Start("BucketExists").Do(ctx, call("buckets.get", true), func(ctx context.Context) error {
+		_, err := bucket.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		if err == storage.ErrBucketNotExist {
 			return false, nil
@@ -184,11 +276,20 @@ func (c *GCPStorageClient) BucketExists(ctx context.Context, bucketName string)
 }
 
 // UpdateBucket updates a GCS bucket (simplified version)
-func (c *GCPStorageClient) UpdateBucket(ctx context.Context, bucketName string, req *models.BucketUpdateRequest) (*models.BucketResponse, error) {
+func (c *GCPStorageClient) UpdateBucket(ctx context.Context, bucketName string, req *models.BucketUpdateRequest) (_ *models.BucketResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.UpdateBucket", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
 
+	budget := c.retry.Start("UpdateBucket")
+
 	// Get current attributes first
-	attrs, err := bucket.Attrs(ctx)
+	var attrs *storage.BucketAttrs
+	err = budget.Do(ctx, call("buckets.get", true), func(ctx context.Context) (err error) {
+		attrs, err = bucket.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current bucket attributes: %w", err)
 	}
@@ -199,7 +300,10 @@ func (c *GCPStorageClient) UpdateBucket(ctx context.Context, bucketName string,
 			VersioningEnabled: req.Versioning,
 		}
 
-		attrs, err = bucket.Update(ctx, attrsToUpdate)
+		err = budget.Do(ctx, call("buckets.patch", false), func(ctx context.Context) (err error) {
+			attrs, err = bucket.Update(ctx, attrsToUpdate)
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to update bucket: %w", err)
 		}
@@ -209,7 +313,11 @@ func (c *GCPStorageClient) UpdateBucket(ctx context.Context, bucketName string,
 }
 
 // UploadObject uploads an object to a bucket
-func (c *GCPStorageClient) UploadObject(ctx context.Context, bucketName, objectName string, data io.Reader) (*models.ObjectResponse, error) {
+func (c *GCPStorageClient) UploadObject(ctx context.Context, bucketName, objectName string, data io.Reader) (_ *models.ObjectResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.UploadObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { endSpan(span, err) }()
+
 	if err := gcp.ValidateBucketName(bucketName); err != nil {
 		return nil, gcp.WrapError("uploading object", bucketName+"/"+objectName, err)
 	}
@@ -231,8 +339,15 @@ func (c *GCPStorageClient) UploadObject(ctx context.Context, bucketName, objectN
 		return nil, gcp.WrapError("closing object writer after upload", bucketName+"/"+objectName, err)
 	}
 
-	// Get object attributes
-	attrs, err := obj.Attrs(ctx)
+	// Get object attributes. The upload itself is not retried, as data cannot
+	// be read again.
+	var attrs *storage.ObjectAttrs
+	getCall := call("objects.get", true)
+	getCall.RetryNotFound = true
+	err = c.retry.Start("UploadObject").Do(ctx, getCall, func(ctx context.Context) (err error) {
+		attrs, err = obj.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, gcp.WrapError("getting object attributes after upload", bucketName+"/"+objectName, err)
 	}
@@ -241,7 +356,11 @@ func (c *GCPStorageClient) UploadObject(ctx context.Context, bucketName, objectN
 }
 
 // DownloadObject downloads an object from a bucket
-func (c *GCPStorageClient) DownloadObject(ctx context.Context, bucketName, objectName string) (io.ReadCloser, error) {
+func (c *GCPStorageClient) DownloadObject(ctx context.Context, bucketName, objectName string) (_ io.ReadCloser, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.DownloadObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { endSpan(span, err) }()
+
 	if err := gcp.ValidateBucketName(bucketName); err != nil {
 		return nil, gcp.WrapError("downloading object", bucketName+"/"+objectName, err)
 	}
@@ -253,7 +372,11 @@ func (c *GCPStorageClient) DownloadObject(ctx context.Context, bucketName, objec
 	bucket := c.client.Bucket(bucketName)
 	obj := bucket.Object(objectName)
 
-	reader, err := obj.NewReader(ctx)
+	var reader *storage.Reader
+	err = c.retry.Start("DownloadObject").Do(ctx, call("objects.get", true), func(ctx context.Context) (err error) {
+		reader, err = obj.NewReader(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, gcp.WrapError("downloading object", bucketName+"/"+objectName, err)
 	}
@@ -262,7 +385,11 @@ func (c *GCPStorageClient) DownloadObject(ctx context.Context, bucketName, objec
 }
 
 // DeleteObject deletes an object from a bucket
-func (c *GCPStorageClient) DeleteObject(ctx context.Context, bucketName, objectName string) error {
+func (c *GCPStorageClient) DeleteObject(ctx context.Context, bucketName, objectName string) (err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.DeleteObject",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { endSpan(span, err) }()
+
 	if err := gcp.ValidateBucketName(bucketName); err != nil {
 		return gcp.WrapError("deleting object", bucketName+"/"+objectName, err)
 	}
@@ -274,7 +401,22 @@ func (c *GCPStorageClient) DeleteObject(ctx context.Context, bucketName, objectN
 	bucket := c.client.Bucket(bucketName)
 	obj := bucket.Object(objectName)
 
-	if err := obj.Delete(ctx); err != nil {
+	budget := c.retry.Start("DeleteObject")
+
+	var attrs *storage.ObjectAttrs
+	err = budget.Do(ctx, call("objects.get", true), func(ctx context.Context) (err error) {
+		attrs, err = obj.Attrs(ctx)
+		return err
+	})
+	if err != nil {
+		return gcp.WrapError("deleting object", bucketName+"/"+objectName, err)
+	}
+
+	// Deleting only the generation that was read makes retries safe: a retry
+	// cannot delete a newer generation uploaded after an earlier attempt
+	deleteCall := call("objects.delete", true)
+	deleteCall.Delete = true
+	if err := budget.Do(ctx, deleteCall, obj.If(storage.Conditions{GenerationMatch: attrs.Generation}).Delete); err != nil {
 		return gcp.WrapError("deleting object", bucketName+"/"+objectName, err)
 	}
 
@@ -282,34 +424,51 @@ func (c *GCPStorageClient) DeleteObject(ctx context.Context, bucketName, objectN
 }
 
 // ListObjects lists objects in a bucket
-func (c *GCPStorageClient) ListObjects(ctx context.Context, bucketName string, prefix string) ([]*models.ObjectResponse, error) {
+func (c *GCPStorageClient) ListObjects(ctx context.Context, bucketName string, prefix string) (_ []*models.ObjectResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.ListObjects", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
 
 	query := &storage.Query{Prefix: prefix}
-	it := bucket.Objects(ctx, query)
 
 	var objects []*models.ObjectResponse
-	for {
-		attrs, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %w", err)
+	err = c.retry.Start("ListObjects").Do(ctx, call("objects.list", true), func(ctx context.Context) error {
+		// A retry lists the objects again from the start
+		objects = nil
+		it := bucket.Objects(ctx, query)
+		for {
+			attrs, err := it.Next()
+			if err == iterator.Done {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			objects = append(objects, c.mapObjectAttrsToResponse(attrs))
 		}
-
-		objects = append(objects, c.mapObjectAttrsToResponse(attrs))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
 	}
 
 	return objects, nil
 }
 
 // ObjectExists checks if an object exists
-func (c *GCPStorageClient) ObjectExists(ctx context.Context, bucketName, objectName string) (bool, error) {
+func (c *GCPStorageClient) ObjectExists(ctx context.Context, bucketName, objectName string) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.ObjectExists",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
 	obj := bucket.Object(objectName)
 
-	_, err := obj.Attrs(ctx)
+	err = c.retry.Start("ObjectExists").Do(ctx, call("objects.get", true), func(ctx context.Context) error {
+		_, err := obj.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		if err == storage.ErrObjectNotExist {
 			return false, nil
@@ -321,11 +480,19 @@ func (c *GCPStorageClient) ObjectExists(ctx context.Context, bucketName, objectN
 }
 
 // GetObjectMetadata retrieves object metadata
-func (c *GCPStorageClient) GetObjectMetadata(ctx context.Context, bucketName, objectName string) (*models.ObjectResponse, error) {
+func (c *GCPStorageClient) GetObjectMetadata(ctx context.Context, bucketName, objectName string) (_ *models.ObjectResponse, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.GetObjectMetadata",
+		attribute.String("gcp.bucket", bucketName), attribute.String("gcp.object", objectName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
 	obj := bucket.Object(objectName)
 
-	attrs, err := obj.Attrs(ctx)
+	var attrs *storage.ObjectAttrs
+	err = c.retry.Start("GetObjectMetadata").Do(ctx, call("objects.get", true), func(ctx context.Context) (err error) {
+		attrs, err = obj.Attrs(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object metadata: %w", err)
 	}
@@ -368,11 +535,18 @@ func (c *GCPStorageClient) GetBucketIAM(ctx context.Context, bucketName string)
 }
 
 // TestBucketIAM tests IAM permissions for a bucket (simplified implementation)
-func (c *GCPStorageClient) TestBucketIAM(ctx context.Context, bucketName string, permissions []string) ([]string, error) {
+func (c *GCPStorageClient) TestBucketIAM(ctx context.Context, bucketName string, permissions []string) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "GCPStorageClient.TestBucketIAM", attribute.String("gcp.bucket", bucketName))
+	defer func() { endSpan(span, err) }()
+
 	bucket := c.client.Bucket(bucketName)
 	handle := bucket.IAM()
 
-	perms, err := handle.TestPermissions(ctx, permissions)
+	var perms []string
+	err = c.retry.Start("TestBucketIAM").Do(ctx, call("buckets.testIamPermissions", true), func(ctx context.Context) (err error) {
+		perms, err = handle.TestPermissions(ctx, permissions)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to test bucket IAM permissions: %w", err)
 	}
@@ -2,6 +2,7 @@ package sdk
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 	"github.com/stuartshay/gcp-automation-api/pkg/validation/gcp"
@@ -133,3 +134,23 @@ func TestGCPStorageClient_ListBuckets_Integration(t *testing.T) {
 	// Test listing buckets with real GCP client
 }
 */
+
+// TestGCPStorageClient_SetRetryPolicy tests that retry budgets are applied
+// per method
+func TestGCPStorageClient_SetRetryPolicy(t *testing.T) {
+	client := &GCPStorageClient{}
+	client.SetRetryPolicy(RetryPolicy{
+		MaxRetries: 2,
+		Budgets:    map[string]int{"CreateBucket": 5},
+		MaxBackoff: time.Second,
+	})
+
+	if client.retry.MaxRetries != 2 || client.retry.Budgets["CreateBucket"] != 5 || client.retry.MaxBackoff != time.Second {
+		t.Errorf("retry policy not applied: %+v", client.retry)
+	}
+
+	defaults := DefaultRetryPolicy()
+	if defaults.MaxRetries != 3 {
+		t.Errorf("DefaultRetryPolicy().MaxRetries = %d, want 3", defaults.MaxRetries)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/audit"
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func setupAuditRoutes(t *testing.T, viewerGroups []string) (*echo.Echo, string) {
+	return setupAuditRoutesAs(t, viewerGroups, "security-team")
+}
+
+// setupAuditRoutesAs serves the audit routes to a caller in groups
+func setupAuditRoutesAs(t *testing.T, viewerGroups []string, groups ...string) (*echo.Echo, string) {
+	e, _, authService := setupTestServer(t)
+
+	sink, err := audit.NewJSONLSink(audit.JSONLOptions{Path: filepath.Join(t.TempDir(), "audit.jsonl")})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sink.Close() })
+
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("DeleteBucket", "old-bucket").Return(nil)
+	handler := handlers.NewHandler(gcpService, authService).WithAuditLog(sink, viewerGroups)
+
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(), audit.Middleware(sink, 1024))
+	v1.DELETE("/buckets/:name", handler.DeleteBucket)
+	v1.GET("/audit", handler.ListAuditEntries)
+
+	return e, generateTestJWT(t, authService, groups...)
+}
+
+func TestAuditLogRecordsWhoDeletedABucket(t *testing.T) {
+	e, token := setupAuditRoutes(t, []string{"security-team"})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/buckets/old-bucket", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/audit?resource_type=buckets&resource=old-bucket&method=DELETE", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data []models.AuditEntry `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "test@example.com", response.Data[0].Email)
+	assert.Equal(t, models.AuditOutcomeSuccess, response.Data[0].Outcome)
+}
+
+func TestAuditLogQueryValidation(t *testing.T) {
+	e, token := setupAuditRoutes(t, []string{"security-team"})
+
+	for _, query := range []string{"since=yesterday", "limit=5000", "outcome=maybe"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+query, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestAuditLogRequiresViewerGroup(t *testing.T) {
+	e, token := setupAuditRoutesAs(t, []string{"security-team"}, "developers")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuditLogViewerGroupsIgnoreCase(t *testing.T) {
+	e, token := setupAuditRoutesAs(t, []string{"Security-Team@Example.com"}, "security-team@example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestAuditLogDeniedWithoutViewerGroups(t *testing.T) {
+	e, token := setupAuditRoutes(t, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
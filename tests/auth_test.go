@@ -9,8 +9,10 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stuartshay/gcp-automation-api/internal/config"
 	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/identity"
 	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 	"github.com/stuartshay/gcp-automation-api/internal/services"
@@ -36,13 +38,14 @@ func setupTestServer(t *testing.T) (*echo.Echo, *handlers.Handler, *services.Aut
 
 	// Create Echo instance
 	e := echo.New()
+	e.HTTPErrorHandler = handlers.ErrorHandler
 
 	return e, handler, authService
 }
 
-// generateTestJWT creates a valid JWT token for testing
-func generateTestJWT(t *testing.T, authService *services.AuthService) string {
-	token, err := authService.GenerateTestJWT("test-user-123", "test@example.com", "Test User")
+// generateTestJWT creates a valid JWT token for testing, carrying groups
+func generateTestJWT(t *testing.T, authService *services.AuthService, groups ...string) string {
+	token, err := authService.GenerateTestJWT("test-user-123", "test@example.com", "Test User", groups...)
 	if err != nil {
 		t.Fatalf("Failed to generate test JWT: %v", err)
 	}
@@ -55,7 +58,7 @@ func TestJWTMiddleware(t *testing.T) {
 	cfg := &config.Config{
 		JWTSecret: "test-secret-key-for-testing-only",
 	}
-	authMiddleware := authmiddleware.NewAuthMiddleware(cfg)
+	authMiddleware := authmiddleware.NewAuthMiddleware(cfg, identity.NewRegistry(cfg))
 
 	// Create a test endpoint
 	e.GET("/protected", func(c echo.Context) error {
@@ -137,7 +140,7 @@ func TestProtectedEndpointRequiresAuth(t *testing.T) {
 	cfg := &config.Config{
 		JWTSecret: "test-secret-key-for-testing-only",
 	}
-	authMiddleware := authmiddleware.NewAuthMiddleware(cfg)
+	authMiddleware := authmiddleware.NewAuthMiddleware(cfg, identity.NewRegistry(cfg))
 
 	// Simulate a protected API endpoint
 	e.GET("/api/v1/projects/test", func(c echo.Context) error {
@@ -157,3 +160,34 @@ func TestProtectedEndpointRequiresAuth(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, strings.ToLower(response.Message), "invalid or missing jwt")
 }
+
+func TestJWTUserIDNamespacedByProvider(t *testing.T) {
+	_, _, authService := setupTestServer(t)
+
+	claims, err := authService.ValidateJWT(generateTestJWT(t, authService))
+	assert.NoError(t, err)
+	assert.Equal(t, "google:test-user-123", claims.UserID)
+	assert.Equal(t, "google:test-user-123", claims.Subject)
+	assert.Equal(t, "test-user-123", claims.GoogleSub)
+
+	// Refreshing keeps the same user ID
+	refreshed, err := authService.RefreshJWT(claims)
+	assert.NoError(t, err)
+	claims, err = authService.ValidateJWT(refreshed)
+	assert.NoError(t, err)
+	assert.Equal(t, "google:test-user-123", claims.UserID)
+}
+
+func TestRefreshJWTWithoutEmailVerifiedClaimRequiresLogin(t *testing.T) {
+	_, _, authService := setupTestServer(t)
+
+	claims, err := authService.ValidateJWT(generateTestJWT(t, authService))
+	require.NoError(t, err)
+	require.NotNil(t, claims.EmailVerified)
+	assert.True(t, *claims.EmailVerified)
+
+	// Tokens issued before the claim existed do not carry it
+	claims.EmailVerified = nil
+	_, err = authService.RefreshJWT(claims)
+	assert.ErrorIs(t, err, services.ErrLoginRequired)
+}
@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/stuartshay/gcp-automation-api/internal/gcperrors"
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func TestDryRunDescribesChangesWithoutMakingThem(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService)
+	v1 := e.Group("/api/v1",
+		authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(),
+		idempotency.Middleware(idempotency.NewMemoryStore(idempotency.Options{TTL: time.Hour})))
+	v1.POST("/buckets", handler.CreateBucket)
+	v1.DELETE("/buckets/:name", handler.DeleteBucket)
+
+	plan := &models.DryRunResponse{
+		Operation: "CreateBucket",
+		Resource:  "buckets/my-new-bucket",
+		Request:   models.GCPRequest{Method: "POST", URL: "https://storage.googleapis.com/storage/v1/b?project=test-project"},
+		Diff:      []models.FieldChange{{Field: "name", Proposed: "my-new-bucket"}},
+		Checks:    []models.DryRunCheck{{Name: "name_available", Status: "passed"}},
+	}
+	gcpService.On("PlanCreateBucket", mock.Anything).Return(plan, nil)
+	gcpService.On("PlanDeleteBucket", "full").Return(nil, gcperrors.New(codes.FailedPrecondition, "bucket full is not empty"))
+
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(idempotency.HeaderIdempotencyKey, "change-42")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"name":"my-new-bucket","location":"US"}`
+	rec := send(http.MethodPost, "/api/v1/buckets?dry_run=true", body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data models.DryRunResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, *plan, response.Data)
+
+	// Validation runs as for the real request
+	rec = send(http.MethodPost, "/api/v1/buckets?dry_run=true", `{"name":"BAD","location":"US"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Failed checks report the error the real request would fail with
+	rec = send(http.MethodDelete, "/api/v1/buckets/full?dry_run=true", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "FAILED_PRECONDITION")
+
+	// A mistyped dry run is rejected rather than executed
+	rec = send(http.MethodDelete, "/api/v1/buckets/full?dry_run=yes", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// The dry run's Idempotency-Key is still free for the real request
+	gcpService.On("CreateBucket", mock.Anything).Return(&models.BucketResponse{Name: "my-new-bucket"}, nil)
+	rec = send(http.MethodPost, "/api/v1/buckets", body)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	gcpService.AssertNotCalled(t, "DeleteBucket", mock.Anything)
+	gcpService.AssertNumberOfCalls(t, "CreateBucket", 1)
+}
@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/services"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func TestGCPErrorsMapToHTTPStatus(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService)
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth())
+	v1.GET("/buckets/:name", handler.GetBucket)
+	v1.POST("/buckets", handler.CreateBucket)
+	v1.DELETE("/buckets/:name", handler.DeleteBucket)
+	v1.GET("/buckets/:name/objects/*", handler.GetObject)
+
+	gcpService.On("GetBucket", "missing").Return(nil, fmt.Errorf("failed to get bucket: %w", storage.ErrBucketNotExist))
+	gcpService.On("GetBucket", "secret").Return(nil, &googleapi.Error{Code: http.StatusForbidden, Message: "caller lacks storage.buckets.get"})
+	gcpService.On("GetBucket", "slow").Return(nil, fmt.Errorf("failed to get bucket: %w", context.DeadlineExceeded))
+	gcpService.On("GetBucket", "broken").Return(nil, fmt.Errorf("unexpected response"))
+	gcpService.On("CreateBucket", mock.Anything).Return(nil,
+		fmt.Errorf("failed to create bucket: %w", &googleapi.Error{Code: http.StatusTooManyRequests, Message: "quota exceeded"}))
+	gcpService.On("DeleteBucket", "full").Return(status.Error(codes.FailedPrecondition, "bucket is not empty"))
+	gcpService.On("GetObject", "data", "gone.txt").Return(nil, storage.ErrObjectNotExist)
+
+	send := func(method, path, body string) (int, models.ErrorResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var resp models.ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, rec.Code, resp.Code)
+		return rec.Code, resp
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{http.MethodGet, "/api/v1/buckets/missing", "", http.StatusNotFound, "NOT_FOUND"},
+		{http.MethodGet, "/api/v1/buckets/secret", "", http.StatusForbidden, "PERMISSION_DENIED"},
+		{http.MethodGet, "/api/v1/buckets/slow", "", http.StatusGatewayTimeout, "DEADLINE_EXCEEDED"},
+		{http.MethodGet, "/api/v1/buckets/broken", "", http.StatusInternalServerError, "UNKNOWN"},
+		{http.MethodPost, "/api/v1/buckets", `{"name":"my-new-bucket","location":"US"}`, http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+		{http.MethodDelete, "/api/v1/buckets/full", "", http.StatusBadRequest, "FAILED_PRECONDITION"},
+		{http.MethodGet, "/api/v1/buckets/data/objects/gone.txt", "", http.StatusNotFound, "NOT_FOUND"},
+		{http.MethodGet, "/api/v1/nothing-here", "", http.StatusNotFound, "NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		code, resp := send(tt.method, tt.path, tt.body)
+		assert.Equal(t, tt.status, code, tt.path)
+		assert.Equal(t, tt.code, resp.Error, tt.path)
+	}
+
+	_, resp := send(http.MethodPost, "/api/v1/buckets", `{"name":"my-new-bucket","location":"US"}`)
+	assert.Equal(t, "failed to create bucket: googleapi: Error 429: quota exceeded", resp.Message)
+}
+
+func TestErrorResponsesUseCodeNames(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+	authMiddleware := authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth()
+
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("CreateBucket", mock.Anything).Return(&models.BucketResponse{Name: "my-new-bucket"}, nil)
+	gcpService.On("GetBucket", "data").Return(&models.BucketResponse{Name: "data"}, nil)
+	handler := handlers.NewHandler(gcpService, authService)
+	v1 := e.Group("/api/v1", authMiddleware,
+		idempotency.Middleware(idempotency.NewMemoryStore(idempotency.Options{TTL: time.Hour})))
+	v1.POST("/buckets", handler.CreateBucket)
+	v1.DELETE("/buckets/:name", handler.DeleteBucket)
+	v1.GET("/label-schemas", handler.ListLabelSchemas)
+
+	limited := e.Group("/limited", authMiddleware, authmiddleware.NewRateLimiter(&config.RateLimitConfig{
+		Default: config.RouteRateLimits{Read: &config.TokenBucket{PerSecond: 0.1, Burst: 1}},
+	}).Middleware())
+	limited.GET("/buckets/:name", handler.GetBucket)
+
+	unmapped := handlers.NewHandler(gcpService, authService).
+		WithGCPServiceResolver(&stubServiceResolver{err: services.ErrNoImpersonationTarget})
+	e.GET("/unmapped/buckets/:name", unmapped.GetBucket, authMiddleware)
+
+	send := func(method, path, body, key string, authenticated bool) (int, models.ErrorResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if authenticated {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(idempotency.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var resp models.ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp
+	}
+
+	got, _ := send(http.MethodPost, "/api/v1/buckets", `{"name":"my-new-bucket","location":"US"}`, "create-bucket", true)
+	require.Equal(t, http.StatusCreated, got)
+	got, _ = send(http.MethodGet, "/limited/buckets/data", "", "", true)
+	require.Equal(t, http.StatusOK, got)
+
+	tests := []struct {
+		name, method, path, body, key string
+		authenticated                 bool
+		status                        int
+		code                          string
+	}{
+		{"missing token", http.MethodGet, "/api/v1/label-schemas", "", "", false, http.StatusUnauthorized, "UNAUTHENTICATED"},
+		{"malformed body", http.MethodPost, "/api/v1/buckets", `{"name":`, "", true, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"failed validation", http.MethodPost, "/api/v1/buckets", `{"location":"US"}`, "", true, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"invalid dry_run", http.MethodDelete, "/api/v1/buckets/data?dry_run=maybe", "", "", true, http.StatusBadRequest, "INVALID_ARGUMENT"},
+		{"label schemas not configured", http.MethodGet, "/api/v1/label-schemas", "", "", true, http.StatusServiceUnavailable, "UNAVAILABLE"},
+		{"no GCP identity", http.MethodGet, "/unmapped/buckets/data", "", "", true, http.StatusForbidden, "PERMISSION_DENIED"},
+		{"rate limited", http.MethodGet, "/limited/buckets/data", "", "", true, http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+		{"idempotency key reused", http.MethodPost, "/api/v1/buckets", `{"name":"other-bucket","location":"US"}`, "create-bucket", true, http.StatusBadRequest, "FAILED_PRECONDITION"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resp := send(tt.method, tt.path, tt.body, tt.key, tt.authenticated)
+			assert.Equal(t, tt.status, got)
+			assert.Equal(t, tt.status, resp.Code)
+			assert.Equal(t, tt.code, resp.Error)
+			assert.NotEmpty(t, resp.Message)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/api/googleapi"
+
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/idempotency"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func TestCreateRetryTreatsAlreadyExistsAsSuccess(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService)
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(),
+		idempotency.Middleware(idempotency.NewMemoryStore(idempotency.Options{TTL: time.Hour})))
+	v1.POST("/projects", handler.CreateProject)
+
+	// The first attempt creates the project but fails before responding
+	gcpService.On("CreateProject", mock.Anything).Return(nil, errors.New("failed to get created project: timeout")).Once()
+	alreadyExists := fmt.Errorf("failed to create project: %w", &googleapi.Error{Code: http.StatusConflict})
+	gcpService.On("CreateProject", mock.Anything).Return(nil, alreadyExists)
+	gcpService.On("GetProject", "my-new-project").Return(&models.ProjectResponse{ProjectID: "my-new-project"}, nil)
+
+	send := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/projects",
+			strings.NewReader(`{"project_id":"my-new-project","display_name":"My New Project"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		if key != "" {
+			req.Header.Set(idempotency.HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, send("create-my-new-project"))
+	assert.Equal(t, http.StatusCreated, send("create-my-new-project"))
+
+	// Without a key a conflict is still an error
+	assert.NotEqual(t, http.StatusCreated, send(""))
+	gcpService.AssertExpectations(t)
+}
@@ -0,0 +1,140 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/services"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+// stubServiceResolver hands out a fixed service and records the caller
+type stubServiceResolver struct {
+	service services.GCPServiceInterface
+	caller  services.CallerIdentity
+	err     error
+}
+
+func (r *stubServiceResolver) ServiceFor(ctx context.Context, caller services.CallerIdentity) (services.GCPServiceInterface, error) {
+	r.caller = caller
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.service, nil
+}
+
+func (r *stubServiceResolver) Close() error {
+	return nil
+}
+
+func TestImpersonationServiceAccountFor(t *testing.T) {
+	resolver := services.NewImpersonatingGCPServiceResolver(&config.Config{
+		GCPImpersonation: &config.ImpersonationConfig{
+			Users: map[string]string{
+				"alice@example.com": "alice-sa@proj.iam.gserviceaccount.com",
+			},
+			Groups: []config.GroupServiceAccount{
+				{Group: "platform-admins@example.com", ServiceAccount: "admin-sa@proj.iam.gserviceaccount.com"},
+				{Group: "developers@example.com", ServiceAccount: "dev-sa@proj.iam.gserviceaccount.com"},
+			},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		caller    services.CallerIdentity
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "User mapping wins over groups",
+			caller:   services.CallerIdentity{Email: "Alice@example.com", EmailVerified: true, Groups: []string{"developers@example.com"}},
+			expected: "alice-sa@proj.iam.gserviceaccount.com",
+		},
+		{
+			name:      "Unverified email does not get the user mapping",
+			caller:    services.CallerIdentity{Email: "alice@example.com"},
+			expectErr: true,
+		},
+		{
+			name:     "Unverified email still gets group mappings",
+			caller:   services.CallerIdentity{Email: "alice@example.com", Groups: []string{"developers@example.com"}},
+			expected: "dev-sa@proj.iam.gserviceaccount.com",
+		},
+		{
+			name:     "First matching group",
+			caller:   services.CallerIdentity{Email: "bob@example.com", EmailVerified: true, Groups: []string{"developers@example.com", "platform-admins@example.com"}},
+			expected: "admin-sa@proj.iam.gserviceaccount.com",
+		},
+		{
+			name:      "Unmapped caller rejected",
+			caller:    services.CallerIdentity{Email: "eve@example.com", EmailVerified: true},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sa, err := resolver.ServiceAccountFor(tt.caller)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, services.ErrNoImpersonationTarget)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sa)
+		})
+	}
+}
+
+func TestHandlerUsesResolvedServiceForCaller(t *testing.T) {
+	e, handler, authService := setupTestServer(t)
+
+	impersonated := &mocks.MockGCPService{}
+	impersonated.On("GetBucket", "team-bucket").Return(&models.BucketResponse{Name: "team-bucket"}, nil)
+
+	resolver := &stubServiceResolver{service: impersonated}
+	handler.WithGCPServiceResolver(resolver)
+
+	authMiddleware := authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders())
+	e.GET("/api/v1/buckets/:name", handler.GetBucket, authMiddleware.RequireAuth())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/team-bucket", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+generateTestJWT(t, authService))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "test@example.com", resolver.caller.Email)
+	assert.True(t, resolver.caller.EmailVerified)
+	impersonated.AssertExpectations(t)
+}
+
+func TestHandlerRejectsUnmappedCaller(t *testing.T) {
+	e, handler, authService := setupTestServer(t)
+
+	handler.WithGCPServiceResolver(&stubServiceResolver{err: services.ErrNoImpersonationTarget})
+
+	authMiddleware := authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders())
+	e.DELETE("/api/v1/buckets/:name", handler.DeleteBucket, authMiddleware.RequireAuth())
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/buckets/team-bucket", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+generateTestJWT(t, authService))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	var response models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(t, response.Message, "no GCP service account is mapped")
+}
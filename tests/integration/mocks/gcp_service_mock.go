@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -14,7 +15,7 @@ type MockGCPService struct {
 }
 
 // CreateProject mocks the CreateProject method
-func (m *MockGCPService) CreateProject(req *models.ProjectRequest) (*models.ProjectResponse, error) {
+func (m *MockGCPService) CreateProject(ctx context.Context, req *models.ProjectRequest) (*models.ProjectResponse, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -23,7 +24,7 @@ func (m *MockGCPService) CreateProject(req *models.ProjectRequest) (*models.Proj
 }
 
 // GetProject mocks the GetProject method
-func (m *MockGCPService) GetProject(projectID string) (*models.ProjectResponse, error) {
+func (m *MockGCPService) GetProject(ctx context.Context, projectID string) (*models.ProjectResponse, error) {
 	args := m.Called(projectID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,13 +33,22 @@ func (m *MockGCPService) GetProject(projectID string) (*models.ProjectResponse,
 }
 
 // DeleteProject mocks the DeleteProject method
-func (m *MockGCPService) DeleteProject(projectID string) error {
+func (m *MockGCPService) DeleteProject(ctx context.Context, projectID string) error {
 	args := m.Called(projectID)
 	return args.Error(0)
 }
 
+// ListProjects mocks the ListProjects method
+func (m *MockGCPService) ListProjects(ctx context.Context) ([]*models.ProjectResponse, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ProjectResponse), args.Error(1)
+}
+
 // CreateFolder mocks the CreateFolder method
-func (m *MockGCPService) CreateFolder(req *models.FolderRequest) (*models.FolderResponse, error) {
+func (m *MockGCPService) CreateFolder(ctx context.Context, req *models.FolderRequest) (*models.FolderResponse, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -47,7 +57,7 @@ func (m *MockGCPService) CreateFolder(req *models.FolderRequest) (*models.Folder
 }
 
 // GetFolder mocks the GetFolder method
-func (m *MockGCPService) GetFolder(folderID string) (*models.FolderResponse, error) {
+func (m *MockGCPService) GetFolder(ctx context.Context, folderID string) (*models.FolderResponse, error) {
 	args := m.Called(folderID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -56,13 +66,13 @@ func (m *MockGCPService) GetFolder(folderID string) (*models.FolderResponse, err
 }
 
 // DeleteFolder mocks the DeleteFolder method
-func (m *MockGCPService) DeleteFolder(folderID string) error {
+func (m *MockGCPService) DeleteFolder(ctx context.Context, folderID string) error {
 	args := m.Called(folderID)
 	return args.Error(0)
 }
 
 // CreateBucket mocks the CreateBucket method
-func (m *MockGCPService) CreateBucket(req *models.BucketRequest) (*models.BucketResponse, error) {
+func (m *MockGCPService) CreateBucket(ctx context.Context, req *models.BucketRequest) (*models.BucketResponse, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -71,7 +81,7 @@ func (m *MockGCPService) CreateBucket(req *models.BucketRequest) (*models.Bucket
 }
 
 // GetBucket mocks the GetBucket method
-func (m *MockGCPService) GetBucket(bucketName string) (*models.BucketResponse, error) {
+func (m *MockGCPService) GetBucket(ctx context.Context, bucketName string) (*models.BucketResponse, error) {
 	args := m.Called(bucketName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -80,11 +90,107 @@ func (m *MockGCPService) GetBucket(bucketName string) (*models.BucketResponse, e
 }
 
 // DeleteBucket mocks the DeleteBucket method
-func (m *MockGCPService) DeleteBucket(bucketName string) error {
+func (m *MockGCPService) DeleteBucket(ctx context.Context, bucketName string) error {
 	args := m.Called(bucketName)
 	return args.Error(0)
 }
 
+// ListBuckets mocks the ListBuckets method
+func (m *MockGCPService) ListBuckets(ctx context.Context) ([]*models.BucketResponse, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BucketResponse), args.Error(1)
+}
+
+// ListObjects mocks the ListObjects method
+func (m *MockGCPService) ListObjects(ctx context.Context, req *models.ObjectListRequest) (*models.ObjectListResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ObjectListResponse), args.Error(1)
+}
+
+// GetObject mocks the GetObject method
+func (m *MockGCPService) GetObject(ctx context.Context, bucketName, objectName string) (*models.ObjectResponse, error) {
+	args := m.Called(bucketName, objectName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ObjectResponse), args.Error(1)
+}
+
+// DeleteObject mocks the DeleteObject method
+func (m *MockGCPService) DeleteObject(ctx context.Context, bucketName, objectName string) error {
+	args := m.Called(bucketName, objectName)
+	return args.Error(0)
+}
+
+// PlanCreateProject mocks the PlanCreateProject method
+func (m *MockGCPService) PlanCreateProject(ctx context.Context, req *models.ProjectRequest) (*models.DryRunResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanDeleteProject mocks the PlanDeleteProject method
+func (m *MockGCPService) PlanDeleteProject(ctx context.Context, projectID string) (*models.DryRunResponse, error) {
+	args := m.Called(projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanCreateFolder mocks the PlanCreateFolder method
+func (m *MockGCPService) PlanCreateFolder(ctx context.Context, req *models.FolderRequest) (*models.DryRunResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanDeleteFolder mocks the PlanDeleteFolder method
+func (m *MockGCPService) PlanDeleteFolder(ctx context.Context, folderID string) (*models.DryRunResponse, error) {
+	args := m.Called(folderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanCreateBucket mocks the PlanCreateBucket method
+func (m *MockGCPService) PlanCreateBucket(ctx context.Context, req *models.BucketRequest) (*models.DryRunResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanDeleteBucket mocks the PlanDeleteBucket method
+func (m *MockGCPService) PlanDeleteBucket(ctx context.Context, bucketName string) (*models.DryRunResponse, error) {
+	args := m.Called(bucketName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
+// PlanDeleteObject mocks the PlanDeleteObject method
+func (m *MockGCPService) PlanDeleteObject(ctx context.Context, bucketName, objectName string) (*models.DryRunResponse, error) {
+	args := m.Called(bucketName, objectName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DryRunResponse), args.Error(1)
+}
+
 // Close mocks the Close method
 func (m *MockGCPService) Close() error {
 	args := m.Called()
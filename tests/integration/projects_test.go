@@ -13,6 +13,7 @@ import (
 	"github.com/stuartshay/gcp-automation-api/internal/middleware"
 	"github.com/stuartshay/gcp-automation-api/internal/models"
 	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+	"google.golang.org/api/googleapi"
 )
 
 func TestProjectOperations(t *testing.T) {
@@ -20,7 +21,7 @@ func TestProjectOperations(t *testing.T) {
 	defer CleanupTestResources(t, setup)
 
 	// Setup authentication middleware
-	authMiddleware := middleware.NewAuthMiddleware(setup.AuthService.GetConfig())
+	authMiddleware := middleware.NewAuthMiddleware(setup.AuthService.GetConfig(), setup.AuthService.IdentityProviders())
 
 	// Setup routes
 	setup.Echo.POST("/api/v1/projects", setup.Handler.CreateProject, authMiddleware.RequireAuth())
@@ -144,7 +145,7 @@ func testCreateProject(t *testing.T, setup *TestSetup, token string) {
 				m.On("CreateProject", mock.AnythingOfType("*models.ProjectRequest")).Return(nil, fmt.Errorf("GCP service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "Failed to create project",
+			expectedError:  "UNKNOWN",
 		},
 	}
 
@@ -228,10 +229,11 @@ func testGetProject(t *testing.T, setup *TestSetup, token string) {
 				if setup.Config.UseRealGCP {
 					return
 				}
-				m.On("GetProject", "non-existent-project").Return(nil, fmt.Errorf("project not found"))
+				m.On("GetProject", "non-existent-project").Return(nil,
+					fmt.Errorf("failed to get project: %w", &googleapi.Error{Code: http.StatusNotFound, Message: "project not found"}))
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "Project not found",
+			expectedError:  "NOT_FOUND",
 		},
 		{
 			name:      "Empty project ID",
@@ -240,7 +242,7 @@ func testGetProject(t *testing.T, setup *TestSetup, token string) {
 				// No mock setup needed as validation happens before service call
 			},
 			expectedStatus: http.StatusNotFound, // Echo returns 404 for empty path params
-			expectedError:  "Not Found",
+			expectedError:  "NOT_FOUND",
 		},
 	}
 
@@ -309,10 +311,11 @@ func testDeleteProject(t *testing.T, setup *TestSetup, token string) {
 				if setup.Config.UseRealGCP {
 					return
 				}
-				m.On("DeleteProject", "non-existent-project").Return(fmt.Errorf("project not found"))
+				m.On("DeleteProject", "non-existent-project").Return(
+					fmt.Errorf("failed to delete project: %w", &googleapi.Error{Code: http.StatusNotFound, Message: "project not found"}))
 			},
-			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "Failed to delete project",
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "NOT_FOUND",
 		},
 		{
 			name:      "Empty project ID",
@@ -321,7 +324,7 @@ func testDeleteProject(t *testing.T, setup *TestSetup, token string) {
 				// No mock setup needed as validation happens before service call
 			},
 			expectedStatus: http.StatusNotFound, // Echo returns 404 for empty path params
-			expectedError:  "Not Found",
+			expectedError:  "NOT_FOUND",
 		},
 	}
 
@@ -372,7 +375,7 @@ func testProjectValidation(t *testing.T, setup *TestSetup, token string) {
 				"display_name": "Missing Project ID",
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Validation failed",
+			expectedError:  "INVALID_ARGUMENT",
 		},
 		{
 			name: "Missing display name",
@@ -380,7 +383,7 @@ func testProjectValidation(t *testing.T, setup *TestSetup, token string) {
 				"project_id": "missing-display-name",
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Validation failed",
+			expectedError:  "INVALID_ARGUMENT",
 		},
 		{
 			name: "Invalid parent type",
@@ -391,13 +394,13 @@ func testProjectValidation(t *testing.T, setup *TestSetup, token string) {
 				"parent_type":  "invalid",
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Validation failed",
+			expectedError:  "INVALID_ARGUMENT",
 		},
 		{
 			name:           "Invalid JSON",
 			request:        "invalid json",
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid request format",
+			expectedError:  "INVALID_ARGUMENT",
 		},
 	}
 
@@ -433,7 +436,7 @@ func TestProjectAuthenticationRequired(t *testing.T) {
 	defer CleanupTestResources(t, setup)
 
 	// Setup authentication middleware
-	authMiddleware := middleware.NewAuthMiddleware(setup.AuthService.GetConfig())
+	authMiddleware := middleware.NewAuthMiddleware(setup.AuthService.GetConfig(), setup.AuthService.IdentityProviders())
 
 	// Setup routes
 	setup.Echo.POST("/api/v1/projects", setup.Handler.CreateProject, authMiddleware.RequireAuth())
@@ -481,7 +484,7 @@ func TestProjectAuthenticationRequired(t *testing.T) {
 
 			// Should return 401 Unauthorized
 			assert.Equal(t, http.StatusUnauthorized, rec.Code)
-			AssertErrorResponse(t, rec.Body.Bytes(), http.StatusUnauthorized, "unauthorized")
+			AssertErrorResponse(t, rec.Body.Bytes(), http.StatusUnauthorized, "UNAUTHENTICATED")
 		})
 	}
 }
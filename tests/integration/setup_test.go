@@ -39,6 +39,7 @@ func SetupTestServer(t *testing.T) *TestSetup {
 
 	// Create Echo instance
 	e := echo.New()
+	e.HTTPErrorHandler = handlers.ErrorHandler
 
 	// Load application config
 	cfg := &config.Config{
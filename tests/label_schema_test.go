@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	"github.com/stuartshay/gcp-automation-api/internal/labels"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+const bucketLabelSchema = `{"keys": [
+	{"key": "environment", "required": true, "allowed_values": ["dev", "staging", "prod"], "default": "dev"},
+	{"key": "cost_center", "required": true, "pattern": "cc-[0-9]{4}"},
+	{"key": "env", "deprecated": true, "replaced_by": "environment"}
+]}`
+
+func setupLabelSchemaRoutes(t *testing.T, adminGroups []string) (*mocks.MockGCPService, func(method, target, body string) *httptest.ResponseRecorder) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService, "label-admins")
+
+	registry, err := labels.NewRegistry("")
+	require.NoError(t, err)
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService).WithLabelSchemas(registry, adminGroups)
+
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth())
+	v1.POST("/buckets", handler.CreateBucket)
+	v1.GET("/label-schemas", handler.ListLabelSchemas)
+	v1.GET("/label-schemas/:resource_type", handler.GetLabelSchema)
+	v1.PUT("/label-schemas/:resource_type", handler.PutLabelSchema)
+	v1.DELETE("/label-schemas/:resource_type", handler.DeleteLabelSchema)
+	v1.GET("/label-schemas/:resource_type/compliance", handler.GetLabelCompliance)
+
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	return gcpService, send
+}
+
+func TestLabelSchemaEnforcedOnCreate(t *testing.T) {
+	gcpService, send := setupLabelSchemaRoutes(t, []string{"label-admins"})
+
+	rec := send(http.MethodPut, "/api/v1/label-schemas/bucket", bucketLabelSchema)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = send(http.MethodGet, "/api/v1/label-schemas/bucket", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"updated_by":"test@example.com"`)
+
+	// Labels that break the schema are rejected with each violation
+	rec = send(http.MethodPost, "/api/v1/buckets", `{"name":"my-bucket","location":"US","labels":{"environment":"qa"}}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	var rejected models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rejected))
+	assert.Equal(t, "INVALID_ARGUMENT", rejected.Error)
+	assert.Equal(t, []string{labels.ReasonNotAllowed, labels.ReasonMissing},
+		[]string{rejected.LabelViolations[0].Reason, rejected.LabelViolations[1].Reason})
+
+	// Defaults are added, and deprecated keys are reported as warnings
+	gcpService.On("CreateBucket", mock.MatchedBy(func(req *models.BucketRequest) bool {
+		return req.Labels["environment"] == "dev"
+	})).Return(&models.BucketResponse{Name: "my-bucket"}, nil)
+	rec = send(http.MethodPost, "/api/v1/buckets", `{"name":"my-bucket","location":"US","labels":{"cost_center":"cc-1234","env":"dev"}}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created models.SuccessResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.Len(t, created.LabelWarnings, 1)
+	assert.Equal(t, labels.ReasonDeprecated, created.LabelWarnings[0].Reason)
+
+	// Invalid schemas are rejected
+	rec = send(http.MethodPut, "/api/v1/label-schemas/bucket", `{"keys":[{"key":"Team"}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = send(http.MethodPut, "/api/v1/label-schemas/folder", `{"keys":[{"key":"team"}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Without a schema any labels are accepted
+	rec = send(http.MethodDelete, "/api/v1/label-schemas/bucket", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = send(http.MethodGet, "/api/v1/label-schemas/bucket", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	gcpService.On("CreateBucket", mock.Anything).Return(&models.BucketResponse{Name: "other-bucket"}, nil)
+	rec = send(http.MethodPost, "/api/v1/buckets", `{"name":"other-bucket","location":"US"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestLabelComplianceReport(t *testing.T) {
+	gcpService, send := setupLabelSchemaRoutes(t, []string{"label-admins"})
+
+	rec := send(http.MethodGet, "/api/v1/label-schemas/bucket/compliance", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = send(http.MethodPut, "/api/v1/label-schemas/bucket", bucketLabelSchema)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	gcpService.On("ListBuckets").Return([]*models.BucketResponse{
+		{Name: "good", Labels: map[string]string{"environment": "prod", "cost_center": "cc-0001"}},
+		{Name: "unlabelled"},
+		{Name: "legacy", Labels: map[string]string{"environment": "prod", "cost_center": "cc-0001", "env": "prod"}},
+	}, nil)
+	rec = send(http.MethodGet, "/api/v1/label-schemas/bucket/compliance", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data models.LabelComplianceReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 3, response.Data.Checked)
+	require.Len(t, response.Data.NonCompliant, 2)
+	assert.Equal(t, "unlabelled", response.Data.NonCompliant[0].Name)
+	assert.Len(t, response.Data.NonCompliant[0].Violations, 2)
+	assert.Equal(t, "legacy", response.Data.NonCompliant[1].Name)
+	assert.Equal(t, labels.ReasonDeprecated, response.Data.NonCompliant[1].Violations[0].Reason)
+}
+
+func TestLabelSchemaChangesRequireAdminGroup(t *testing.T) {
+	_, send := setupLabelSchemaRoutes(t, []string{"platform-admins"})
+
+	rec := send(http.MethodPut, "/api/v1/label-schemas/bucket", bucketLabelSchema)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = send(http.MethodDelete, "/api/v1/label-schemas/bucket", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Reading schemas is open to any caller
+	rec = send(http.MethodGet, "/api/v1/label-schemas", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestLabelSchemaChangesDeniedWithoutAdminGroups(t *testing.T) {
+	_, send := setupLabelSchemaRoutes(t, nil)
+
+	rec := send(http.MethodPut, "/api/v1/label-schemas/bucket", bucketLabelSchema)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = send(http.MethodDelete, "/api/v1/label-schemas/bucket", "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestLabelSchemaAdminGroupsIgnoreCase(t *testing.T) {
+	_, send := setupLabelSchemaRoutes(t, []string{"Label-Admins"})
+
+	rec := send(http.MethodPut, "/api/v1/label-schemas/bucket", bucketLabelSchema)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,94 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func setupObjectRoutes(t *testing.T, gcpService *mocks.MockGCPService) (*echo.Echo, string) {
+	e, _, authService := setupTestServer(t)
+	handler := handlers.NewHandler(gcpService, authService)
+
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth())
+	v1.GET("/buckets/:name/objects", handler.ListObjects)
+	v1.GET("/buckets/:name/objects/*", handler.GetObject)
+	v1.DELETE("/buckets/:name/objects/*", handler.DeleteObject)
+	v1.GET("/cloudrun/logs/:serviceName/:region", handler.GetCloudRunLogs)
+
+	return e, generateTestJWT(t, authService)
+}
+
+func TestListObjectsWithPrefix(t *testing.T) {
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("ListObjects", &models.ObjectListRequest{
+		Bucket: "data-bucket", Prefix: "reports/", PageSize: 50, PageToken: "page-2",
+	}).Return(&models.ObjectListResponse{
+		Objects:       []*models.ObjectResponse{{Name: "reports/2025.csv", Bucket: "data-bucket", Size: 42}},
+		NextPageToken: "page-3",
+	}, nil)
+	e, token := setupObjectRoutes(t, gcpService)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/data-bucket/objects?prefix=reports/&page_size=50&page_token=page-2", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Data models.ObjectListResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data.Objects, 1)
+	assert.Equal(t, "reports/2025.csv", response.Data.Objects[0].Name)
+	assert.Equal(t, "page-3", response.Data.NextPageToken)
+	gcpService.AssertExpectations(t)
+}
+
+func TestListObjectsRejectsInvalidPageSize(t *testing.T) {
+	e, token := setupObjectRoutes(t, &mocks.MockGCPService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/data-bucket/objects?page_size=5000", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestObjectNamesMayContainSlashes(t *testing.T) {
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("GetObject", "data-bucket", "reports/2025/q1.csv").Return(&models.ObjectResponse{Name: "reports/2025/q1.csv"}, nil)
+	gcpService.On("DeleteObject", "data-bucket", "reports/2025/q1.csv").Return(nil)
+	e, token := setupObjectRoutes(t, gcpService)
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/api/v1/buckets/data-bucket/objects/reports/2025/q1.csv", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, method)
+	}
+	gcpService.AssertExpectations(t)
+}
+
+func TestCloudRunLogsUnavailableWithoutService(t *testing.T) {
+	e, token := setupObjectRoutes(t, &mocks.MockGCPService{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/cloudrun/logs/my-service/us-central1", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
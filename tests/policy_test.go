@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/internal/policy"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+const guardrails = `
+policies:
+  - name: cost-center
+    resources: [bucket, project]
+    effect: mutate
+    condition: "!('cost_center' in request.labels)"
+    set:
+      labels.cost_center: '"unassigned"'
+  - name: buckets-in-us
+    resources: [bucket]
+    effect: deny
+    condition: "!request.location.startsWith('us')"
+    message: Buckets must be created in a US location
+  - name: versioning
+    resources: [bucket]
+    effect: warn
+    condition: "!request.versioning"
+    message: Buckets should enable versioning
+`
+
+func TestPoliciesGuardCreateRequests(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(guardrails), 0o600))
+	engine, err := policy.Load([]string{path})
+	require.NoError(t, err)
+
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService).WithPolicies(engine)
+	v1 := e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth())
+	v1.POST("/buckets", handler.CreateBucket)
+
+	send := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Denied requests report every matching policy
+	rec := send("/api/v1/buckets", `{"name":"eu-bucket","location":"europe-west1"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+	var denied models.ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &denied))
+	assert.Equal(t, "FAILED_PRECONDITION", denied.Error)
+	assert.Contains(t, denied.Message, "Buckets must be created in a US location")
+	require.Len(t, denied.PolicyDecisions, 3)
+	assert.Equal(t, policy.EffectDeny, denied.PolicyDecisions[1].Effect)
+
+	// Dry runs are denied too
+	rec = send("/api/v1/buckets?dry_run=true", `{"name":"eu-bucket","location":"europe-west1"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Allowed requests are created with the mutations applied
+	gcpService.On("CreateBucket", mock.MatchedBy(func(req *models.BucketRequest) bool {
+		return req.Labels["cost_center"] == "unassigned" && req.Labels["team"] == "data"
+	})).Return(&models.BucketResponse{Name: "us-bucket"}, nil)
+	rec = send("/api/v1/buckets", `{"name":"us-bucket","location":"us-east1","labels":{"team":"data"}}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created models.SuccessResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, []models.PolicyDecision{
+		{
+			Policy:  "cost-center",
+			Effect:  policy.EffectMutate,
+			Changes: []models.FieldChange{{Field: "labels.cost_center", Proposed: "unassigned"}},
+		},
+		{Policy: "versioning", Effect: policy.EffectWarn, Message: "Buckets should enable versioning"},
+	}, created.PolicyDecisions)
+
+	gcpService.AssertNumberOfCalls(t, "CreateBucket", 1)
+}
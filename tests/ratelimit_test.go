@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stuartshay/gcp-automation-api/internal/config"
+	"github.com/stuartshay/gcp-automation-api/internal/handlers"
+	authmiddleware "github.com/stuartshay/gcp-automation-api/internal/middleware"
+	"github.com/stuartshay/gcp-automation-api/internal/models"
+	"github.com/stuartshay/gcp-automation-api/tests/integration/mocks"
+)
+
+func setupRateLimitedRoutes(t *testing.T, limits *config.RateLimitConfig) (*echo.Echo, *mocks.MockGCPService, string) {
+	e, _, authService := setupTestServer(t)
+
+	gcpService := &mocks.MockGCPService{}
+	handler := handlers.NewHandler(gcpService, authService)
+
+	v1 := e.Group("/api/v1",
+		authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(),
+		authmiddleware.NewRateLimiter(limits).Middleware())
+	v1.GET("/buckets/:name", handler.GetBucket)
+	v1.DELETE("/buckets/:name", handler.DeleteBucket)
+	v1.GET("/projects/:id", handler.GetProject)
+
+	return e, gcpService, generateTestJWT(t, authService)
+}
+
+func TestRateLimitRejectsRunawayCallers(t *testing.T) {
+	e, gcpService, token := setupRateLimitedRoutes(t, &config.RateLimitConfig{
+		Default: config.RouteRateLimits{
+			Read:  &config.TokenBucket{PerSecond: 0.5, Burst: 3},
+			Write: &config.TokenBucket{PerSecond: 0.1, Burst: 1},
+		},
+		Groups: map[string]config.RouteRateLimits{
+			"buckets": {Read: &config.TokenBucket{PerSecond: 0.5, Burst: 2}},
+		},
+	})
+	gcpService.On("GetBucket", "data").Return(&models.BucketResponse{Name: "data"}, nil)
+	gcpService.On("DeleteBucket", "data").Return(nil)
+	gcpService.On("GetProject", "my-project").Return(&models.ProjectResponse{ProjectID: "my-project"}, nil)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send(http.MethodGet, "/api/v1/buckets/data")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get(authmiddleware.HeaderRateLimitLimit))
+	assert.Equal(t, "1", first.Header().Get(authmiddleware.HeaderRateLimitRemaining))
+
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/buckets/data").Code)
+	limited := send(http.MethodGet, "/api/v1/buckets/data")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "2", limited.Header().Get("Retry-After"))
+	assert.Equal(t, "0", limited.Header().Get(authmiddleware.HeaderRateLimitRemaining))
+	assert.Contains(t, limited.Body.String(), `"code":429`)
+
+	// Writes and other route groups have buckets of their own
+	assert.Equal(t, http.StatusOK, send(http.MethodDelete, "/api/v1/buckets/data").Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(http.MethodDelete, "/api/v1/buckets/data").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/projects/my-project").Code)
+}
+
+func TestRateLimitRejectsUnauthenticatedFloods(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("GetBucket", "data").Return(&models.BucketResponse{Name: "data"}, nil)
+	limiter := authmiddleware.NewRateLimiter(&config.RateLimitConfig{
+		Default: config.RouteRateLimits{Read: &config.TokenBucket{PerSecond: 0.1, Burst: 2}},
+	})
+	e.Group("/api/v1",
+		limiter.UnauthenticatedMiddleware(),
+		authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(),
+		limiter.Middleware()).
+		GET("/buckets/:name", handlers.NewHandler(gcpService, authService).GetBucket)
+
+	send := func(remoteAddr, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/data", nil)
+		req.RemoteAddr = remoteAddr
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("198.51.100.7:1234", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, send("198.51.100.7:1234", "Bearer not-a-jwt").Code)
+	limited := send("198.51.100.7:1234", "")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.NotEmpty(t, limited.Header().Get("Retry-After"))
+
+	// The flood does not affect other clients
+	assert.Equal(t, http.StatusUnauthorized, send("203.0.113.9:1234", "").Code)
+	assert.Equal(t, http.StatusOK, send("203.0.113.9:1234", "Bearer "+token).Code)
+}
+
+func TestRateLimitReload(t *testing.T) {
+	e, _, authService := setupTestServer(t)
+	token := generateTestJWT(t, authService)
+
+	gcpService := &mocks.MockGCPService{}
+	gcpService.On("GetBucket", "data").Return(&models.BucketResponse{Name: "data"}, nil)
+	limiter := authmiddleware.NewRateLimiter(&config.RateLimitConfig{
+		Default: config.RouteRateLimits{Read: &config.TokenBucket{PerSecond: 0.1, Burst: 1}},
+	})
+	e.Group("/api/v1", authmiddleware.NewAuthMiddleware(authService.GetConfig(), authService.IdentityProviders()).RequireAuth(), limiter.Middleware()).
+		GET("/buckets/:name", handlers.NewHandler(gcpService, authService).GetBucket)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/buckets/data", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	require.Equal(t, http.StatusOK, send().Code)
+	require.Equal(t, http.StatusTooManyRequests, send().Code)
+
+	// New limits apply to the next request
+	limiter.SetLimits(&config.RateLimitConfig{
+		Default: config.RouteRateLimits{Read: &config.TokenBucket{PerSecond: 1, Burst: 5}},
+	})
+	rec := send()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "5", rec.Header().Get(authmiddleware.HeaderRateLimitLimit))
+}